@@ -0,0 +1,21 @@
+package main
+
+import "math"
+
+const (
+	// DefaultUEREMeters is a typical user-equivalent range error for a single-frequency receiver
+	DefaultUEREMeters = 5.0
+	// MaxPlausibleHdop is the largest HDOP an accuracy estimate is given for; beyond it the
+	// geometry is too poor for the estimate to mean anything
+	MaxPlausibleHdop = 50.0
+)
+
+// EstimateAccuracyMeters approximates the horizontal accuracy of a fix as HDOP × UERE, the
+// user-equivalent range error in meters (see DefaultUEREMeters). It returns 0 when the HDOP is
+// missing (zero or negative), NaN or above MaxPlausibleHdop.
+func EstimateAccuracyMeters(hdop, uereMeters float64) float64 {
+	if math.IsNaN(hdop) || hdop <= 0 || hdop > MaxPlausibleHdop {
+		return 0
+	}
+	return hdop * uereMeters
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateAccuracyMeters(t *testing.T) {
+	tests := []struct {
+		name       string
+		hdop, uere float64
+		want       float64
+	}{
+		{"excellent", 0.8, DefaultUEREMeters, 4},
+		{"good", 1.5, DefaultUEREMeters, 7.5},
+		{"moderate", 5.0, DefaultUEREMeters, 25},
+		{"custom UERE", 1.5, 3, 4.5},
+		{"at the plausible limit", MaxPlausibleHdop, DefaultUEREMeters, 250},
+		{"missing", 0, DefaultUEREMeters, 0},
+		{"negative", -1, DefaultUEREMeters, 0},
+		{"absurd", 99.99, DefaultUEREMeters, 0},
+		{"NaN", math.NaN(), DefaultUEREMeters, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateAccuracyMeters(tt.hdop, tt.uere); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("EstimateAccuracyMeters(%v, %v) = %v, want %v", tt.hdop, tt.uere, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripFunc is an http.RoundTripper that answers requests without a network
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// stubResponse returns a response with the given status and body
+func stubResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     strconv.Itoa(status) + " " + http.StatusText(status),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Header:     make(http.Header),
+	}
+}
+
+func TestAzureIoTPublish(t *testing.T) {
+	var captured *http.Request
+	var body []byte
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	p := &AzureIoTPublisher{
+		HostName:        "myhub.azure-devices.net",
+		DeviceID:        "tachyon 1",
+		Key:             []byte("secret-key"),
+		ContentType:     "application/json",
+		ContentEncoding: "utf-8",
+		Clock:           clock,
+		Client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			captured = req
+			body, _ = io.ReadAll(req.Body)
+			return stubResponse(http.StatusNoContent, ""), nil
+		})},
+	}
+	payload := []byte(`{"Latitude":51.5}`)
+	if err := p.Publish(context.Background(), "ignored/topic", payload, map[string]string{"valid": "true"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "https://myhub.azure-devices.net/devices/tachyon%201/messages/events?api-version=" + azureIoTAPIVersion; captured.URL.String() != want {
+		t.Errorf("URL = %s, want %s", captured.URL, want)
+	}
+	if captured.Method != http.MethodPost || string(body) != string(payload) {
+		t.Errorf("request %s with body %s, want POST with the payload", captured.Method, body)
+	}
+	for header, want := range map[string]string{
+		"Content-Type":           "application/json",
+		"iothub-contenttype":     "application/json",
+		"iothub-contentencoding": "utf-8",
+		"iothub-app-valid":       "true",
+	} {
+		if got := captured.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	// The token signs the URL-encoded resource and expiry with the device key
+	token := strings.TrimPrefix(captured.Header.Get("Authorization"), "SharedAccessSignature ")
+	fields, err := url.ParseQuery(token)
+	if err != nil {
+		t.Fatalf("parsing SAS token %q: %v", token, err)
+	}
+	wantExpiry := strconv.FormatInt(clock.Now().Add(azureSASTokenTTL).Unix(), 10)
+	if fields.Get("sr") != "myhub.azure-devices.net/devices/tachyon 1" || fields.Get("se") != wantExpiry {
+		t.Errorf("SAS token resource %q expiry %q, want the device resource expiring at %s", fields.Get("sr"), fields.Get("se"), wantExpiry)
+	}
+	mac := hmac.New(sha256.New, p.Key)
+	mac.Write([]byte(url.QueryEscape(fields.Get("sr")) + "\n" + fields.Get("se")))
+	if want := base64.StdEncoding.EncodeToString(mac.Sum(nil)); fields.Get("sig") != want {
+		t.Errorf("SAS signature %q, want %q", fields.Get("sig"), want)
+	}
+}
+
+func TestAzureIoTSASTokenRenewal(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	p := &AzureIoTPublisher{HostName: "myhub.azure-devices.net", DeviceID: "dev", Key: []byte("k"), Clock: clock}
+	first := p.sasToken()
+	clock.Advance(azureSASTokenTTL - azureSASRenewBefore - time.Second)
+	if got := p.sasToken(); got != first {
+		t.Error("token renewed before it was close to expiry")
+	}
+	clock.Advance(time.Second)
+	if got := p.sasToken(); got == first {
+		t.Error("token not renewed within the renewal window")
+	}
+}
+
+func TestAzureIoTPublishError(t *testing.T) {
+	p := &AzureIoTPublisher{
+		HostName: "myhub.azure-devices.net", DeviceID: "dev", Key: []byte("k"), Clock: systemClock{},
+		Client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return stubResponse(http.StatusUnauthorized, `{"Message":"ErrorCode:IotHubUnauthorizedAccess"}`), nil
+		})},
+	}
+	err := p.Publish(context.Background(), "", []byte(`{}`), nil)
+	if err == nil || !strings.Contains(err.Error(), "IotHubUnauthorizedAccess") {
+		t.Errorf("Publish() error = %v, want the IoT Hub message", err)
+	}
+}
+
+func TestParseAzureConnectionString(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("secret-key"))
+	tests := []struct {
+		name    string
+		connStr string
+		wantErr bool
+	}{
+		{"complete", "HostName=myhub.azure-devices.net;DeviceId=tachyon-1;SharedAccessKey=" + key, false},
+		{"spaces and extra parts", " HostName=myhub.azure-devices.net ; DeviceId=tachyon-1;GatewayHostName=gw;SharedAccessKey=" + key, false},
+		{"missing key", "HostName=myhub.azure-devices.net;DeviceId=tachyon-1", true},
+		{"missing device", "HostName=myhub.azure-devices.net;SharedAccessKey=" + key, true},
+		{"key not base64", "HostName=myhub.azure-devices.net;DeviceId=tachyon-1;SharedAccessKey=not*base64", true},
+		{"empty", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, device, gotKey, err := ParseAzureConnectionString(tt.connStr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAzureConnectionString() error = %v, want error %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && (host != "myhub.azure-devices.net" || device != "tachyon-1" || string(gotKey) != "secret-key") {
+				t.Errorf("ParseAzureConnectionString() = %q, %q, %q", host, device, gotKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// BirthMessage summarizes the device's runtime configuration. It's published retained to
+// <topic>/birth at startup so consumers connecting later can see how the device is set up.
+type BirthMessage struct {
+	DeviceID            string   `json:"device_id"`
+	Version             string   `json:"version"`
+	StartedAt           string   `json:"started_at"`   // RFC3339 process start time
+	PublishedAt         string   `json:"published_at"` // RFC3339 time this message was published
+	PollIntervalSeconds float64  `json:"poll_interval_seconds"`
+	PayloadFormat       string   `json:"payload_format"`
+	Sinks               []string `json:"sinks"`    // Destinations fix payloads are published to
+	Features            []string `json:"features"` // Sorted names of the enabled optional features
+}
+
+// NewBirthMessage builds a birth message listing the features set to true in features
+func NewBirthMessage(deviceID string, started, now time.Time, pollInterval time.Duration, payloadFormat string, sinks []string, features map[string]bool) BirthMessage {
+	enabled := []string{}
+	for name, on := range features {
+		if on {
+			enabled = append(enabled, name)
+		}
+	}
+	sort.Strings(enabled)
+	return BirthMessage{
+		DeviceID:            deviceID,
+		Version:             version,
+		StartedAt:           started.UTC().Format(time.RFC3339),
+		PublishedAt:         now.UTC().Format(time.RFC3339),
+		PollIntervalSeconds: pollInterval.Seconds(),
+		PayloadFormat:       payloadFormat,
+		Sinks:               sinks,
+		Features:            enabled,
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewBirthMessage(t *testing.T) {
+	started := time.Date(2024, 1, 1, 12, 0, 0, 0, time.FixedZone("BST", 3600))
+	now := started.Add(90 * time.Second)
+	features := map[string]bool{"smoothing": true, "geofence": false, "crc": true, "health_status": true}
+	birth := NewBirthMessage("tachyon-1", started, now, 5*time.Second, PayloadFormatMsgpack, []string{"mqtt", "webhook"}, features)
+
+	want := BirthMessage{
+		DeviceID:            "tachyon-1",
+		Version:             version,
+		StartedAt:           "2024-01-01T11:00:00Z",
+		PublishedAt:         "2024-01-01T11:01:30Z",
+		PollIntervalSeconds: 5,
+		PayloadFormat:       PayloadFormatMsgpack,
+		Sinks:               []string{"mqtt", "webhook"},
+		Features:            []string{"crc", "health_status", "smoothing"},
+	}
+	if !reflect.DeepEqual(birth, want) {
+		t.Errorf("NewBirthMessage() = %+v, want %+v", birth, want)
+	}
+}
+
+func TestBirthMessageReflectsReload(t *testing.T) {
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := NewBirthMessage("tachyon-1", started, started, 5*time.Second, PayloadFormatJSON, []string{"mqtt"}, nil)
+	after := NewBirthMessage("tachyon-1", started, started.Add(time.Hour), 30*time.Second, PayloadFormatJSON, []string{"mqtt"}, nil)
+	if before.PollIntervalSeconds != 5 || after.PollIntervalSeconds != 30 {
+		t.Errorf("poll interval %v before and %v after reload, want 5 and 30", before.PollIntervalSeconds, after.PollIntervalSeconds)
+	}
+	if after.StartedAt != before.StartedAt || after.PublishedAt == before.PublishedAt {
+		t.Errorf("reloaded birth started %s published %s, want the original start and a new publish time", after.StartedAt, after.PublishedAt)
+	}
+}
+
+func TestBirthMessageJSON(t *testing.T) {
+	// No features still publishes an empty list rather than null
+	birth := NewBirthMessage("tachyon-1", time.Time{}, time.Time{}, time.Second, PayloadFormatJSON, []string{"mqtt"}, nil)
+	payload, err := json.Marshal(birth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"device_id", "version", "started_at", "published_at", "poll_interval_seconds", "payload_format", "sinks", "features"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("payload %s is missing %q", payload, key)
+		}
+	}
+	if features, ok := got["features"].([]any); !ok || len(features) != 0 {
+		t.Errorf("features = %v, want an empty list", got["features"])
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import "math"
+
+// Cayenne Low Power Payload constants for GPS data
+const (
+	CayenneTypeGPS    = 0x88 // LPP data type for a GPS location
+	CayenneGPSChannel = 1    // Channel the fix is published on
+)
+
+// EncodeCayenneGPS encodes a location as a Cayenne LPP GPS frame: the channel, the 0x88 type
+// and then latitude and longitude in units of 0.0001° and altitude in units of 0.01m, each a
+// big-endian 24-bit signed integer
+func EncodeCayenneGPS(channel uint8, lat, lon, alt float64) []byte {
+	frame := make([]byte, 0, 11)
+	frame = append(frame, channel, CayenneTypeGPS)
+	frame = appendInt24(frame, lat*10000)
+	frame = appendInt24(frame, lon*10000)
+	frame = appendInt24(frame, alt*100)
+	return frame
+}
+
+// appendInt24 rounds v and appends it as a big-endian 24-bit two's complement integer,
+// saturating at the limits of the range
+func appendInt24(b []byte, v float64) []byte {
+	n := int32(max(-(1 << 23), min(math.Round(v), (1<<23)-1)))
+	return append(b, byte(n>>16), byte(n>>8), byte(n))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeCayenneGPS(t *testing.T) {
+	tests := []struct {
+		name          string
+		channel       uint8
+		lat, lon, alt float64
+		want          []byte
+	}{
+		{
+			// The GPS example from the Cayenne LPP documentation
+			name: "reference frame", channel: 1, lat: 42.3519, lon: -87.9094, alt: 10,
+			want: []byte{0x01, 0x88, 0x06, 0x76, 0x5F, 0xF2, 0x96, 0x0A, 0x00, 0x03, 0xE8},
+		},
+		{
+			name: "southern and eastern hemispheres", channel: 3, lat: -33.8688, lon: 151.2093, alt: -2.5,
+			want: []byte{0x03, 0x88, 0xFA, 0xD5, 0x00, 0x17, 0x12, 0x9D, 0xFF, 0xFF, 0x06},
+		},
+		{
+			name: "origin", channel: 0, lat: 0, lon: 0, alt: 0,
+			want: []byte{0x00, 0x88, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name: "rounding to the resolution", channel: 1, lat: 0.00005, lon: -0.00005, alt: 0.004,
+			want: []byte{0x01, 0x88, 0x00, 0x00, 0x01, 0xFF, 0xFF, 0xFF, 0x00, 0x00, 0x00},
+		},
+		{
+			// 24 bits of centimeters only reach ±83.9 km, so higher altitudes saturate
+			name: "altitude out of range", channel: 1, lat: 90, lon: -180, alt: 100000,
+			want: []byte{0x01, 0x88, 0x0D, 0xBB, 0xA0, 0xE4, 0x88, 0xC0, 0x7F, 0xFF, 0xFF},
+		},
+		{
+			name: "negative altitude out of range", channel: 1, lat: 0, lon: 0, alt: -100000,
+			want: []byte{0x01, 0x88, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80, 0x00, 0x00},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EncodeCayenneGPS(tt.channel, tt.lat, tt.lon, tt.alt); !bytes.Equal(got, tt.want) {
+				t.Errorf("EncodeCayenneGPS() = % X, want % X", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import "time"
+
+// Clock abstracts the current time so time-dependent features can be driven deterministically
+type Clock interface {
+	Now() time.Time
+	// After returns a channel that receives the time once d has elapsed
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock backed by the host's wall clock
+type systemClock struct{}
+
+// Now returns the current host time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for d on the host clock
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
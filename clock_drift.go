@@ -0,0 +1,59 @@
+package main
+
+import "time"
+
+// ClockOffset returns how far the host clock is ahead of the GNSS UTC time, negative when
+// it's behind. It returns false when the fix has no usable UTC time.
+func ClockOffset(utc NmeaUtcTime, host time.Time) (time.Duration, bool) {
+	gnssTime, err := utc.Time()
+	if err != nil {
+		return 0, false
+	}
+	return host.Sub(gnssTime), true
+}
+
+// ClockDriftEvent is published when the host clock drifts beyond the threshold or comes back within it
+type ClockDriftEvent struct {
+	Event       string `json:"event"`     // "drift" or "ok"
+	OffsetMs    int64  `json:"offset_ms"` // Host clock minus GNSS time
+	ThresholdMs int64  `json:"threshold_ms"`
+	GnssTime    string `json:"gnss_time"` // RFC3339 GNSS UTC time
+	HostTime    string `json:"host_time"` // RFC3339Nano host time
+}
+
+// ClockDriftMonitor reports transitions of the host clock offset across a threshold
+type ClockDriftMonitor struct {
+	threshold time.Duration
+	drifting  bool
+}
+
+// NewClockDriftMonitor creates a monitor alerting when the absolute offset exceeds threshold
+func NewClockDriftMonitor(threshold time.Duration) *ClockDriftMonitor {
+	return &ClockDriftMonitor{threshold: threshold}
+}
+
+// Check computes the host clock offset for the fix time, returning an event when the offset
+// crosses the threshold in either direction and nil while the drift state is unchanged.
+// ok is false when the fix has no usable UTC time.
+func (m *ClockDriftMonitor) Check(utc NmeaUtcTime, host time.Time) (offset time.Duration, event *ClockDriftEvent, ok bool) {
+	if offset, ok = ClockOffset(utc, host); !ok {
+		return 0, nil, false
+	}
+	drifting := offset > m.threshold || offset < -m.threshold
+	if drifting == m.drifting {
+		return offset, nil, true
+	}
+	m.drifting = drifting
+	gnssTime, _ := utc.Time()
+	event = &ClockDriftEvent{
+		Event:       "ok",
+		OffsetMs:    offset.Milliseconds(),
+		ThresholdMs: m.threshold.Milliseconds(),
+		GnssTime:    gnssTime.Format(time.RFC3339),
+		HostTime:    host.UTC().Format(time.RFC3339Nano),
+	}
+	if drifting {
+		event.Event = "drift"
+	}
+	return offset, event, true
+}
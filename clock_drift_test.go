@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// fixTime is the GNSS UTC time used by the clock drift tests
+var fixTime = NmeaUtcTime{Year: 2024, Month: 6, Date: 1, Hour: 12, Min: 0, Sec: 0}
+
+func TestClockOffset(t *testing.T) {
+	gnss := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		utc    NmeaUtcTime
+		host   time.Time
+		want   time.Duration
+		wantOK bool
+	}{
+		{"in sync", fixTime, gnss, 0, true},
+		{"host ahead", fixTime, gnss.Add(1500 * time.Millisecond), 1500 * time.Millisecond, true},
+		{"host behind", fixTime, gnss.Add(-3 * time.Second), -3 * time.Second, true},
+		{"host in another zone", fixTime, gnss.In(time.FixedZone("CEST", 2*3600)).Add(250 * time.Millisecond), 250 * time.Millisecond, true},
+		{"two digit year", NmeaUtcTime{Year: 24, Month: 6, Date: 1, Hour: 12}, gnss.Add(time.Second), time.Second, true},
+		{"no UTC time yet", NmeaUtcTime{}, gnss, 0, false},
+		{"invalid UTC time", NmeaUtcTime{Year: 2024, Month: 13, Date: 1}, gnss, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ClockOffset(tt.utc, tt.host)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ClockOffset() = %s, %t, want %s, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestClockDriftMonitorThreshold(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+	monitor := NewClockDriftMonitor(2 * time.Second)
+	steps := []struct {
+		name  string
+		skew  time.Duration // Host clock skew applied before the check
+		event string        // Event expected, empty for none
+	}{
+		{"in sync", 0, ""},
+		{"within threshold", 1500 * time.Millisecond, ""},
+		{"at threshold", 500 * time.Millisecond, ""},
+		{"beyond threshold", 500 * time.Millisecond, "drift"},
+		{"still drifting", 10 * time.Second, ""},
+		{"behind beyond threshold", -15 * time.Second, ""},
+		{"corrected", 2500 * time.Millisecond, "ok"},
+	}
+	for _, step := range steps {
+		clock.Advance(step.skew)
+		offset, event, ok := monitor.Check(fixTime, clock.Now())
+		if !ok {
+			t.Fatalf("%s: Check() found no UTC time", step.name)
+		}
+		want := clock.Now().Sub(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+		if offset != want {
+			t.Errorf("%s: offset %s, want %s", step.name, offset, want)
+		}
+		got := ""
+		if event != nil {
+			got = event.Event
+			if event.OffsetMs != want.Milliseconds() || event.ThresholdMs != 2000 || event.GnssTime != "2024-06-01T12:00:00Z" {
+				t.Errorf("%s: event %+v, want offset %d ms against a 2000 ms threshold", step.name, event, want.Milliseconds())
+			}
+		}
+		if got != step.event {
+			t.Errorf("%s: event %q, want %q", step.name, got, step.event)
+		}
+	}
+}
+
+func TestClockDriftMonitorNoUTC(t *testing.T) {
+	monitor := NewClockDriftMonitor(time.Second)
+	if _, event, ok := monitor.Check(NmeaUtcTime{}, time.Now()); ok || event != nil {
+		t.Errorf("Check() without a UTC time = %v, %t, want no event and not ok", event, ok)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+const (
+	// CloudEventsSpecVersion is the CloudEvents specification version emitted
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventTypeFix is the CloudEvents type used for GNSS fixes
+	CloudEventTypeFix = "io.particle.tachyon.gnss.fix"
+)
+
+// CloudEvent represents a CloudEvents v1.0 JSON envelope
+type CloudEvent struct {
+	SpecVersion     string `json:"specversion"`     // CloudEvents specification version
+	Type            string `json:"type"`            // Event type
+	Source          string `json:"source"`          // URI reference identifying the producing device
+	ID              string `json:"id"`              // Unique event identifier
+	Time            string `json:"time"`            // RFC3339 time the event was produced
+	DataContentType string `json:"datacontenttype"` // Media type of Data
+	Data            any    `json:"data"`            // Event payload
+}
+
+// NewCloudEvent wraps data in a CloudEvent with a freshly generated ID
+func NewCloudEvent(eventType, source string, data any) (*CloudEvent, error) {
+	id, err := NewEventID()
+	if err != nil {
+		return nil, err
+	}
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		Type:            eventType,
+		Source:          source,
+		ID:              id,
+		Time:            time.Now().UTC().Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		Data:            data,
+	}, nil
+}
+
+// CloudEventSource builds the CloudEvents source attribute for a device
+func CloudEventSource(deviceID string) string {
+	return fmt.Sprintf("/particle-tachyon-gps-dbus/%s", deviceID)
+}
+
+// NewEventID returns a random RFC 4122 version 4 UUID string
+func NewEventID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate event ID: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
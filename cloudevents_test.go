@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestCloudEventsPayloadAttributes(t *testing.T) {
+	encoder, err := NewPayloadEncoder(PayloadFormatCloudEvents, CloudEventSource("tachyon-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := encoder.Encode(&GnssData{Latitude: 51.5, Longitude: -0.12, Valid: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event map[string]any
+	if err := json.Unmarshal(payload, &event); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+
+	tests := []struct {
+		attr string
+		want string // Empty to only require a non-empty string
+	}{
+		{"specversion", "1.0"},
+		{"type", CloudEventTypeFix},
+		{"source", "/particle-tachyon-gps-dbus/tachyon-1"},
+		{"id", ""},
+		{"time", ""},
+		{"datacontenttype", "application/json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.attr, func(t *testing.T) {
+			got, ok := event[tt.attr].(string)
+			if !ok || got == "" {
+				t.Fatalf("%s = %v, want a non-empty string", tt.attr, event[tt.attr])
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.attr, got, tt.want)
+			}
+		})
+	}
+	if _, err := time.Parse(time.RFC3339Nano, event["time"].(string)); err != nil {
+		t.Errorf("time is not RFC 3339: %v", err)
+	}
+	data, ok := event["data"].(map[string]any)
+	if !ok {
+		t.Fatalf("data = %v, want the fix object", event["data"])
+	}
+	if data["Latitude"] != 51.5 || data["Longitude"] != -0.12 {
+		t.Errorf("data position = %v,%v, want 51.5,-0.12", data["Latitude"], data["Longitude"])
+	}
+}
+
+func TestNewEventID(t *testing.T) {
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	seen := make(map[string]bool)
+	for range 100 {
+		id, err := NewEventID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !uuidV4.MatchString(id) {
+			t.Fatalf("NewEventID() = %q, want a version 4 UUID", id)
+		}
+		if seen[id] {
+			t.Fatalf("NewEventID() repeated %q", id)
+		}
+		seen[id] = true
+	}
+}
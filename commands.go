@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Bounds on a poll interval set through the command topic
+const (
+	MinCommandPollInterval = 100 * time.Millisecond
+	MaxCommandPollInterval = time.Hour
+)
+
+// RuntimeSettings holds the settings that can be changed at runtime through the command topic or
+// a SIGHUP reload. The MQTT client delivers commands on its own goroutine, so every access takes
+// the mutex.
+type RuntimeSettings struct {
+	mu             sync.Mutex
+	pollInterval   time.Duration
+	publishInvalid bool
+}
+
+// NewRuntimeSettings creates runtime settings starting from the configured values
+func NewRuntimeSettings(pollInterval time.Duration, publishInvalid bool) *RuntimeSettings {
+	return &RuntimeSettings{pollInterval: pollInterval, publishInvalid: publishInvalid}
+}
+
+// PollInterval returns how often the modem is polled
+func (s *RuntimeSettings) PollInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pollInterval
+}
+
+// PublishInvalid reports whether fixes without a usable position are published
+func (s *RuntimeSettings) PublishInvalid() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.publishInvalid
+}
+
+// Set replaces both settings at once, as on a config reload, and reports whether the poll
+// interval changed
+func (s *RuntimeSettings) Set(pollInterval time.Duration, publishInvalid bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	intervalChanged := pollInterval != s.pollInterval
+	s.pollInterval, s.publishInvalid = pollInterval, publishInvalid
+	return intervalChanged
+}
+
+// Command is a runtime config change received on <topic>/cmd. Omitted members are left unchanged.
+type Command struct {
+	PollIntervalSeconds *float64 `json:"poll_interval_seconds,omitempty"`
+	PublishInvalid      *bool    `json:"publish_invalid,omitempty"`
+}
+
+// CommandAck is published to <topic>/cmd/ack in reply to every command
+type CommandAck struct {
+	OK                  bool    `json:"ok"`
+	Error               string  `json:"error,omitempty"`
+	PollIntervalSeconds float64 `json:"poll_interval_seconds"` // Settings in effect after the command
+	PublishInvalid      bool    `json:"publish_invalid"`
+}
+
+// ParseCommand decodes a command payload, rejecting unknown members and out-of-range values
+func ParseCommand(payload []byte) (*Command, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.DisallowUnknownFields()
+	var cmd Command
+	if err := dec.Decode(&cmd); err != nil {
+		return nil, fmt.Errorf("invalid command: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("invalid command: trailing data after JSON object")
+	}
+	if cmd.PollIntervalSeconds == nil && cmd.PublishInvalid == nil {
+		return nil, fmt.Errorf("invalid command: no settings given")
+	}
+	if seconds := cmd.PollIntervalSeconds; seconds != nil {
+		// Compared as floats so NaN and values overflowing a Duration are rejected too
+		if !(*seconds >= MinCommandPollInterval.Seconds() && *seconds <= MaxCommandPollInterval.Seconds()) {
+			return nil, fmt.Errorf("poll_interval_seconds must be between %g and %g, got %g",
+				MinCommandPollInterval.Seconds(), MaxCommandPollInterval.Seconds(), *seconds)
+		}
+	}
+	return &cmd, nil
+}
+
+// HandleCommand parses payload and, if it's valid, applies every change it holds at once.
+// It returns the ack to publish and whether the poll interval changed.
+func (s *RuntimeSettings) HandleCommand(payload []byte) (CommandAck, bool) {
+	cmd, err := ParseCommand(payload)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	intervalChanged := false
+	if err == nil {
+		if cmd.PollIntervalSeconds != nil {
+			interval := time.Duration(*cmd.PollIntervalSeconds * float64(time.Second))
+			intervalChanged = interval != s.pollInterval
+			s.pollInterval = interval
+		}
+		if cmd.PublishInvalid != nil {
+			s.publishInvalid = *cmd.PublishInvalid
+		}
+	}
+	ack := CommandAck{OK: err == nil, PollIntervalSeconds: s.pollInterval.Seconds(), PublishInvalid: s.publishInvalid}
+	if err != nil {
+		ack.Error = err.Error()
+	}
+	return ack, intervalChanged
+}
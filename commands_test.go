@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHandleCommand(t *testing.T) {
+	settings := NewRuntimeSettings(10*time.Second, false)
+	steps := []struct {
+		payload         string
+		wantAck         string
+		intervalChanged bool
+	}{
+		{`{"poll_interval_seconds": 5}`, `{"ok":true,"poll_interval_seconds":5,"publish_invalid":false}`, true},
+		{`{"publish_invalid": true}`, `{"ok":true,"poll_interval_seconds":5,"publish_invalid":true}`, false},
+		{`{"poll_interval_seconds": 5}`, `{"ok":true,"poll_interval_seconds":5,"publish_invalid":true}`, false},
+		{`{"poll_interval_seconds": 0.5, "publish_invalid": false}`, `{"ok":true,"poll_interval_seconds":0.5,"publish_invalid":false}`, true},
+		// Rejected commands change nothing, even the valid parts
+		{`{"poll_interval_seconds": 0.01, "publish_invalid": true}`,
+			`{"ok":false,"error":"poll_interval_seconds must be between 0.1 and 3600, got 0.01","poll_interval_seconds":0.5,"publish_invalid":false}`, false},
+		{`{"poll_interval_seconds": 3601}`,
+			`{"ok":false,"error":"poll_interval_seconds must be between 0.1 and 3600, got 3601","poll_interval_seconds":0.5,"publish_invalid":false}`, false},
+		{`{"publish_invalid": true, "qos": 2}`,
+			`{"ok":false,"error":"invalid command: json: unknown field \"qos\"","poll_interval_seconds":0.5,"publish_invalid":false}`, false},
+		{`{}`, `{"ok":false,"error":"invalid command: no settings given","poll_interval_seconds":0.5,"publish_invalid":false}`, false},
+		{`{"publish_invalid": "yes"}`, "", false},
+		{`{"publish_invalid": true} {}`, "", false},
+		{`not json`, "", false},
+	}
+	for i, step := range steps {
+		ack, changed := settings.HandleCommand([]byte(step.payload))
+		raw, err := json.Marshal(ack)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if step.wantAck == "" {
+			if ack.OK || ack.Error == "" || ack.PollIntervalSeconds != 0.5 || ack.PublishInvalid {
+				t.Errorf("step %d: %s acked with %s, want a negative ack with the settings unchanged", i, step.payload, raw)
+			}
+		} else if string(raw) != step.wantAck {
+			t.Errorf("step %d: %s acked with %s, want %s", i, step.payload, raw, step.wantAck)
+		}
+		if changed != step.intervalChanged {
+			t.Errorf("step %d: %s changed the interval %t, want %t", i, step.payload, changed, step.intervalChanged)
+		}
+		if got := settings.PollInterval().Seconds(); got != ack.PollIntervalSeconds {
+			t.Errorf("step %d: poll interval %vs, ack says %vs", i, got, ack.PollIntervalSeconds)
+		}
+		if got := settings.PublishInvalid(); got != ack.PublishInvalid {
+			t.Errorf("step %d: publish invalid %t, ack says %t", i, got, ack.PublishInvalid)
+		}
+	}
+}
+
+func TestRuntimeSettingsSet(t *testing.T) {
+	settings := NewRuntimeSettings(10*time.Second, false)
+	if !settings.Set(5*time.Second, true) {
+		t.Error("Set() with a new interval reported no change")
+	}
+	if settings.Set(5*time.Second, false) {
+		t.Error("Set() with the same interval reported a change")
+	}
+	if settings.PollInterval() != 5*time.Second || settings.PublishInvalid() {
+		t.Errorf("settings = %v, %t, want 5s and false", settings.PollInterval(), settings.PublishInvalid())
+	}
+}
+
+func TestHandleCommandConcurrent(t *testing.T) {
+	settings := NewRuntimeSettings(10*time.Second, false)
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			settings.HandleCommand([]byte(`{"poll_interval_seconds": 2, "publish_invalid": true}`))
+		}()
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				settings.PollInterval()
+			} else {
+				settings.PublishInvalid()
+			}
+		}()
+	}
+	wg.Wait()
+	if settings.PollInterval() != 2*time.Second || !settings.PublishInvalid() {
+		t.Errorf("settings = %v, %t, want 2s and true", settings.PollInterval(), settings.PublishInvalid())
+	}
+}
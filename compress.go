@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// PayloadCompressionGzip is the supported value of PAYLOAD_COMPRESSION
+const PayloadCompressionGzip = "gzip"
+
+// GzipTopicSuffix is appended to the fix topic for gzip payloads over MQTT 3.1.1, which has no
+// message properties to flag the encoding with
+const GzipTopicSuffix = "/gz"
+
+// compressPayload gzip-compresses payload at the best compression level, since fixes are small
+// and cellular bytes cost more than CPU
+func compressPayload(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressPayload reverses compressPayload
+func decompressPayload(payload []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestCompressPayloadRoundTrip(t *testing.T) {
+	fix := GnssData{
+		DeviceID: "tachyon-1", Latitude: 51.5007, Longitude: -0.1246, Altitude: 35.2, SpeedUnit: SpeedUnitKmh,
+		Svnum: 9, Utc: NmeaUtcTime{Year: 2024, Month: 6, Date: 1, Hour: 12, Min: 30, Sec: 15},
+		Slmsg: []NmeaSatelliteMsg{{Num: 5, Eledeg: 40, Azideg: 120, SN: 38}, {Num: 7, Eledeg: 12, Azideg: 300, SN: 21}},
+	}
+	payload, err := json.Marshal(fix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, original := range [][]byte{payload, {}, []byte("{}")} {
+		compressed, err := compressPayload(original)
+		if err != nil {
+			t.Fatalf("compressPayload() = %v", err)
+		}
+		if len(compressed) < 2 || compressed[0] != 0x1f || compressed[1] != 0x8b {
+			t.Errorf("compressPayload(%q) = % x, want a gzip stream", original, compressed)
+		}
+		got, err := decompressPayload(compressed)
+		if err != nil {
+			t.Fatalf("decompressPayload() = %v", err)
+		}
+		if !bytes.Equal(got, original) {
+			t.Errorf("round trip of %q gave %q", original, got)
+		}
+	}
+
+	compressed, err := compressPayload(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compressed) >= len(payload) {
+		t.Errorf("compressed fix is %d bytes, no smaller than the %d byte JSON", len(compressed), len(payload))
+	}
+}
+
+func TestDecompressPayloadRejectsGarbage(t *testing.T) {
+	if _, err := decompressPayload([]byte(`{"Latitude":51.5}`)); err == nil {
+		t.Error("decompressPayload() of plain JSON succeeded, want an error")
+	}
+	compressed, err := compressPayload([]byte(`{"Latitude":51.5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decompressPayload(compressed[:len(compressed)-4]); err == nil {
+		t.Error("decompressPayload() of a truncated stream succeeded, want an error")
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import "math"
+
+// ConfidenceScore blends fix geometry, satellite count and signal strength into a 0-100 score:
+//
+//   - 50% HDOP: 1.0 or better scores full marks, falling linearly to nothing at 10.0
+//   - 25% satellites used in the solution (SatellitesUsed, not those merely in view): 4
+//     scores nothing, rising linearly to full marks at 12
+//   - 25% average SNR of tracked satellites: 20 dB-Hz scores nothing, rising linearly to full marks at 45 dB-Hz
+//
+// Invalid fixes and fixes without an HDOP score 0.
+func (d *GnssData) ConfidenceScore() int {
+	if d.Valid == 0 || d.Hdop <= 0 {
+		return 0
+	}
+	hdop := clamp01((10 - d.Hdop) / 9)
+
+	sats := clamp01(float64(d.SatellitesUsed-4) / 8)
+
+	snr := clamp01((AverageSNR(d.Satellites()) - 20) / 25)
+
+	score := int(math.Round(100 * (0.5*hdop + 0.25*sats + 0.25*snr)))
+	return max(0, min(100, score))
+}
+
+// clamp01 limits v to the range [0, 1]
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
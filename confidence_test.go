@@ -0,0 +1,71 @@
+package main
+
+import "testing"
+
+// satellitesWithSNR returns GPS satellites with the given SNRs
+func satellitesWithSNR(snrs ...int8) []NmeaSatelliteMsg {
+	sats := make([]NmeaSatelliteMsg, len(snrs))
+	for i, snr := range snrs {
+		sats[i] = NmeaSatelliteMsg{Num: int8(i + 1), Eledeg: 30, Azideg: 90, SN: snr}
+	}
+	return sats
+}
+
+func TestConfidenceScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     GnssData
+		min, max int
+	}{
+		{
+			name: "good fix",
+			data: GnssData{Valid: 1, Hdop: 0.8, SatellitesUsed: 12, Slmsg: satellitesWithSNR(45, 48, 44, 47)},
+			min:  95, max: 100,
+		},
+		{
+			name: "marginal fix",
+			data: GnssData{Valid: 1, Hdop: 5.5, SatellitesUsed: 8, Slmsg: satellitesWithSNR(30, 35)},
+			min:  40, max: 60,
+		},
+		{
+			name: "weak fix",
+			data: GnssData{Valid: 1, Hdop: 9.5, SatellitesUsed: 4, Slmsg: satellitesWithSNR(18, 0)},
+			min:  1, max: 10,
+		},
+		{
+			name: "no fix",
+			data: GnssData{Valid: 0, Hdop: 0.8, SatellitesUsed: 12, Slmsg: satellitesWithSNR(45, 48)},
+			min:  0, max: 0,
+		},
+		{
+			name: "no HDOP",
+			data: GnssData{Valid: 1, SatellitesUsed: 12, Slmsg: satellitesWithSNR(45)},
+			min:  0, max: 0,
+		},
+		{
+			name: "beyond every range",
+			data: GnssData{Valid: 1, Hdop: 0.1, SatellitesUsed: 40, Slmsg: satellitesWithSNR(60, 60)},
+			min:  100, max: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.data.ConfidenceScore(); got < tt.min || got > tt.max {
+				t.Errorf("ConfidenceScore() = %d, want %d to %d", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestConfidenceScoreWeighting(t *testing.T) {
+	// Each component at half marks scores half overall
+	data := GnssData{Valid: 1, Hdop: 5.5, SatellitesUsed: 8, Slmsg: satellitesWithSNR(30, 35)}
+	if got := data.ConfidenceScore(); got != 50 {
+		t.Errorf("ConfidenceScore() = %d, want 50", got)
+	}
+	// HDOP carries half the weight on its own
+	data = GnssData{Valid: 1, Hdop: 1, SatellitesUsed: 4}
+	if got := data.ConfidenceScore(); got != 50 {
+		t.Errorf("ConfidenceScore() with only a perfect HDOP = %d, want 50", got)
+	}
+}
@@ -0,0 +1,735 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultClientIDSuffix is appended to the device ID to form the default MQTT client ID
+const DefaultClientIDSuffix = "-gnss"
+
+// Config holds every setting, read from the environment at startup by readConfig. A SIGHUP
+// reload runs readConfig again but only applies the subset in ReloadableSettings.
+type Config struct {
+	DeviceID    string // DEVICE_ID, defaulting to the hostname, sanitized for use as a topic level
+	DeviceTopic string // <MQTT_TOPIC>/<DeviceID>, the prefix of every topic published
+
+	MQTTBrokerURL    string
+	MQTTBrokerPort   string
+	MQTTTopic        string
+	MQTTUsername     string
+	MQTTPassword     string
+	MQTTQoS          byte // QoS of fix payloads
+	MQTTRetain       bool // Publish fix payloads retained
+	MQTTClientID     string
+	MQTTCleanSession bool
+	MQTTProtocol     string            // MQTTProtocol311 or MQTTProtocol5 for fix payloads
+	MQTTUserProps    map[string]string // MQTT 5 user properties added to every fix payload
+	MQTTCACert       string            // Extra CA certificate file trusted for the broker
+	MQTTClientCert   string            // Client certificate file for mutual TLS, with MQTTClientKey
+	MQTTClientKey    string
+	MQTTMaxReconnect time.Duration // Longest backoff between reconnect attempts
+	MQTTCommands     bool          // Accept settings changes on <topic>/cmd
+
+	PollInterval    time.Duration
+	PublishInvalid  bool    // Publish fixes without a valid position
+	MovingThreshold float64 // MOVING_SPEED_THRESHOLD, the minimum speed counted as moving, in SpeedUnit
+
+	PayloadFormat      string            // PAYLOAD_FORMAT, or its alias OUTPUT_FORMAT
+	PayloadCRC         bool              // Append a CRC-32 to JSON payloads
+	PayloadEncKey      []byte            // AES-256-GCM key, nil to publish in the clear
+	PayloadCompression string            // "" or PayloadCompressionGzip
+	PayloadSplit       bool              // Publish position and satellite detail separately
+	SatellitesInterval time.Duration     // How often split mode publishes the satellite detail
+	NMEASplit          bool              // Per-constellation GGA sentences
+	NMEABeidouTalker   string            // TalkerBeidou or TalkerBeidouLegacy
+	Rounding           map[string]int    // Decimal places per field, see ParseRoundingRules
+	InfluxMeasurement  string            // Measurement name for the influx format
+	InfluxTags         map[string]string // Tag set for the influx format, host=<DeviceID> by default
+	ValidateSchema     string            // "", SchemaModeLog or SchemaModeDrop
+
+	SpeedUnit            string
+	UEREMeters           float64 // User equivalent range error behind the accuracy estimate
+	UsableSNR            int     // Minimum SNR in dB-Hz counted as a usable satellite
+	KeepSatellitePadding bool    // Keep the modem's zero-filled satellite slots
+	SatelliteMinSNR      int     // Satellites below this SNR in dB-Hz are left out of the arrays, 0 to keep all
+	RangeCheck           string  // RangeCheckOff, RangeCheckWarn or RangeCheckStrict
+
+	ZonesFile       string
+	GeofenceCenter  *[2]float64 // Latitude and longitude, nil without a geofence
+	GeofenceRadiusM float64
+	SanityBox       *BoundingBox
+	RefPoint        *[2]float64 // Surveyed REF_LAT and REF_LON, nil when unset
+
+	MedianFilterWindow  int // 0 to disable
+	MaxSpeedMs          float64
+	SmoothWindow        int // 0 to disable
+	LeverArm            *LeverArm
+	HeadingMinDistanceM float64
+	OdometerPath        string
+	OdometerMaxSpeedMs  float64
+	VerticalSpeed       bool
+	SampleEveryM        float64 // Distance between published records, 0 to publish every fix
+	EventMinInterval    time.Duration
+	GeohashPrecision    int             // 0 to leave out the geohash
+	CoordFormats        map[string]bool // Extra coordinate formats, see ParseCoordFormats
+	IncludeConfidence   bool
+	IncludeStreaks      bool
+	IncludeNetworkType  bool
+	FixEvents           bool
+
+	LatestFixPath     string
+	DisplayStatusPath string
+	DisplayWidth      int
+
+	SNRHistogramInterval time.Duration
+	StartupGrace         time.Duration
+	MaxRuntime           time.Duration
+	ClockDriftThreshold  time.Duration
+	DailyRollup          bool // DAILY_ROLLUP_TIME is set, to DailyRollupHour:DailyRollupMinute
+	DailyRollupHour      int
+	DailyRollupMinute    int
+
+	GeocoderURL         string
+	GeocoderKey         string
+	GeocoderMinInterval time.Duration
+	GeocoderCacheMeters float64
+
+	PublishWindows        []TimeWindow
+	PublishOnlyWhenMoving bool
+	MinMoveMeters         float64
+	Heartbeat             time.Duration
+	IdleInterval          time.Duration // Poll interval while stationary, 0 to disable adaptive polling
+	StationaryPeriod      time.Duration
+	MovingFixes           int
+
+	PromRemoteWriteURL string
+	AzureIoTHostName   string // From AZURE_IOT_CONNSTR, empty when unset
+	AzureIoTDeviceID   string
+	AzureIoTKey        []byte
+	WebhookURL         string
+	WebhookTimeout     time.Duration
+	WebhookMaxBackoff  time.Duration
+	WebhookHeaders     map[string]string
+	PGDSN              string
+	PGTable            string
+	PGBatchSize        int
+	PGFlushInterval    time.Duration
+	QueueDir           string // QUEUE_DIR, or its alias QUEUE_PATH
+	QueueMaxBytes      int64
+	QueueMaxEntries    int // 0 for no limit
+	GPXOutputDir       string
+	GPXMaxBytes        int64
+	RecordPath         string
+	HADiscovery        bool
+	BirthMessage       bool
+
+	MetricsListenAddr     string
+	PprofListenAddr       string
+	HTTPListenAddr        string
+	HealthMaxAge          *time.Duration // nil to derive it from the poll interval
+	HealthPublishInterval time.Duration
+	OTLPEndpoint          string // OTEL_EXPORTER_OTLP_ENDPOINT, empty to disable tracing
+
+	DbusBus            string
+	DbusAddress        string
+	GnssDbusDest       string
+	GnssDbusPath       string
+	GnssDbusMethod     string
+	GnssDbusRTCMMethod string // Empty for InjectRtcm on the GNSS_DBUS_METHOD interface
+	DbusCallTimeout    time.Duration
+	DbusRetryAttempts  int
+	DbusRetryDelay     time.Duration
+	ReplayPath         string
+	ReplaySpeed        float64
+	GnssSignals        bool
+	GnssSignalTimeout  time.Duration
+	RepublishStale     bool // Republish the last known good fix in place of failed reads and invalid fixes
+	StaleMaxAge        time.Duration
+	NTRIPAddress       string // Host and port from NTRIP_URL, empty when unset
+	NTRIPMountpoint    string
+	NTRIPUsername      string
+	NTRIPPassword      string
+
+	DryRun      bool // Print payloads to stdout instead of connecting to MQTT
+	WebhookOnly bool // WEBHOOK_URL is set without MQTT_BROKER_URL, so fixes go only to the webhook
+
+	MemoryLimit int64 // Soft memory limit in bytes, 0 to leave the runtime default
+	MaxProcs    int   // GOMAXPROCS override, 0 to leave the runtime default
+}
+
+// envReader reads settings from the environment, collecting every malformed value rather than
+// stopping at the first. A malformed value reads as the default, so validateConfig doesn't
+// report it a second time.
+type envReader struct {
+	errs []error
+}
+
+// check records err, if any, prefixed with the variable it came from
+func (r *envReader) check(key string, err error) {
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s: %w", key, err))
+	}
+}
+
+// boolean reads an optional boolean variable
+func (r *envReader) boolean(key string, def bool) bool {
+	b, err := getEnvBool(key, def)
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return def
+	}
+	return b
+}
+
+// integer reads an optional integer variable
+func (r *envReader) integer(key string, def int) int {
+	i, err := getEnvInt(key, def)
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return def
+	}
+	return i
+}
+
+// float reads an optional floating point variable
+func (r *envReader) float(key string, def float64) float64 {
+	f, err := getEnvFloat(key, def)
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return def
+	}
+	return f
+}
+
+// seconds reads an optional duration given as a number of seconds
+func (r *envReader) seconds(key string, def time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+	seconds, err := strconv.ParseFloat(val, 64)
+	if err != nil || math.IsNaN(seconds) || math.Abs(seconds) > math.MaxInt64/float64(time.Second) {
+		r.errs = append(r.errs, fmt.Errorf("invalid value for %s: %q is not a number of seconds", key, val))
+		return def
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// readConfig reads every setting from the environment and validates it. Rather than stopping
+// at the first problem it reports every missing or invalid variable at once, joined with
+// errors.Join.
+func readConfig() (*Config, error) {
+	cfg := &Config{}
+	r := &envReader{}
+	cfg.DryRun = r.boolean("DRY_RUN", false)
+	cfg.DeviceID = os.Getenv("DEVICE_ID")
+	if cfg.DeviceID == "" {
+		var err error
+		if cfg.DeviceID, err = os.Hostname(); err != nil {
+			r.errs = append(r.errs, fmt.Errorf("DEVICE_ID is unset and the hostname is unavailable: %w", err))
+		}
+	}
+	var err error
+	cfg.DeviceID, err = SanitizeDeviceID(cfg.DeviceID)
+	r.check("DEVICE_ID", err)
+	// Without a broker URL a webhook replaces MQTT rather than adding to it
+	cfg.WebhookOnly = !cfg.DryRun && os.Getenv("WEBHOOK_URL") != "" && os.Getenv("MQTT_BROKER_URL") == ""
+	if cfg.DryRun || cfg.WebhookOnly {
+		// Nothing is sent to a broker, so the MQTT settings aren't needed
+		cfg.MQTTTopic = getEnvDefault("MQTT_TOPIC", DefaultDryRunTopic)
+	} else {
+		readMQTTConfig(cfg, r)
+	}
+	cfg.DeviceTopic = cfg.MQTTTopic + "/" + cfg.DeviceID
+	cfg.MQTTMaxReconnect = r.seconds("MQTT_MAX_RECONNECT_INTERVAL_SECONDS", time.Minute)
+	cfg.MQTTCommands = r.boolean("MQTT_COMMANDS", false)
+
+	cfg.PollInterval = DefaultPollInterval
+	if val := os.Getenv("POLL_INTERVAL_SECONDS"); val != "" {
+		if interval, err := ParsePollInterval(val); err != nil {
+			r.errs = append(r.errs, err)
+		} else {
+			cfg.PollInterval = interval
+		}
+	}
+	cfg.PublishInvalid = r.boolean("PUBLISH_INVALID_FIXES", false)
+	cfg.MovingThreshold = r.float("MOVING_SPEED_THRESHOLD", 1)
+
+	readPayloadConfig(cfg, r)
+	readFixConfig(cfg, r)
+	readSinkConfig(cfg, r)
+	readGnssConfig(cfg, r)
+
+	if limit := os.Getenv("MEMORY_LIMIT"); limit != "" {
+		var err error
+		cfg.MemoryLimit, err = ParseByteSize(limit)
+		r.check("MEMORY_LIMIT", err)
+	}
+	cfg.MaxProcs = r.integer("MAX_PROCS", 0)
+
+	if err := errors.Join(append(r.errs, validateConfig(cfg))...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// readMQTTConfig reads the MQTT connection settings into cfg
+func readMQTTConfig(cfg *Config, r *envReader) {
+	required := func(key string, dst *string) {
+		val, err := getEnv(key)
+		if err != nil {
+			r.errs = append(r.errs, err)
+		}
+		*dst = val
+	}
+	required("MQTT_BROKER_URL", &cfg.MQTTBrokerURL)
+	required("MQTT_BROKER_PORT", &cfg.MQTTBrokerPort)
+	required("MQTT_TOPIC", &cfg.MQTTTopic)
+	required("MQTT_USERNAME", &cfg.MQTTUsername)
+	required("MQTT_PASSWORD", &cfg.MQTTPassword)
+
+	qos := r.integer("MQTT_QOS", 0)
+	if qos < 0 || qos > 2 {
+		r.errs = append(r.errs, fmt.Errorf("MQTT_QOS must be 0, 1 or 2, got %d", qos))
+	}
+	cfg.MQTTQoS = byte(qos)
+	cfg.MQTTRetain = r.boolean("MQTT_RETAIN", false)
+
+	// A stable client ID lets the broker resume the session and apply per-client ACLs
+	cfg.MQTTClientID = getEnvDefault("MQTT_CLIENT_ID", cfg.DeviceID+DefaultClientIDSuffix)
+	cfg.MQTTCleanSession = r.boolean("MQTT_CLEAN_SESSION", true)
+
+	cfg.MQTTProtocol = getEnvDefault("MQTT_PROTOCOL", MQTTProtocol311)
+	if userProps := os.Getenv("MQTT_USER_PROPERTIES"); userProps != "" {
+		var err error
+		cfg.MQTTUserProps, err = ParseUserProperties(userProps)
+		r.check("MQTT_USER_PROPERTIES", err)
+	}
+
+	cfg.MQTTCACert = os.Getenv("MQTT_CA_CERT")
+	cfg.MQTTClientCert = os.Getenv("MQTT_CLIENT_CERT")
+	cfg.MQTTClientKey = os.Getenv("MQTT_CLIENT_KEY")
+}
+
+// readPayloadConfig reads the payload encoding settings into cfg
+func readPayloadConfig(cfg *Config, r *envReader) {
+	var err error
+	cfg.PayloadFormat = getEnvDefault("PAYLOAD_FORMAT", getEnvDefault("OUTPUT_FORMAT", PayloadFormatJSON))
+	if outputFormat := os.Getenv("OUTPUT_FORMAT"); outputFormat != "" && outputFormat != cfg.PayloadFormat {
+		r.errs = append(r.errs, fmt.Errorf("PAYLOAD_FORMAT %q and OUTPUT_FORMAT %q conflict", cfg.PayloadFormat, outputFormat))
+	}
+	cfg.PayloadCRC = r.boolean("PAYLOAD_CRC", false)
+	if encKey := os.Getenv("PAYLOAD_ENC_KEY"); encKey != "" {
+		cfg.PayloadEncKey, err = ParsePayloadKey(encKey)
+		r.check("PAYLOAD_ENC_KEY", err)
+	}
+	cfg.PayloadCompression = os.Getenv("PAYLOAD_COMPRESSION")
+	cfg.PayloadSplit = r.boolean("PAYLOAD_SPLIT", false)
+	cfg.SatellitesInterval = r.seconds("SATELLITES_INTERVAL_SECONDS", time.Minute)
+	cfg.NMEASplit = r.boolean("NMEA_SPLIT_CONSTELLATIONS", false)
+	cfg.NMEABeidouTalker = getEnvDefault("NMEA_BEIDOU_TALKER", TalkerBeidou)
+	if rounding := os.Getenv("ROUNDING"); rounding != "" {
+		cfg.Rounding, err = ParseRoundingRules(rounding)
+		r.check("ROUNDING", err)
+	}
+	cfg.InfluxMeasurement = getEnvDefault("INFLUX_MEASUREMENT", DefaultInfluxMeasurement)
+	cfg.InfluxTags = map[string]string{"host": cfg.DeviceID}
+	if tags := os.Getenv("INFLUX_TAGS"); tags != "" {
+		cfg.InfluxTags, err = ParseUserProperties(tags)
+		r.check("INFLUX_TAGS", err)
+	}
+	cfg.ValidateSchema = os.Getenv("VALIDATE_SCHEMA")
+}
+
+// readFixConfig reads the settings for filtering and deriving fields from each fix into cfg
+func readFixConfig(cfg *Config, r *envReader) {
+	var err error
+	cfg.SpeedUnit = getEnvDefault("SPEED_UNIT", SpeedUnitRaw)
+	cfg.UEREMeters = r.float("UERE_METERS", DefaultUEREMeters)
+	cfg.UsableSNR = r.integer("USABLE_SNR_THRESHOLD", DefaultUsableSNR)
+	cfg.KeepSatellitePadding = r.boolean("KEEP_SATELLITE_PADDING", false)
+	cfg.SatelliteMinSNR = r.integer("SATELLITE_MIN_SNR", 0)
+	cfg.RangeCheck = getEnvDefault("RANGE_CHECK", RangeCheckStrict)
+
+	cfg.ZonesFile = os.Getenv("ZONES_FILE")
+	geofenceCenter, geofenceRadius := os.Getenv("GEOFENCE_CENTER"), os.Getenv("GEOFENCE_RADIUS_M")
+	if (geofenceCenter == "") != (geofenceRadius == "") {
+		r.errs = append(r.errs, fmt.Errorf("GEOFENCE_CENTER and GEOFENCE_RADIUS_M must be set together"))
+	} else if geofenceCenter != "" {
+		lat, lon, err := ParseGeofenceCenter(geofenceCenter)
+		r.check("GEOFENCE_CENTER", err)
+		cfg.GeofenceCenter = &[2]float64{lat, lon}
+		cfg.GeofenceRadiusM = r.float("GEOFENCE_RADIUS_M", 0)
+	}
+	if bbox := os.Getenv("SANITY_BBOX"); bbox != "" {
+		box, err := ParseBoundingBox(bbox)
+		r.check("SANITY_BBOX", err)
+		cfg.SanityBox = &box
+	}
+	refLat, refLon := os.Getenv("REF_LAT"), os.Getenv("REF_LON")
+	if (refLat == "") != (refLon == "") {
+		r.errs = append(r.errs, fmt.Errorf("REF_LAT and REF_LON must be set together"))
+	} else if refLat != "" {
+		cfg.RefPoint = &[2]float64{r.float("REF_LAT", 0), r.float("REF_LON", 0)}
+	}
+
+	cfg.MedianFilterWindow = r.integer("MEDIAN_FILTER_WINDOW", 0)
+	cfg.MaxSpeedMs = r.float("MAX_SPEED_MS", 0)
+	cfg.SmoothWindow = r.integer("SMOOTH_WINDOW", 0)
+	if offset := os.Getenv("LEVER_ARM_M"); offset != "" {
+		arm, err := ParseLeverArm(offset)
+		r.check("LEVER_ARM_M", err)
+		cfg.LeverArm = &arm
+	}
+	cfg.HeadingMinDistanceM = r.float("HEADING_MIN_DISTANCE_M", HeadingMinDistance)
+	cfg.OdometerPath = os.Getenv("ODOMETER_PATH")
+	cfg.OdometerMaxSpeedMs = r.float("ODOMETER_MAX_SPEED_MS", DefaultOdometerMaxSpeed)
+	cfg.VerticalSpeed = r.boolean("VERTICAL_SPEED", false)
+	cfg.SampleEveryM = r.float("SAMPLE_EVERY_M", 0)
+	cfg.EventMinInterval = r.seconds("EVENT_MIN_INTERVAL_SECONDS", 0)
+	cfg.GeohashPrecision = r.integer("GEOHASH_PRECISION", 0)
+	cfg.CoordFormats, err = ParseCoordFormats(os.Getenv("COORD_FORMATS"))
+	r.check("COORD_FORMATS", err)
+	if cfg.CoordFormats[CoordFormatGeohash] && cfg.GeohashPrecision == 0 {
+		cfg.GeohashPrecision = DefaultCoordGeohashPrecision
+	}
+	cfg.IncludeConfidence = r.boolean("INCLUDE_CONFIDENCE", false)
+	cfg.IncludeStreaks = r.boolean("INCLUDE_STREAKS", false)
+	cfg.IncludeNetworkType = r.boolean("INCLUDE_NETWORK_TYPE", false)
+	cfg.FixEvents = r.boolean("FIX_EVENTS", false)
+
+	cfg.LatestFixPath = os.Getenv("LATEST_FIX_PATH")
+	cfg.DisplayStatusPath = os.Getenv("DISPLAY_STATUS_PATH")
+	cfg.DisplayWidth = r.integer("DISPLAY_WIDTH", DisplayWidthDefault)
+
+	cfg.SNRHistogramInterval = r.seconds("SNR_HISTOGRAM_INTERVAL_SECONDS", 0)
+	cfg.StartupGrace = r.seconds("STARTUP_GRACE_SECONDS", 0)
+	cfg.MaxRuntime = r.seconds("MAX_RUNTIME_SECONDS", 0)
+	cfg.ClockDriftThreshold = time.Duration(r.integer("CLOCK_DRIFT_THRESHOLD_MS", 0)) * time.Millisecond
+	if rollupTime := os.Getenv("DAILY_ROLLUP_TIME"); rollupTime != "" {
+		cfg.DailyRollupHour, cfg.DailyRollupMinute, err = ParseTimeOfDay(rollupTime)
+		r.check("DAILY_ROLLUP_TIME", err)
+		cfg.DailyRollup = true
+	}
+
+	cfg.GeocoderURL = os.Getenv("GEOCODER_URL")
+	cfg.GeocoderKey = os.Getenv("GEOCODER_KEY")
+	cfg.GeocoderMinInterval = r.seconds("GEOCODER_MIN_INTERVAL_SECONDS", time.Minute)
+	cfg.GeocoderCacheMeters = r.float("GEOCODER_CACHE_METERS", 100)
+
+	if windows := os.Getenv("PUBLISH_WINDOWS"); windows != "" {
+		cfg.PublishWindows, err = ParseTimeWindows(windows)
+		r.check("PUBLISH_WINDOWS", err)
+	}
+	cfg.PublishOnlyWhenMoving = r.boolean("PUBLISH_ONLY_WHEN_MOVING", false)
+	cfg.MinMoveMeters = r.float("MIN_MOVE_METERS", 0)
+	cfg.Heartbeat = r.seconds("HEARTBEAT_SECONDS", 5*time.Minute)
+	cfg.IdleInterval = r.seconds("IDLE_INTERVAL_SECONDS", 0)
+	cfg.StationaryPeriod = r.seconds("STATIONARY_SECONDS", DefaultStationaryPeriod)
+	cfg.MovingFixes = r.integer("MOVING_FIXES", DefaultMovingFixes)
+}
+
+// readSinkConfig reads the settings of the destinations and servers besides the MQTT broker
+// into cfg
+func readSinkConfig(cfg *Config, r *envReader) {
+	var err error
+	cfg.PromRemoteWriteURL = os.Getenv("PROM_REMOTE_WRITE_URL")
+	if connStr := os.Getenv("AZURE_IOT_CONNSTR"); connStr != "" {
+		cfg.AzureIoTHostName, cfg.AzureIoTDeviceID, cfg.AzureIoTKey, err = ParseAzureConnectionString(connStr)
+		r.check("AZURE_IOT_CONNSTR", err)
+	}
+	if cfg.WebhookURL = os.Getenv("WEBHOOK_URL"); cfg.WebhookURL != "" {
+		u, err := url.Parse(cfg.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			r.errs = append(r.errs, fmt.Errorf("WEBHOOK_URL must be an http or https URL"))
+		}
+	}
+	cfg.WebhookTimeout = r.seconds("WEBHOOK_TIMEOUT_SECONDS", DefaultWebhookTimeout)
+	cfg.WebhookMaxBackoff = r.seconds("WEBHOOK_MAX_BACKOFF_SECONDS", time.Minute)
+	if headers := os.Getenv("WEBHOOK_HEADERS"); headers != "" {
+		cfg.WebhookHeaders, err = ParseUserProperties(headers)
+		r.check("WEBHOOK_HEADERS", err)
+	}
+	cfg.PGDSN = os.Getenv("PG_DSN")
+	cfg.PGTable = getEnvDefault("PG_TABLE", "gnss_fixes")
+	cfg.PGBatchSize = r.integer("PG_BATCH_SIZE", 50)
+	cfg.PGFlushInterval = r.seconds("PG_FLUSH_SECONDS", 30*time.Second)
+	cfg.QueueDir = getEnvDefault("QUEUE_DIR", os.Getenv("QUEUE_PATH"))
+	cfg.QueueMaxBytes, err = ParseByteSize(getEnvDefault("QUEUE_MAX_BYTES", "10MiB"))
+	r.check("QUEUE_MAX_BYTES", err)
+	cfg.QueueMaxEntries = r.integer("QUEUE_MAX_ENTRIES", 0)
+	cfg.GPXOutputDir = os.Getenv("GPX_OUTPUT_DIR")
+	cfg.GPXMaxBytes = int64(r.integer("GPX_MAX_BYTES", DefaultGPXMaxBytes))
+	cfg.RecordPath = os.Getenv("RECORD_PATH")
+	cfg.HADiscovery = r.boolean("HA_DISCOVERY", false)
+	// The birth message is MQTT-only, so there's no point building it for a webhook-only bridge
+	cfg.BirthMessage = r.boolean("BIRTH_MESSAGE", !cfg.WebhookOnly)
+
+	cfg.MetricsListenAddr = os.Getenv("METRICS_LISTEN_ADDR")
+	cfg.PprofListenAddr = os.Getenv("PPROF_LISTEN_ADDR")
+	cfg.HTTPListenAddr = os.Getenv("HTTP_LISTEN_ADDR")
+	if os.Getenv("HEALTH_MAX_AGE_SECONDS") != "" {
+		maxAge := r.seconds("HEALTH_MAX_AGE_SECONDS", 0)
+		cfg.HealthMaxAge = &maxAge
+	}
+	cfg.HealthPublishInterval = r.seconds("HEALTH_PUBLISH_INTERVAL_SECONDS", 0)
+	cfg.OTLPEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+}
+
+// readGnssConfig reads the settings for reading fixes from the modem, or a recording, into cfg
+func readGnssConfig(cfg *Config, r *envReader) {
+	cfg.DbusBus = getEnvDefault("DBUS_BUS", DbusBusSystem)
+	cfg.DbusAddress = os.Getenv("DBUS_ADDRESS")
+	cfg.GnssDbusDest = getEnvDefault("GNSS_DBUS_DEST", GnssDbusDest)
+	cfg.GnssDbusPath = getEnvDefault("GNSS_DBUS_PATH", GnssDbusPath)
+	cfg.GnssDbusMethod = getEnvDefault("GNSS_DBUS_METHOD", GnssDbusMethod)
+	cfg.GnssDbusRTCMMethod = os.Getenv("GNSS_DBUS_RTCM_METHOD")
+	cfg.DbusCallTimeout = r.seconds("DBUS_CALL_TIMEOUT", DefaultDbusCallTimeout)
+	cfg.DbusRetryAttempts = r.integer("DBUS_RETRY_ATTEMPTS", DefaultDbusRetryAttempts)
+	cfg.DbusRetryDelay = r.seconds("DBUS_RETRY_DELAY_SECONDS", DefaultDbusRetryDelay)
+	cfg.ReplayPath = os.Getenv("REPLAY_PATH")
+	cfg.ReplaySpeed = r.float("REPLAY_SPEED", 1)
+	cfg.GnssSignals = r.boolean("GNSS_SIGNALS", false)
+	cfg.GnssSignalTimeout = r.seconds("GNSS_SIGNAL_TIMEOUT_SECONDS", 30*time.Second)
+	cfg.RepublishStale = r.boolean("REPUBLISH_STALE", false)
+	cfg.StaleMaxAge = r.seconds("STALE_MAX_AGE_SECONDS", DefaultStaleMaxAge)
+	if ntripURL := os.Getenv("NTRIP_URL"); ntripURL != "" {
+		u, err := url.Parse(ntripURL)
+		if err != nil || u.Host == "" {
+			r.errs = append(r.errs, fmt.Errorf("invalid NTRIP_URL %q", ntripURL))
+		} else {
+			cfg.NTRIPAddress = u.Host
+		}
+		mountpoint, err := getEnv("NTRIP_MOUNTPOINT")
+		if err != nil {
+			r.errs = append(r.errs, err)
+		}
+		cfg.NTRIPMountpoint = strings.TrimPrefix(mountpoint, "/")
+	}
+	cfg.NTRIPUsername = os.Getenv("NTRIP_USERNAME")
+	cfg.NTRIPPassword = os.Getenv("NTRIP_PASSWORD")
+}
+
+// validateConfig checks the settings read by readConfig against each other and their allowed
+// ranges, returning every problem found joined with errors.Join
+func validateConfig(cfg *Config) error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	if cfg.MQTTProtocol != "" && cfg.MQTTProtocol != MQTTProtocol311 && cfg.MQTTProtocol != MQTTProtocol5 {
+		fail("MQTT_PROTOCOL must be %q or %q, got %q", MQTTProtocol311, MQTTProtocol5, cfg.MQTTProtocol)
+	}
+	if cfg.MQTTUserProps != nil && cfg.MQTTProtocol != MQTTProtocol5 {
+		fail("MQTT_USER_PROPERTIES requires MQTT_PROTOCOL=%s", MQTTProtocol5)
+	}
+	if (cfg.MQTTClientCert == "") != (cfg.MQTTClientKey == "") {
+		fail("MQTT_CLIENT_CERT and MQTT_CLIENT_KEY must be set together")
+	}
+	if cfg.MQTTMaxReconnect < time.Second {
+		fail("MQTT_MAX_RECONNECT_INTERVAL_SECONDS must be at least 1")
+	}
+
+	if err := ValidatePayloadFormat(cfg.PayloadFormat); err != nil {
+		errs = append(errs, fmt.Errorf("PAYLOAD_FORMAT: %w", err))
+	}
+	if cfg.PayloadCRC && !isJSONFormat(cfg.PayloadFormat) {
+		fail("PAYLOAD_CRC requires a JSON payload format")
+	}
+	if cfg.PayloadCompression != "" && cfg.PayloadCompression != PayloadCompressionGzip {
+		fail("PAYLOAD_COMPRESSION must be %q, got %q", PayloadCompressionGzip, cfg.PayloadCompression)
+	}
+	if cfg.PayloadSplit && cfg.PayloadFormat != PayloadFormatJSON {
+		fail("PAYLOAD_SPLIT requires PAYLOAD_FORMAT=%s", PayloadFormatJSON)
+	}
+	if cfg.SatellitesInterval <= 0 {
+		fail("SATELLITES_INTERVAL_SECONDS must be positive")
+	}
+	if cfg.NMEABeidouTalker != TalkerBeidou && cfg.NMEABeidouTalker != TalkerBeidouLegacy {
+		fail("NMEA_BEIDOU_TALKER must be %q or %q", TalkerBeidou, TalkerBeidouLegacy)
+	}
+	if cfg.ValidateSchema != "" && cfg.ValidateSchema != SchemaModeLog && cfg.ValidateSchema != SchemaModeDrop {
+		fail("VALIDATE_SCHEMA must be %q or %q, got %q", SchemaModeLog, SchemaModeDrop, cfg.ValidateSchema)
+	}
+	if cfg.HADiscovery && (cfg.PayloadFormat != PayloadFormatJSON || cfg.PayloadEncKey != nil || cfg.PayloadCompression != "") {
+		fail("HA_DISCOVERY requires unencrypted, uncompressed PAYLOAD_FORMAT=%s", PayloadFormatJSON)
+	}
+	if cfg.HADiscovery && cfg.PayloadSplit {
+		// The discovery templates read Hdop and the Svnum counts, which split mode moves off the fix topic
+		fail("HA_DISCOVERY can't be combined with PAYLOAD_SPLIT")
+	}
+
+	if _, err := convertSpeed(0, cfg.SpeedUnit); err != nil {
+		errs = append(errs, fmt.Errorf("SPEED_UNIT: %w", err))
+	}
+	if !(cfg.UEREMeters > 0) {
+		fail("UERE_METERS must be positive")
+	}
+	if cfg.UsableSNR <= 0 {
+		fail("USABLE_SNR_THRESHOLD must be positive")
+	}
+	if cfg.SatelliteMinSNR < 0 {
+		fail("SATELLITE_MIN_SNR can't be negative")
+	}
+	if cfg.SatelliteMinSNR > 0 && cfg.KeepSatellitePadding {
+		fail("SATELLITE_MIN_SNR can't be combined with KEEP_SATELLITE_PADDING")
+	}
+	if err := ValidateRangeCheck(cfg.RangeCheck); err != nil {
+		errs = append(errs, fmt.Errorf("RANGE_CHECK: %w", err))
+	}
+	if cfg.GeofenceCenter != nil && !(cfg.GeofenceRadiusM > 0) {
+		fail("GEOFENCE_RADIUS_M must be positive")
+	}
+	if cfg.RefPoint != nil && (math.Abs(cfg.RefPoint[0]) > 90 || math.Abs(cfg.RefPoint[1]) > 180) {
+		fail("REF_LAT/REF_LON out of range")
+	}
+	if cfg.MedianFilterWindow != 0 {
+		if _, err := NewMedianFilter(cfg.MedianFilterWindow); err != nil {
+			errs = append(errs, fmt.Errorf("MEDIAN_FILTER_WINDOW: %w", err))
+		}
+	}
+	if cfg.MaxSpeedMs < 0 {
+		fail("MAX_SPEED_MS must not be negative")
+	}
+	if cfg.SmoothWindow != 0 {
+		if _, err := NewSmoothingFilter(cfg.SmoothWindow); err != nil {
+			errs = append(errs, fmt.Errorf("SMOOTH_WINDOW: %w", err))
+		}
+	}
+	if !(cfg.HeadingMinDistanceM > 0) {
+		fail("HEADING_MIN_DISTANCE_M must be positive")
+	}
+	if !(cfg.OdometerMaxSpeedMs > 0) {
+		fail("ODOMETER_MAX_SPEED_MS must be positive")
+	}
+	if cfg.SampleEveryM < 0 {
+		fail("SAMPLE_EVERY_M must not be negative")
+	}
+	if cfg.EventMinInterval < 0 {
+		fail("EVENT_MIN_INTERVAL_SECONDS must not be negative")
+	}
+	if cfg.GeohashPrecision < 0 || cfg.GeohashPrecision > MaxGeohashPrecision {
+		fail("GEOHASH_PRECISION must be between 1 and %d", MaxGeohashPrecision)
+	}
+	if cfg.DisplayWidth < MinDisplayWidth {
+		fail("DISPLAY_WIDTH must be at least %d", MinDisplayWidth)
+	}
+
+	if cfg.SNRHistogramInterval < 0 {
+		fail("SNR_HISTOGRAM_INTERVAL_SECONDS must not be negative")
+	}
+	if cfg.StartupGrace < 0 {
+		fail("STARTUP_GRACE_SECONDS must not be negative")
+	}
+	if cfg.MaxRuntime < 0 {
+		fail("MAX_RUNTIME_SECONDS must not be negative")
+	}
+	if cfg.ClockDriftThreshold < 0 {
+		fail("CLOCK_DRIFT_THRESHOLD_MS must not be negative")
+	}
+	if cfg.GeocoderMinInterval < 0 {
+		fail("GEOCODER_MIN_INTERVAL_SECONDS must not be negative")
+	}
+	if cfg.GeocoderCacheMeters < 0 {
+		fail("GEOCODER_CACHE_METERS must not be negative")
+	}
+	if cfg.MinMoveMeters < 0 {
+		fail("MIN_MOVE_METERS must not be negative")
+	}
+	if cfg.Heartbeat <= 0 {
+		fail("HEARTBEAT_SECONDS must be positive")
+	}
+	if cfg.IdleInterval < 0 {
+		fail("IDLE_INTERVAL_SECONDS must not be negative")
+	}
+	if cfg.StationaryPeriod < 0 {
+		fail("STATIONARY_SECONDS must not be negative")
+	}
+	if cfg.MovingFixes < 1 {
+		fail("MOVING_FIXES must be at least 1")
+	}
+
+	if cfg.WebhookTimeout <= 0 {
+		fail("WEBHOOK_TIMEOUT_SECONDS must be positive")
+	}
+	if cfg.WebhookMaxBackoff < time.Second {
+		fail("WEBHOOK_MAX_BACKOFF_SECONDS must be at least 1")
+	}
+
+	if cfg.PGDSN != "" && !pgTableName.MatchString(cfg.PGTable) {
+		fail("PG_TABLE %q is not a valid table name", cfg.PGTable)
+	}
+	if cfg.PGBatchSize < 1 {
+		fail("PG_BATCH_SIZE must be at least 1")
+	}
+	if cfg.PGFlushInterval <= 0 {
+		fail("PG_FLUSH_SECONDS must be positive")
+	}
+	if cfg.QueueMaxBytes <= 0 {
+		fail("QUEUE_MAX_BYTES must be positive")
+	}
+	if cfg.QueueMaxEntries < 0 {
+		fail("QUEUE_MAX_ENTRIES must not be negative")
+	}
+	if cfg.GPXMaxBytes < MinGPXMaxBytes {
+		fail("GPX_MAX_BYTES must be at least %d", MinGPXMaxBytes)
+	}
+	if cfg.HealthMaxAge != nil && *cfg.HealthMaxAge < 0 {
+		fail("HEALTH_MAX_AGE_SECONDS must not be negative")
+	}
+	if cfg.HealthPublishInterval < 0 {
+		fail("HEALTH_PUBLISH_INTERVAL_SECONDS must not be negative")
+	}
+
+	gnss := &GNSSDbus{Bus: cfg.DbusBus, Dest: cfg.GnssDbusDest, Path: cfg.GnssDbusPath, Method: cfg.GnssDbusMethod}
+	if err := gnss.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.DbusCallTimeout <= 0 {
+		fail("DBUS_CALL_TIMEOUT must be positive")
+	}
+	if cfg.DbusRetryAttempts < 1 {
+		fail("DBUS_RETRY_ATTEMPTS must be at least 1")
+	}
+	if cfg.DbusRetryDelay < 0 {
+		fail("DBUS_RETRY_DELAY_SECONDS must not be negative")
+	}
+	if err := ValidateReplaySpeed(cfg.ReplaySpeed); err != nil {
+		errs = append(errs, fmt.Errorf("REPLAY_SPEED: %w", err))
+	}
+	if cfg.GnssSignalTimeout <= 0 {
+		fail("GNSS_SIGNAL_TIMEOUT_SECONDS must be positive")
+	}
+	if cfg.StaleMaxAge <= 0 {
+		fail("STALE_MAX_AGE_SECONDS must be positive")
+	}
+
+	if cfg.MaxProcs < 0 {
+		fail("MAX_PROCS must not be negative")
+	}
+	return errors.Join(errs...)
+}
+
+// SanitizeDeviceID makes id safe to use as a single MQTT topic level: the wildcards + and #,
+// the level separator / and control characters are replaced with underscores. Surrounding
+// whitespace is trimmed, and an ID left empty is an error.
+func SanitizeDeviceID(id string) (string, error) {
+	id = strings.Map(func(r rune) rune {
+		if r == '+' || r == '#' || r == '/' || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(id))
+	if id == "" {
+		return "", fmt.Errorf("device ID is empty")
+	}
+	return id, nil
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// requiredMQTTKeys are the variables readConfig needs unless DRY_RUN or a webhook replaces MQTT
+var requiredMQTTKeys = []string{"MQTT_BROKER_URL", "MQTT_BROKER_PORT", "MQTT_TOPIC", "MQTT_USERNAME", "MQTT_PASSWORD"}
+
+// setConfigEnv sets env for the test on top of an environment cleared of the variables that
+// decide which settings are required
+func setConfigEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for _, key := range append([]string{"DRY_RUN", "WEBHOOK_URL", "CONFIG_FILE"}, requiredMQTTKeys...) {
+		t.Setenv(key, "")
+	}
+	t.Setenv("DEVICE_ID", "tachyon-1")
+	for key, val := range env {
+		t.Setenv(key, val)
+	}
+}
+
+func TestReadConfigReportsEveryProblem(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantKeys    []string
+		notWantKeys []string
+	}{
+		{
+			name:     "empty environment",
+			env:      nil,
+			wantKeys: requiredMQTTKeys,
+		},
+		{
+			name:        "partially populated",
+			env:         map[string]string{"MQTT_BROKER_URL": "broker.example.com", "MQTT_TOPIC": "gnss"},
+			wantKeys:    []string{"MQTT_BROKER_PORT", "MQTT_USERNAME", "MQTT_PASSWORD"},
+			notWantKeys: []string{"MQTT_BROKER_URL", "MQTT_TOPIC"},
+		},
+		{
+			name: "missing and invalid together",
+			env: map[string]string{
+				"MQTT_BROKER_URL": "broker.example.com", "MQTT_BROKER_PORT": "1883", "MQTT_TOPIC": "gnss",
+				"MQTT_QOS": "3", "POLL_INTERVAL_SECONDS": "ten", "UERE_METERS": "-1", "PUBLISH_INVALID_FIXES": "maybe",
+			},
+			wantKeys:    []string{"MQTT_USERNAME", "MQTT_PASSWORD", "MQTT_QOS", "POLL_INTERVAL_SECONDS", "UERE_METERS", "PUBLISH_INVALID_FIXES"},
+			notWantKeys: []string{"MQTT_BROKER_PORT"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfigEnv(t, tt.env)
+			cfg, err := readConfig()
+			if err == nil {
+				t.Fatalf("readConfig() = %+v, want an error", cfg)
+			}
+			for _, key := range tt.wantKeys {
+				if !strings.Contains(err.Error(), key) {
+					t.Errorf("error doesn't mention %s:\n%v", key, err)
+				}
+			}
+			for _, key := range tt.notWantKeys {
+				if strings.Contains(err.Error(), key) {
+					t.Errorf("error mentions %s, which is set:\n%v", key, err)
+				}
+			}
+			if lines := strings.Count(err.Error(), "\n") + 1; lines != len(tt.wantKeys) {
+				t.Errorf("error has %d lines, want one per problem:\n%v", lines, err)
+			}
+		})
+	}
+}
+
+func TestReadConfigComplete(t *testing.T) {
+	setConfigEnv(t, map[string]string{
+		"MQTT_BROKER_URL": "broker.example.com", "MQTT_BROKER_PORT": "8883", "MQTT_TOPIC": "gnss",
+		"MQTT_USERNAME": "tachyon", "MQTT_PASSWORD": "secret", "MQTT_QOS": "1", "POLL_INTERVAL_SECONDS": "5",
+	})
+	cfg, err := readConfig()
+	if err != nil {
+		t.Fatalf("readConfig() = %v", err)
+	}
+	if cfg.MQTTBrokerURL != "broker.example.com" || cfg.MQTTBrokerPort != "8883" || cfg.MQTTQoS != 1 {
+		t.Errorf("MQTT settings = %q %q QoS %d", cfg.MQTTBrokerURL, cfg.MQTTBrokerPort, cfg.MQTTQoS)
+	}
+	if cfg.DeviceTopic != "gnss/tachyon-1" || cfg.PollInterval != 5*time.Second {
+		t.Errorf("device topic %q and poll interval %v, want gnss/tachyon-1 and 5s", cfg.DeviceTopic, cfg.PollInterval)
+	}
+}
+
+func TestReadConfigDryRunNeedsNoMQTT(t *testing.T) {
+	setConfigEnv(t, map[string]string{"DRY_RUN": "true"})
+	cfg, err := readConfig()
+	if err != nil {
+		t.Fatalf("readConfig() = %v", err)
+	}
+	if !cfg.DryRun || cfg.MQTTTopic != DefaultDryRunTopic {
+		t.Errorf("DryRun %t with topic %q, want a dry run on %q", cfg.DryRun, cfg.MQTTTopic, DefaultDryRunTopic)
+	}
+}
+
+func TestValidateConfigCollectsErrors(t *testing.T) {
+	setConfigEnv(t, map[string]string{"DRY_RUN": "true"})
+	cfg, err := readConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.UEREMeters, cfg.MovingFixes, cfg.MaxProcs = 0, 0, -1
+	cfg.HADiscovery, cfg.PayloadSplit = true, true
+	cfg.SatelliteMinSNR, cfg.KeepSatellitePadding = 25, true
+	err = validateConfig(cfg)
+	for _, key := range []string{"UERE_METERS", "MOVING_FIXES", "MAX_PROCS", "PAYLOAD_SPLIT", "KEEP_SATELLITE_PADDING"} {
+		if err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("validateConfig() = %v, want it to mention %s", err, key)
+		}
+	}
+}
+
+// loadTestConfigFile writes contents to a CONFIG_FILE and loads it into the environment. Every
+// variable the file sets is registered with t.Setenv first, so the test's cleanup unsets it.
+func loadTestConfigFile(t *testing.T, contents string) error {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	settings, err := parseConfigFile([]byte(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key := range settings {
+		if os.Getenv(key) == "" {
+			t.Setenv(key, "")
+		}
+	}
+	saved := configFileValues
+	configFileValues = map[string]string{}
+	t.Cleanup(func() { configFileValues = saved })
+	t.Setenv("CONFIG_FILE", path)
+	return loadConfigFile()
+}
+
+func TestConfigFileWithEnvOverride(t *testing.T) {
+	setConfigEnv(t, map[string]string{"MQTT_TOPIC": "env-topic"})
+	err := loadTestConfigFile(t, `
+mqtt:
+  broker_url: broker.example.com
+  broker_port: 8883
+  topic: file-topic
+  username: tachyon
+  password: secret
+  qos: 1
+poll_interval_seconds: 2.5
+PUBLISH_INVALID_FIXES: true
+geohash_precision:
+`)
+	if err != nil {
+		t.Fatalf("loadConfigFile() = %v", err)
+	}
+	cfg, err := readConfig()
+	if err != nil {
+		t.Fatalf("readConfig() = %v", err)
+	}
+	if cfg.MQTTBrokerURL != "broker.example.com" || cfg.MQTTBrokerPort != "8883" || cfg.MQTTUsername != "tachyon" || cfg.MQTTQoS != 1 {
+		t.Errorf("MQTT settings from the file = %q %q %q QoS %d", cfg.MQTTBrokerURL, cfg.MQTTBrokerPort, cfg.MQTTUsername, cfg.MQTTQoS)
+	}
+	if cfg.MQTTTopic != "env-topic" {
+		t.Errorf("MQTT topic = %q, want the environment's env-topic over the file", cfg.MQTTTopic)
+	}
+	if cfg.PollInterval != 2500*time.Millisecond || !cfg.PublishInvalid {
+		t.Errorf("poll interval %v and publish invalid %t, want 2.5s and true from the file", cfg.PollInterval, cfg.PublishInvalid)
+	}
+	if _, ok := configFileValues["MQTT_TOPIC"]; ok {
+		t.Error("MQTT_TOPIC recorded as taken from the file, though the environment overrode it")
+	}
+	if configFileValues["MQTT_BROKER_URL"] != "broker.example.com" {
+		t.Errorf("file values = %v, want MQTT_BROKER_URL recorded", configFileValues)
+	}
+}
+
+func TestConfigFileValidatedLikeEnv(t *testing.T) {
+	setConfigEnv(t, map[string]string{"DRY_RUN": "true"})
+	if err := loadTestConfigFile(t, "uere_meters: -3\npoll_interval_seconds: soon\n"); err != nil {
+		t.Fatalf("loadConfigFile() = %v", err)
+	}
+	_, err := readConfig()
+	for _, key := range []string{"UERE_METERS", "POLL_INTERVAL_SECONDS"} {
+		if err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("readConfig() = %v, want it to report the file's %s", err, key)
+		}
+	}
+}
+
+func TestParseConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"flat", "device_id: tachyon-1\nmqtt_qos: 2", map[string]string{"DEVICE_ID": "tachyon-1", "MQTT_QOS": "2"}, false},
+		{"nested", "mqtt:\n  tls:\n    ca_cert: /etc/ca.pem", map[string]string{"MQTT_TLS_CA_CERT": "/etc/ca.pem"}, false},
+		{"scalars", "a: true\nb: 1.5\nc:", map[string]string{"A": "true", "B": "1.5"}, false},
+		{"empty", "", map[string]string{}, false},
+		{"list", "coord_formats: [dms, utm]", nil, true},
+		{"set twice", "mqtt_topic: a\nmqtt:\n  topic: b", nil, true},
+		{"malformed", "mqtt: [", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseConfigFile([]byte(tt.yaml))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseConfigFile() error = %v, want error %t", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseConfigFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFileValues are the settings taken from CONFIG_FILE, i.e. those the environment didn't
+// override, as last loaded. A SIGHUP reload compares the file against them.
+var configFileValues = map[string]string{}
+
+// loadConfigFile applies the YAML settings file named by CONFIG_FILE, if any, to the
+// environment. Settings are keyed by environment variable name, case-insensitively, and nested
+// mappings are joined with underscores, so
+//
+//	mqtt:
+//	  broker_url: broker.example.com
+//
+// sets MQTT_BROKER_URL. Variables already in the environment (including from .env) win over the
+// file, giving a precedence of defaults < file < environment. Since the file only feeds the
+// environment, every setting is validated the same way wherever it came from.
+func loadConfigFile() error {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("CONFIG_FILE: %w", err)
+	}
+	settings, err := parseConfigFile(raw)
+	if err != nil {
+		return fmt.Errorf("CONFIG_FILE %s: %w", path, err)
+	}
+	for key, val := range settings {
+		if os.Getenv(key) != "" {
+			continue // The environment overrides the file; empty counts as unset, as everywhere else
+		}
+		if err := os.Setenv(key, val); err != nil {
+			return fmt.Errorf("CONFIG_FILE %s: setting %s: %w", path, key, err)
+		}
+		configFileValues[key] = val
+	}
+	return nil
+}
+
+// parseConfigFile flattens a YAML settings file into environment variable names and values
+func parseConfigFile(raw []byte) (map[string]string, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	settings := make(map[string]string)
+	if err := flattenConfig("", doc, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// flattenConfig adds the scalar values under node to settings, prefixing their keys with prefix
+func flattenConfig(prefix string, node map[string]any, settings map[string]string) error {
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // Deterministic errors
+	for _, key := range keys {
+		name := strings.ToUpper(key)
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+		switch val := node[key].(type) {
+		case map[string]any:
+			if err := flattenConfig(name, val, settings); err != nil {
+				return err
+			}
+		case []any:
+			return fmt.Errorf("%s: lists aren't supported, use the environment variable's string form", name)
+		case nil:
+			// An empty value leaves the setting at its default
+		default:
+			if _, dup := settings[name]; dup {
+				return fmt.Errorf("%s is set more than once", name)
+			}
+			settings[name] = fmt.Sprint(val)
+		}
+	}
+	return nil
+}
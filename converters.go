@@ -2,81 +2,144 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 
 	"github.com/godbus/dbus/v5"
 )
 
-// ParseFloatVariant converts a D-Bus variant to a float64 value
+// ParseFloatVariant converts a D-Bus variant to a float64 value. Depending on the firmware,
+// numbers arrive as doubles, strings or any of the D-Bus integer types.
 func ParseFloatVariant(v dbus.Variant) (float64, error) {
 	switch val := v.Value().(type) {
 	case float64:
 		return val, nil
 	case string:
 		return strconv.ParseFloat(val, 64)
+	case byte:
+		return float64(val), nil
+	case int16:
+		return float64(val), nil
+	case uint16:
+		return float64(val), nil
 	case int32:
 		return float64(val), nil
+	case uint32:
+		return float64(val), nil
+	case int64:
+		return float64(val), nil
+	case uint64:
+		return float64(val), nil
 	default:
 		return 0, fmt.Errorf("unexpected type for GNSS value: %T", val)
 	}
 }
 
-// ToInt8 converts various numeric types to int8
-func ToInt8(val any) int8 {
+// toInt64 converts any Go integer type to int64, saturating uint64 values beyond its range.
+// ok is false for anything that isn't an integer.
+func toInt64(val any) (n int64, ok bool) {
 	switch v := val.(type) {
 	case int8:
-		return v
+		return int64(v), true
 	case uint8:
-		return int8(v)
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
 	case int32:
-		return int8(v)
+		return int64(v), true
 	case uint32:
-		return int8(v)
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint64:
+		return int64(min(v, math.MaxInt64)), true
 	case int:
-		return int8(v)
+		return int64(v), true
 	case uint:
-		return int8(v)
+		return int64(min(uint64(v), math.MaxInt64)), true
 	default:
-		return 0
+		return 0, false
 	}
 }
 
-// ToInt32 converts various numeric types to int32
-func ToInt32(val any) int32 {
-	switch v := val.(type) {
-	case int8:
-		return int32(v)
-	case uint8:
-		return int32(v)
-	case int32:
-		return v
-	case uint32:
-		return int32(v)
-	case int:
-		return int32(v)
-	case uint:
-		return int32(v)
-	default:
-		return 0
+// clampInt converts an integer of any type to the range [lo, hi], clamping rather than
+// wrapping values outside it. ok is false when the value was clamped, or isn't an integer, in
+// which case it's 0.
+func clampInt(val any, lo, hi int64) (int64, bool) {
+	n, ok := toInt64(val)
+	if !ok {
+		return 0, false
+	}
+	if n < lo {
+		return lo, false
 	}
+	if n > hi {
+		return hi, false
+	}
+	return n, true
 }
 
-// ToUint8 converts various numeric types to uint8
-func ToUint8(val any) uint8 {
-	switch v := val.(type) {
-	case int8:
-		return uint8(v)
-	case uint8:
-		return v
-	case int32:
-		return uint8(v)
-	case uint32:
-		return uint8(v)
-	case int:
-		return uint8(v)
-	case uint:
-		return uint8(v)
-	default:
-		return 0
+// ToInt8 converts an integer of any type to int8; see clampInt for out-of-range values
+func ToInt8(val any) (int8, bool) {
+	n, ok := clampInt(val, math.MinInt8, math.MaxInt8)
+	return int8(n), ok
+}
+
+// ToInt32 converts an integer of any type to int32; see clampInt for out-of-range values
+func ToInt32(val any) (int32, bool) {
+	n, ok := clampInt(val, math.MinInt32, math.MaxInt32)
+	return int32(n), ok
+}
+
+// ToUint8 converts an integer of any type to uint8; see clampInt for out-of-range values
+func ToUint8(val any) (uint8, bool) {
+	n, ok := clampInt(val, 0, math.MaxUint8)
+	return uint8(n), ok
+}
+
+// decodeProblems collects the values that couldn't be converted exactly while decoding a
+// GetGnss reply, so they're logged instead of silently becoming wrong numbers
+type decodeProblems []string
+
+// add records a value that was clamped or isn't an integer, formatting the field name from
+// format and args. Formatting is left until a problem is found, as most values convert exactly.
+func (p *decodeProblems) add(val any, got int64, format string, args ...any) {
+	field := fmt.Sprintf(format, args...)
+	if _, isInt := toInt64(val); isInt {
+		*p = append(*p, fmt.Sprintf("%s %v clamped to %d", field, val, got))
+	} else {
+		*p = append(*p, fmt.Sprintf("%s has unexpected type %T", field, val))
+	}
+}
+
+// int8 converts val with ToInt8, recording a problem with the field named by format and args
+// if it isn't exact
+func (p *decodeProblems) int8(val any, format string, args ...any) int8 {
+	n, ok := ToInt8(val)
+	if !ok {
+		p.add(val, int64(n), format, args...)
+	}
+	return n
+}
+
+// int32 converts val with ToInt32, recording a problem with the field named by format and args
+// if it isn't exact
+func (p *decodeProblems) int32(val any, format string, args ...any) int32 {
+	n, ok := ToInt32(val)
+	if !ok {
+		p.add(val, int64(n), format, args...)
+	}
+	return n
+}
+
+// uint8 converts val with ToUint8, recording a problem with the field named by format and args
+// if it isn't exact
+func (p *decodeProblems) uint8(val any, format string, args ...any) uint8 {
+	n, ok := ToUint8(val)
+	if !ok {
+		p.add(val, int64(n), format, args...)
 	}
+	return n
 }
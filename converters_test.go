@@ -0,0 +1,156 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestParseFloatVariant(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    float64
+		wantErr bool
+	}{
+		{"double", 545.4, 545.4, false},
+		{"string", "-12.75", -12.75, false},
+		{"byte", byte(200), 200, false},
+		{"int16", int16(-420), -420, false},
+		{"uint16", uint16(65535), 65535, false},
+		{"int32", int32(-123456), -123456, false},
+		{"uint32", uint32(4000000000), 4000000000, false},
+		{"int64", int64(-9000000000), -9000000000, false},
+		{"uint64", uint64(18000000000), 18000000000, false},
+		{"unparseable string", "high", 0, true},
+		{"boolean", true, 0, true},
+		{"array", []float64{1}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := dbus.MakeVariant(tt.value)
+			got, err := ParseFloatVariant(v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFloatVariant(%s) error = %v, want error %t", v, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFloatVariant(%s) = %v, want %v", v, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeGnssIntegerAltitude(t *testing.T) {
+	for _, altitude := range []any{uint16(545), int16(545), uint32(545), int64(545), uint64(545)} {
+		result := validFixVariants()
+		result["altitude"] = dbus.MakeVariant(altitude)
+		data, err := decodeGnss(result, RangeCheckOff)
+		if err != nil {
+			t.Fatalf("decodeGnss() = %v", err)
+		}
+		if data.Altitude != 545 {
+			t.Errorf("altitude %T(545) decoded as %v", altitude, data.Altitude)
+		}
+	}
+}
+
+func TestClampInt(t *testing.T) {
+	tests := []struct {
+		name   string
+		val    any
+		lo, hi int64
+		want   int64
+		wantOK bool
+	}{
+		{"in range", int32(300), 0, 359, 300, true},
+		{"at the bounds", int8(-128), math.MinInt8, math.MaxInt8, -128, true},
+		{"above", int32(300), math.MinInt8, math.MaxInt8, math.MaxInt8, false},
+		{"below", int64(-200), math.MinInt8, math.MaxInt8, math.MinInt8, false},
+		{"negative into unsigned", int32(-1), 0, math.MaxUint8, 0, false},
+		{"uint64 beyond int64", uint64(math.MaxUint64), math.MinInt32, math.MaxInt32, math.MaxInt32, false},
+		{"uint saturating int64", uint(math.MaxUint), 0, math.MaxInt64, math.MaxInt64, true},
+		{"string", "12", 0, 100, 0, false},
+		{"float", 12.0, 0, 100, 0, false},
+		{"nil", nil, 0, 100, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := clampInt(tt.val, tt.lo, tt.hi)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("clampInt(%T(%v), %d, %d) = %d, %t, want %d, %t", tt.val, tt.val, tt.lo, tt.hi, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestIntConverters(t *testing.T) {
+	tests := []struct {
+		name      string
+		val       any
+		wantInt8  int8
+		wantInt32 int32
+		wantUint8 uint8
+		wantOK    [3]bool // For ToInt8, ToInt32 and ToUint8
+	}{
+		{"small", uint8(42), 42, 42, 42, [3]bool{true, true, true}},
+		{"azimuth", int32(300), math.MaxInt8, 300, math.MaxUint8, [3]bool{false, true, false}},
+		{"negative", int16(-5), -5, -5, 0, [3]bool{true, true, false}},
+		{"huge", int64(1 << 40), math.MaxInt8, math.MaxInt32, math.MaxUint8, [3]bool{false, false, false}},
+		{"hugely negative", int64(-1 << 40), math.MinInt8, math.MinInt32, 0, [3]bool{false, false, false}},
+		{"wrong type", "42", 0, 0, 0, [3]bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := ToInt8(tt.val); got != tt.wantInt8 || ok != tt.wantOK[0] {
+				t.Errorf("ToInt8(%v) = %d, %t, want %d, %t", tt.val, got, ok, tt.wantInt8, tt.wantOK[0])
+			}
+			if got, ok := ToInt32(tt.val); got != tt.wantInt32 || ok != tt.wantOK[1] {
+				t.Errorf("ToInt32(%v) = %d, %t, want %d, %t", tt.val, got, ok, tt.wantInt32, tt.wantOK[1])
+			}
+			if got, ok := ToUint8(tt.val); got != tt.wantUint8 || ok != tt.wantOK[2] {
+				t.Errorf("ToUint8(%v) = %d, %t, want %d, %t", tt.val, got, ok, tt.wantUint8, tt.wantOK[2])
+			}
+		})
+	}
+}
+
+func TestDecodeProblems(t *testing.T) {
+	var problems decodeProblems
+	if n := problems.int8(int32(12), "slmsg[%d] num", 0); n != 12 || len(problems) != 0 {
+		t.Fatalf("int8() of an exact value = %d with problems %q", n, problems)
+	}
+	problems.int8(int32(200), "slmsg[%d] snr", 3)
+	problems.int32("north", "utc year")
+	problems.uint8(int32(-4), "possl[%d]", 1)
+	want := decodeProblems{
+		"slmsg[3] snr 200 clamped to 127",
+		"utc year has unexpected type string",
+		"possl[1] -4 clamped to 0",
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("problems = %q, want %q", problems, want)
+	}
+}
+
+func TestDecodeGnssClampsSatelliteFields(t *testing.T) {
+	result := validFixVariants()
+	result["slmsg"] = dbus.MakeVariant([][]any{
+		{int32(5), int32(45), int32(300), int32(38)},
+		{int32(300), int32(-100), int64(1 << 40), int32(200)},
+		{"7", int32(10), int32(90), 3.5},
+	})
+	data, err := decodeGnss(result, RangeCheckOff)
+	if err != nil {
+		t.Fatalf("decodeGnss() = %v", err)
+	}
+	want := []NmeaSatelliteMsg{
+		{Num: 5, Eledeg: 45, Azideg: 300, SN: 38},
+		{Num: math.MaxInt8, Eledeg: -100, Azideg: math.MaxInt32, SN: math.MaxInt8},
+		{Num: 0, Eledeg: 10, Azideg: 90, SN: 0},
+	}
+	if !reflect.DeepEqual(data.Slmsg, want) {
+		t.Errorf("Slmsg = %+v, want %+v", data.Slmsg, want)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+)
+
+// payloadCRC returns the CRC-32 (IEEE 802.3 polynomial, as used by zlib and PNG) of b
+func payloadCRC(b []byte) uint32 {
+	return crc32.ChecksumIEEE(b)
+}
+
+// AppendCRCField appends a "crc" member holding payloadCRC(payload) to a JSON object payload.
+// Consumers verify by stripping the trailing `,"crc":<n>` member, restoring the closing brace,
+// and comparing the CRC-32 of the resulting bytes with <n>.
+func AppendCRCField(payload []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) < 2 || trimmed[0] != '{' || trimmed[len(trimmed)-1] != '}' {
+		return nil, fmt.Errorf("crc field requires a JSON object payload")
+	}
+	crc := payloadCRC(trimmed)
+	out := make([]byte, 0, len(trimmed)+20)
+	out = append(out, trimmed[:len(trimmed)-1]...)
+	if len(bytes.TrimSpace(trimmed[1:len(trimmed)-1])) > 0 {
+		out = append(out, ',')
+	}
+	out = append(out, `"crc":`...)
+	out = strconv.AppendUint(out, uint64(crc), 10)
+	return append(out, '}'), nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+func TestPayloadCRC(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint32
+	}{
+		{"", 0},
+		{"123456789", 0xCBF43926}, // The CRC-32 check value
+		{"The quick brown fox jumps over the lazy dog", 0x414FA339},
+		{"a", 0xE8B7BE43},
+	}
+	for _, tt := range tests {
+		if got := payloadCRC([]byte(tt.in)); got != tt.want {
+			t.Errorf("payloadCRC(%q) = %#08x, want %#08x", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAppendCRCField(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    string // Empty when an error is expected
+	}{
+		{"object", `{"a":1}`, `{"a":1,"crc":` + strconv.FormatUint(uint64(payloadCRC([]byte(`{"a":1}`))), 10) + `}`},
+		{"empty object", `{}`, `{"crc":` + strconv.FormatUint(uint64(payloadCRC([]byte(`{}`))), 10) + `}`},
+		{"surrounding whitespace", " {\"a\":1}\n", `{"a":1,"crc":` + strconv.FormatUint(uint64(payloadCRC([]byte(`{"a":1}`))), 10) + `}`},
+		{"array", `[1,2]`, ""},
+		{"text", `$GPGGA`, ""},
+		{"empty", ``, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AppendCRCField([]byte(tt.payload))
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("AppendCRCField(%q) = %s, want an error", tt.payload, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("AppendCRCField(%q) = %s, want %s", tt.payload, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAppendCRCFieldVerify follows the verification steps documented for consumers
+func TestAppendCRCFieldVerify(t *testing.T) {
+	payload := []byte(`{"Latitude":51.5,"Longitude":-0.12}`)
+	withCRC, err := AppendCRCField(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := bytes.LastIndex(withCRC, []byte(`,"crc":`))
+	if i < 0 {
+		t.Fatalf("no crc member in %s", withCRC)
+	}
+	crc, err := strconv.ParseUint(string(withCRC[i+len(`,"crc":`):len(withCRC)-1]), 10, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := append(withCRC[:i:i], '}')
+	if !bytes.Equal(restored, payload) || payloadCRC(restored) != uint32(crc) {
+		t.Errorf("restored %s with crc %d, want %s with crc %d", restored, crc, payload, payloadCRC(payload))
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// DebouncedEvent is a state-change event subject to de-bouncing
+type DebouncedEvent struct {
+	Topic   string // MQTT topic the event is published to
+	Key     string // Identifies the event source, e.g. "zone:depot"
+	State   string // State the event reports, e.g. "enter"
+	Payload any    // Value marshaled as the event body
+}
+
+// debouncedState records the last state published for a key
+type debouncedState struct {
+	state string
+	at    time.Time
+}
+
+// EventDebouncer limits each event key to one publish per interval. Events arriving
+// too soon are held back, and only released once the interval has elapsed if the
+// latest held-back state still differs from the last published one, so flapping
+// inputs coalesce into at most one event per interval.
+type EventDebouncer struct {
+	minInterval time.Duration
+	published   map[string]debouncedState
+	pending     map[string]DebouncedEvent
+}
+
+// NewEventDebouncer creates a debouncer allowing one event per key every minInterval
+func NewEventDebouncer(minInterval time.Duration) *EventDebouncer {
+	return &EventDebouncer{
+		minInterval: minInterval,
+		published:   make(map[string]debouncedState),
+		pending:     make(map[string]DebouncedEvent),
+	}
+}
+
+// SetMinInterval changes the interval, applying to held-back events as well as new ones
+func (d *EventDebouncer) SetMinInterval(minInterval time.Duration) {
+	d.minInterval = minInterval
+}
+
+// Offer reports whether ev may be published now. Suppressed events are held for Due.
+func (d *EventDebouncer) Offer(ev DebouncedEvent, now time.Time) bool {
+	last, ok := d.published[ev.Key]
+	if ok && now.Sub(last.at) < d.minInterval {
+		d.pending[ev.Key] = ev
+		return false
+	}
+	delete(d.pending, ev.Key)
+	if ok && last.state == ev.State {
+		return false
+	}
+	d.published[ev.Key] = debouncedState{state: ev.State, at: now}
+	return true
+}
+
+// Due returns held-back events whose interval has elapsed and whose state genuinely
+// differs from the last published state, ordered by key
+func (d *EventDebouncer) Due(now time.Time) []DebouncedEvent {
+	var due []DebouncedEvent
+	for key, ev := range d.pending {
+		last := d.published[key]
+		if now.Sub(last.at) < d.minInterval {
+			continue
+		}
+		delete(d.pending, key)
+		if ev.State == last.state {
+			continue
+		}
+		d.published[key] = debouncedState{state: ev.State, at: now}
+		due = append(due, ev)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].Key < due[j].Key })
+	return due
+}
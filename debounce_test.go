@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventDebouncerBoundsFlapping(t *testing.T) {
+	tests := []struct {
+		name        string
+		minInterval time.Duration
+		every       time.Duration // Time between state flips
+		flips       int
+		finalState  string // State left pending at the end, if any, once Due runs after the interval
+		maxEvents   int
+	}{
+		{"flapping every second for a minute", 10 * time.Second, time.Second, 60, "exit", 7},
+		{"flapping faster than the interval", time.Minute, 100 * time.Millisecond, 600, "exit", 2},
+		{"slower than the interval", time.Second, 2 * time.Second, 10, "exit", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewEventDebouncer(tt.minInterval)
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			now := start
+			var published []string
+			for i := range tt.flips {
+				state := "enter"
+				if i%2 == 1 {
+					state = "exit"
+				}
+				if d.Offer(DebouncedEvent{Key: "zone:depot", State: state}, now) {
+					published = append(published, state)
+				}
+				for _, ev := range d.Due(now) {
+					published = append(published, ev.State)
+				}
+				now = now.Add(tt.every)
+			}
+			for _, ev := range d.Due(now.Add(tt.minInterval)) {
+				published = append(published, ev.State)
+			}
+			if len(published) == 0 || len(published) > tt.maxEvents {
+				t.Fatalf("published %d events, want 1 to %d", len(published), tt.maxEvents)
+			}
+			for i := 1; i < len(published); i++ {
+				if published[i] == published[i-1] {
+					t.Fatalf("published %q twice in a row: %v", published[i], published)
+				}
+			}
+			if last := published[len(published)-1]; last != tt.finalState {
+				t.Errorf("last published state %q, want the final state %q", last, tt.finalState)
+			}
+		})
+	}
+}
+
+func TestEventDebouncerKeysIndependent(t *testing.T) {
+	d := NewEventDebouncer(time.Minute)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if !d.Offer(DebouncedEvent{Key: "zone:a", State: "enter"}, now) {
+		t.Fatal("first event for zone:a was held back")
+	}
+	if !d.Offer(DebouncedEvent{Key: "zone:b", State: "enter"}, now) {
+		t.Fatal("first event for zone:b was held back by zone:a")
+	}
+	if d.Offer(DebouncedEvent{Key: "zone:a", State: "exit"}, now.Add(time.Second)) {
+		t.Fatal("zone:a exit within the interval was published")
+	}
+	// Flapping back before the interval ends leaves nothing to publish
+	if d.Offer(DebouncedEvent{Key: "zone:a", State: "enter"}, now.Add(2*time.Second)) {
+		t.Fatal("zone:a enter within the interval was published")
+	}
+	if due := d.Due(now.Add(2 * time.Minute)); len(due) != 0 {
+		t.Errorf("Due() = %v, want nothing as zone:a is back in its published state", due)
+	}
+}
+
+func TestEventDebouncerSetMinInterval(t *testing.T) {
+	d := NewEventDebouncer(time.Hour)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d.Offer(DebouncedEvent{Key: "zone:a", State: "enter"}, now)
+	if d.Offer(DebouncedEvent{Key: "zone:a", State: "exit"}, now.Add(time.Minute)) {
+		t.Fatal("zone:a exit within the interval was published")
+	}
+	// Shortening the interval releases the held-back event once the new interval has passed
+	d.SetMinInterval(30 * time.Second)
+	if due := d.Due(now.Add(time.Minute)); len(due) != 1 || due[0].State != "exit" {
+		t.Errorf("Due() after shortening the interval = %v, want the held-back exit", due)
+	}
+}
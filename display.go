@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DisplayWidthDefault is the width of a 128 pixel wide OLED with a 6 pixel font
+	DisplayWidthDefault = 21
+	// MinDisplayWidth is the narrowest display the status line can be laid out for
+	MinDisplayWidth = 8
+)
+
+// DisplayStatus formats a single-line fix summary such as "3D 9sat 0.9 51.50,-0.12" that
+// fits in width characters. Coordinate precision is reduced to make the line fit; anything
+// still too long is cut off.
+func (d *GnssData) DisplayStatus(width int) string {
+	mode := "NOFIX"
+	if d.Valid != 0 {
+		switch d.Fixmode {
+		case 3:
+			mode = "3D"
+		case 2:
+			mode = "2D"
+		default:
+			mode = "FIX"
+		}
+	}
+	sats := int(d.Posslnum)
+	if sats == 0 {
+		sats = d.SatellitesInView()
+	}
+	status := fmt.Sprintf("%s %dsat", mode, sats)
+	if d.Valid == 0 {
+		return truncateDisplay(status, width)
+	}
+	status += fmt.Sprintf(" %.1f", d.Hdop)
+	for decimals := 5; decimals >= 0; decimals-- {
+		line := fmt.Sprintf("%s %.*f,%.*f", status, decimals, d.Latitude, decimals, d.Longitude)
+		if len(line) <= width {
+			return line
+		}
+	}
+	return truncateDisplay(status, width)
+}
+
+// truncateDisplay cuts s to at most width characters, dropping trailing spaces
+func truncateDisplay(s string, width int) string {
+	if len(s) > width {
+		s = s[:width]
+	}
+	return strings.TrimRight(s, " ")
+}
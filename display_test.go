@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestDisplayStatus(t *testing.T) {
+	fix := GnssData{Valid: 1, Fixmode: 3, Posslnum: 9, Hdop: 0.9, Latitude: 51.5007, Longitude: -0.1246}
+	tests := []struct {
+		name  string
+		data  GnssData
+		width int
+		want  string
+	}{
+		{"wide display", fix, 40, "3D 9sat 0.9 51.50070,-0.12460"},
+		{"medium display", fix, 28, "3D 9sat 0.9 51.5007,-0.1246"},
+		{"21 character display", fix, 21, "3D 9sat 0.9 51.5,-0.1"},
+		{"16 character display", fix, 16, "3D 9sat 0.9"},
+		{"minimum width", fix, MinDisplayWidth, "3D 9sat"},
+		{"2D fix", GnssData{Valid: 1, Fixmode: 2, Posslnum: 4, Hdop: 2.5, Latitude: -33.8688, Longitude: 151.2093}, 21, "2D 4sat 2.5 -34,151"},
+		{"valid without fix mode", GnssData{Valid: 1, Posslnum: 5, Hdop: 1.2, Latitude: 1, Longitude: 2}, 21, "FIX 5sat 1.2 1.0,2.0"},
+		{"no fix counts satellites in view", GnssData{Svnum: 8, BeidouSvnum: 4}, 16, "NOFIX 12sat"},
+		{"no fix on narrow display", GnssData{Svnum: 8, BeidouSvnum: 4}, MinDisplayWidth, "NOFIX 12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.data.DisplayStatus(tt.width)
+			if got != tt.want {
+				t.Errorf("DisplayStatus(%d) = %q, want %q", tt.width, got, tt.want)
+			}
+			if len(got) > tt.width {
+				t.Errorf("DisplayStatus(%d) = %q is %d characters wide", tt.width, got, len(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+// DistanceSampler emits a record every fixed number of meters traveled instead of every tick.
+// Positions between fixes are linearly interpolated so records land at the configured spacing.
+type DistanceSampler struct {
+	spacing  float64   // Meters between emitted records
+	prev     *GnssData // Previous valid fix
+	traveled float64   // Meters traveled since the last emitted record
+}
+
+// NewDistanceSampler creates a sampler emitting a record every spacing meters
+func NewDistanceSampler(spacing float64) *DistanceSampler {
+	return &DistanceSampler{spacing: spacing}
+}
+
+// Add feeds a valid fix into the sampler and returns the records due for publishing
+func (s *DistanceSampler) Add(data GnssData) []GnssData {
+	if s.prev == nil {
+		s.prev = &data
+		return []GnssData{data}
+	}
+	prev := s.prev
+	s.prev = &data
+	segment := Haversine(prev.Latitude, prev.Longitude, data.Latitude, data.Longitude)
+	if segment == 0 {
+		return nil
+	}
+	var records []GnssData
+	pos := s.spacing - s.traveled // Distance along this segment to the next record
+	for pos <= segment {
+		records = append(records, interpolateFix(prev, &data, pos/segment))
+		pos += s.spacing
+	}
+	s.traveled = segment - (pos - s.spacing)
+	return records
+}
+
+// interpolateFix returns cur with its position, altitude and speed interpolated
+// a fraction f of the way from prev to cur
+func interpolateFix(prev, cur *GnssData, f float64) GnssData {
+	out := *cur
+	out.Latitude = prev.Latitude + (cur.Latitude-prev.Latitude)*f
+	out.Longitude = prev.Longitude + (cur.Longitude-prev.Longitude)*f
+	out.Altitude = prev.Altitude + (cur.Altitude-prev.Altitude)*f
+	out.Speed = prev.Speed + (cur.Speed-prev.Speed)*f
+	return out
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceSamplerSpacing(t *testing.T) {
+	tests := []struct {
+		name    string
+		spacing float64 // Meters between records
+		step    float64 // Degrees of latitude between fixes, about 111 km per degree
+		fixes   int
+	}{
+		{"several fixes per record", 25, 0.0001, 200},
+		{"several records per fix", 5, 0.0002, 50},
+		{"spacing equal to step", 11.119508, 0.0001, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sampler := NewDistanceSampler(tt.spacing)
+			var records []GnssData
+			for i := range tt.fixes {
+				fix := GnssData{Latitude: 51 + float64(i)*tt.step, Longitude: -1, Speed: 40, Valid: 1}
+				records = append(records, sampler.Add(fix)...)
+			}
+			track := Haversine(51, -1, 51+float64(tt.fixes-1)*tt.step, -1)
+			if want := int(track/tt.spacing) + 1; len(records) < want-1 || len(records) > want {
+				t.Fatalf("got %d records over %.0f m, want about %d", len(records), track, want)
+			}
+			if records[0].Latitude != 51 {
+				t.Errorf("first record at %v, want the first fix", records[0].Latitude)
+			}
+			for i := 1; i < len(records); i++ {
+				d := Haversine(records[i-1].Latitude, records[i-1].Longitude, records[i].Latitude, records[i].Longitude)
+				if math.Abs(d-tt.spacing) > 0.01 {
+					t.Fatalf("records %d and %d are %.3f m apart, want %.3f", i-1, i, d, tt.spacing)
+				}
+				if records[i].Speed != 40 {
+					t.Errorf("record %d speed = %v, want 40", i, records[i].Speed)
+				}
+			}
+		})
+	}
+}
+
+func TestDistanceSamplerStationary(t *testing.T) {
+	sampler := NewDistanceSampler(10)
+	fix := GnssData{Latitude: 51, Longitude: -1, Valid: 1}
+	if got := sampler.Add(fix); len(got) != 1 {
+		t.Fatalf("first fix produced %d records, want 1", len(got))
+	}
+	for range 10 {
+		if got := sampler.Add(fix); len(got) != 0 {
+			t.Fatalf("stationary fix produced %d records, want 0", len(got))
+		}
+	}
+}
+
+func TestInterpolateFix(t *testing.T) {
+	prev := &GnssData{Latitude: 10, Longitude: 20, Altitude: 100, Speed: 0, Hdop: 1}
+	cur := &GnssData{Latitude: 12, Longitude: 24, Altitude: 200, Speed: 10, Hdop: 2}
+	got := interpolateFix(prev, cur, 0.25)
+	want := GnssData{Latitude: 10.5, Longitude: 21, Altitude: 125, Speed: 2.5, Hdop: 2}
+	if got.Latitude != want.Latitude || got.Longitude != want.Longitude || got.Altitude != want.Altitude ||
+		got.Speed != want.Speed || got.Hdop != want.Hdop {
+		t.Errorf("interpolateFix() = %+v, want %+v", got, want)
+	}
+}
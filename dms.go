@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Supported entries of COORD_FORMATS
+const (
+	CoordFormatDMS     = "dms"
+	CoordFormatGeohash = "geohash"
+	CoordFormatUTM     = "utm"
+)
+
+// DefaultCoordGeohashPrecision is the geohash length used when COORD_FORMATS enables geohashes
+// without GEOHASH_PRECISION, roughly 4.8m x 4.8m
+const DefaultCoordGeohashPrecision = 9
+
+// ParseCoordFormats parses a comma-separated COORD_FORMATS list into the set of formats enabled
+func ParseCoordFormats(s string) (map[string]bool, error) {
+	formats := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		format := strings.ToLower(strings.TrimSpace(part))
+		switch format {
+		case "":
+		case CoordFormatDMS, CoordFormatGeohash, CoordFormatUTM:
+			formats[format] = true
+		default:
+			return nil, fmt.Errorf("unsupported coordinate format %q, expected %q, %q or %q",
+				format, CoordFormatDMS, CoordFormatGeohash, CoordFormatUTM)
+		}
+	}
+	return formats, nil
+}
+
+// DecimalToDMS splits the magnitude of an angle in decimal degrees into whole degrees, whole
+// minutes and seconds. The sign is dropped; the caller reports it as a hemisphere.
+func DecimalToDMS(deg float64) (int, int, float64) {
+	deg = math.Abs(deg)
+	d := math.Floor(deg)
+	minutes := (deg - d) * 60
+	m := math.Floor(minutes)
+	return int(d), int(m), (minutes - m) * 60
+}
+
+// FormatDMS formats an angle as degrees, minutes and seconds to a hundredth of an arcsecond
+// followed by the hemisphere letter, e.g. 51°30'26.46"N. pos and neg are the letters for
+// positive and negative angles.
+func FormatDMS(deg float64, pos, neg byte) string {
+	hemisphere := pos
+	if deg < 0 {
+		hemisphere = neg
+	}
+	// Round before splitting so 59.999" carries into the minutes instead of printing as 60.00"
+	d, m, s := DecimalToDMS(math.Round(math.Abs(deg)*360000) / 360000)
+	if s >= 59.995 {
+		s = 0
+		if m++; m == 60 {
+			m = 0
+			d++
+		}
+	}
+	return fmt.Sprintf("%d°%d'%.2f\"%c", d, m, s, hemisphere)
+}
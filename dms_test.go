@@ -0,0 +1,67 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestDecimalToDMS(t *testing.T) {
+	tests := []struct {
+		deg  float64
+		d, m int
+		s    float64
+	}{
+		{51.5074, 51, 30, 26.64},
+		{-0.1278, 0, 7, 40.08},
+		{-33.8688, 33, 52, 7.68},
+		{0, 0, 0, 0},
+		{180, 180, 0, 0},
+	}
+	for _, tt := range tests {
+		d, m, s := DecimalToDMS(tt.deg)
+		if d != tt.d || m != tt.m || math.Abs(s-tt.s) > 1e-6 {
+			t.Errorf("DecimalToDMS(%v) = %d, %d, %v, want %d, %d, %v", tt.deg, d, m, s, tt.d, tt.m, tt.s)
+		}
+	}
+}
+
+func TestFormatDMS(t *testing.T) {
+	tests := []struct {
+		deg      float64
+		pos, neg byte
+		want     string
+	}{
+		{51.5074, 'N', 'S', `51°30'26.64"N`},
+		{-0.1278, 'E', 'W', `0°7'40.08"W`},
+		{-33.8688, 'N', 'S', `33°52'7.68"S`},
+		{151.2093, 'E', 'W', `151°12'33.48"E`},
+		{0, 'N', 'S', `0°0'0.00"N`},
+		{51.99999999, 'N', 'S', `52°0'0.00"N`}, // 59.99996" carries into the minutes and degrees
+		{10.5166666, 'E', 'W', `10°31'0.00"E`},
+	}
+	for _, tt := range tests {
+		if got := FormatDMS(tt.deg, tt.pos, tt.neg); got != tt.want {
+			t.Errorf("FormatDMS(%v) = %s, want %s", tt.deg, got, tt.want)
+		}
+	}
+}
+
+func TestParseCoordFormats(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]bool
+		wantErr bool
+	}{
+		{"", map[string]bool{}, false},
+		{"dms", map[string]bool{CoordFormatDMS: true}, false},
+		{" DMS , geohash,utm,", map[string]bool{CoordFormatDMS: true, CoordFormatGeohash: true, CoordFormatUTM: true}, false},
+		{"dms,mgrs", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCoordFormats(tt.in)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCoordFormats(%q) = %v, %v, want %v with error %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// DefaultDryRunTopic is the topic prefix used without a broker, in dry-run or webhook-only mode,
+// when MQTT_TOPIC is unset
+const DefaultDryRunTopic = "gnss"
+
+// doneToken is an mqtt.Token that has already completed
+type doneToken struct{ err error }
+
+// closedChan is returned by every doneToken's Done
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+func (t doneToken) Wait() bool                     { return true }
+func (t doneToken) WaitTimeout(time.Duration) bool { return true }
+func (t doneToken) Done() <-chan struct{}          { return closedChan }
+func (t doneToken) Error() error                   { return t.err }
+
+// StdoutClient is an mqtt.Client for DRY_RUN that writes each publish to w as the topic and
+// payload on one line instead of sending it to a broker. Payloads that aren't valid UTF-8,
+// such as msgpack or gzip, are printed base64-encoded with a "base64:" prefix.
+type StdoutClient struct {
+	opts *mqtt.ClientOptions
+	mu   sync.Mutex // Serializes writes to w
+	w    io.Writer
+}
+
+// NewStdoutClient creates a client printing to w. Connect runs the options' OnConnect
+// handler, so the usual on-connect publishes are printed too.
+func NewStdoutClient(opts *mqtt.ClientOptions, w io.Writer) *StdoutClient {
+	return &StdoutClient{opts: opts, w: w}
+}
+
+func (c *StdoutClient) IsConnected() bool      { return true }
+func (c *StdoutClient) IsConnectionOpen() bool { return true }
+func (c *StdoutClient) Disconnect(uint)        {}
+
+// Connect completes at once, running the OnConnect handler in the background as paho does
+func (c *StdoutClient) Connect() mqtt.Token {
+	if c.opts.OnConnect != nil {
+		go c.opts.OnConnect(c)
+	}
+	return doneToken{}
+}
+
+// Publish prints the topic and payload
+func (c *StdoutClient) Publish(topic string, _ byte, _ bool, payload any) mqtt.Token {
+	var b []byte
+	switch p := payload.(type) {
+	case []byte:
+		b = p
+	case string:
+		b = []byte(p)
+	default:
+		return doneToken{err: fmt.Errorf("unsupported payload type %T", payload)}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := fmt.Fprintf(c.w, "%s %s\n", topic, printablePayload(b))
+	return doneToken{err: err}
+}
+
+// printablePayload returns payload as text, base64-encoded with a "base64:" prefix unless it's
+// valid UTF-8
+func printablePayload(payload []byte) string {
+	if !utf8.Valid(payload) {
+		return "base64:" + base64.StdEncoding.EncodeToString(payload)
+	}
+	return string(payload)
+}
+
+// Subscribe, SubscribeMultiple and Unsubscribe succeed without ever delivering a message
+func (c *StdoutClient) Subscribe(string, byte, mqtt.MessageHandler) mqtt.Token { return doneToken{} }
+func (c *StdoutClient) SubscribeMultiple(map[string]byte, mqtt.MessageHandler) mqtt.Token {
+	return doneToken{}
+}
+func (c *StdoutClient) Unsubscribe(...string) mqtt.Token     { return doneToken{} }
+func (c *StdoutClient) AddRoute(string, mqtt.MessageHandler) {}
+func (c *StdoutClient) OptionsReader() mqtt.ClientOptionsReader {
+	return mqtt.NewOptionsReader(c.opts)
+}
+
+// StdoutPublisher stands in for a fix payload sink such as the webhook under DRY_RUN, writing
+// each payload to W as the sink's name, the topic and the payload on one line
+type StdoutPublisher struct {
+	Name string
+	W    io.Writer
+
+	mu sync.Mutex // Serializes writes to W
+}
+
+// Publish prints the payload
+func (p *StdoutPublisher) Publish(_ context.Context, topic string, payload []byte, _ map[string]string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := fmt.Fprintf(p.W, "%s %s %s\n", p.Name, topic, printablePayload(payload))
+	return err
+}
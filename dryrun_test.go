@@ -0,0 +1,22 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestStdoutPublisher(t *testing.T) {
+	var out bytes.Buffer
+	p := &StdoutPublisher{Name: WebhookSink, W: &out}
+	if err := p.Publish(context.Background(), "gnss/tachyon-1/gnss", []byte(`{"Valid":1}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Publish(context.Background(), "gnss/tachyon-1/gnss", []byte{0x1f, 0x8b}, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "webhook gnss/tachyon-1/gnss {\"Valid\":1}\nwebhook gnss/tachyon-1/gnss base64:H4s=\n"
+	if out.String() != want {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+}
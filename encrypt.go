@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// PayloadKeySize is the AES-256 key length in bytes
+const PayloadKeySize = 32
+
+// ParsePayloadKey decodes a 32-byte AES-256 key given as 64 hex characters or standard base64
+func ParsePayloadKey(s string) ([]byte, error) {
+	if key, err := hex.DecodeString(s); err == nil && len(key) == PayloadKeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == PayloadKeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("payload encryption key must be %d bytes encoded as hex or base64", PayloadKeySize)
+}
+
+// encryptPayload seals plaintext with AES-256-GCM, returning the random 12-byte nonce
+// followed by the ciphertext and 16-byte authentication tag
+func encryptPayload(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newPayloadGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// decryptPayload opens a nonce+ciphertext produced by encryptPayload
+func decryptPayload(key, sealed []byte) ([]byte, error) {
+	gcm, err := newPayloadGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, fmt.Errorf("encrypted payload too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// newPayloadGCM creates an AES-256-GCM AEAD for key
+func newPayloadGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != PayloadKeySize {
+		return nil, fmt.Errorf("payload encryption key must be %d bytes, got %d", PayloadKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestEncryptPayloadRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, PayloadKeySize)
+	tests := []struct {
+		name      string
+		plaintext []byte
+	}{
+		{"json", []byte(`{"Latitude":51.5,"Longitude":-0.12}`)},
+		{"empty", []byte{}},
+		{"binary", []byte{0x00, 0xFF, 0x10, 0x80}},
+		{"large", bytes.Repeat([]byte("fix"), 10000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := encryptPayload(key, tt.plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := 12 + len(tt.plaintext) + 16; len(sealed) != want {
+				t.Errorf("sealed length %d, want nonce + plaintext + tag = %d", len(sealed), want)
+			}
+			got, err := decryptPayload(key, sealed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.plaintext) {
+				t.Errorf("decryptPayload() = %q, want %q", got, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestEncryptPayloadUsesFreshNonce(t *testing.T) {
+	key := make([]byte, PayloadKeySize)
+	a, _ := encryptPayload(key, []byte("same"))
+	b, _ := encryptPayload(key, []byte("same"))
+	if bytes.Equal(a[:12], b[:12]) || bytes.Equal(a, b) {
+		t.Error("two encryptions of the same plaintext share a nonce")
+	}
+}
+
+// TestDecryptPayloadKnownVector opens the AES-256-GCM test case with an all-zero key, nonce and empty plaintext
+func TestDecryptPayloadKnownVector(t *testing.T) {
+	sealed, _ := hex.DecodeString("000000000000000000000000" + "530f8afbc74536b9a963b4f1c4cb738b")
+	got, err := decryptPayload(make([]byte, PayloadKeySize), sealed)
+	if err != nil || len(got) != 0 {
+		t.Errorf("decryptPayload() = %q, %v, want an empty plaintext", got, err)
+	}
+}
+
+func TestDecryptPayloadRejects(t *testing.T) {
+	key := bytes.Repeat([]byte{0x01}, PayloadKeySize)
+	sealed, err := encryptPayload(key, []byte("secret location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 0x01
+	tests := []struct {
+		name   string
+		key    []byte
+		sealed []byte
+	}{
+		{"wrong key", bytes.Repeat([]byte{0x02}, PayloadKeySize), sealed},
+		{"tampered", key, tampered},
+		{"too short", key, sealed[:27]},
+		{"short key", key[:16], sealed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := decryptPayload(tt.key, tt.sealed); err == nil {
+				t.Errorf("decryptPayload() = %q, want an error", got)
+			}
+		})
+	}
+}
+
+func TestParsePayloadKey(t *testing.T) {
+	key := make([]byte, PayloadKeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	tests := []struct {
+		name    string
+		in      string
+		wantErr bool
+	}{
+		{"hex", hex.EncodeToString(key), false},
+		{"upper case hex", strings.ToUpper(hex.EncodeToString(key)), false},
+		{"base64", base64.StdEncoding.EncodeToString(key), false},
+		{"short hex", hex.EncodeToString(key[:16]), true},
+		{"short base64", base64.StdEncoding.EncodeToString(key[:31]), true},
+		{"not encoded", "correct horse battery staple", true},
+		{"empty", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePayloadKey(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePayloadKey(%q) error = %v, want error %t", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, key) {
+				t.Errorf("ParsePayloadKey(%q) = %x, want %x", tt.in, got, key)
+			}
+		})
+	}
+}
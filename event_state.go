@@ -0,0 +1,22 @@
+package main
+
+// eventState remembers the last state announced for each event source so that events are
+// strictly edge-triggered: a source re-reporting its current state publishes nothing
+type eventState struct {
+	current map[string]string
+}
+
+// newEventState creates an empty eventState; every source's first state counts as a transition
+func newEventState() *eventState {
+	return &eventState{current: make(map[string]string)}
+}
+
+// transition records value as the state of name and reports whether it differs from the
+// previous one
+func (e *eventState) transition(name, value string) bool {
+	if prev, ok := e.current[name]; ok && prev == value {
+		return false
+	}
+	e.current[name] = value
+	return true
+}
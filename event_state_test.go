@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestEventStateTransitions(t *testing.T) {
+	type step struct {
+		name, value string
+		want        bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"first state is a transition", []step{{"fixmode", "3D", true}}},
+		{"repeated state emits nothing", []step{
+			{"fixmode", "3D", true}, {"fixmode", "3D", false}, {"fixmode", "3D", false}, {"fixmode", "3D", false},
+		}},
+		{"each change emits once", []step{
+			{"fixmode", "3D", true}, {"fixmode", "2D", true}, {"fixmode", "2D", false}, {"fixmode", "3D", true},
+		}},
+		{"sources are independent", []step{
+			{"zone:home", "enter", true}, {"zone:work", "enter", true}, {"zone:home", "enter", false},
+			{"zone:home", "exit", true}, {"zone:work", "enter", false},
+		}},
+		{"empty value is a state", []step{{"fixmode", "", true}, {"fixmode", "", false}, {"fixmode", "3D", true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newEventState()
+			for i, s := range tt.steps {
+				if got := e.transition(s.name, s.value); got != s.want {
+					t.Errorf("step %d: transition(%q, %q) = %t, want %t", i, s.name, s.value, got, s.want)
+				}
+			}
+		})
+	}
+}
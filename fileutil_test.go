@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteFileAtomicLatestFix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "latest.json")
+
+	var want GnssData
+	for i, lat := range []float64{51.5007, 51.5008, 51.5010} {
+		want = GnssData{DeviceID: "tachyon-1", Latitude: lat, Longitude: -0.1246, Svnum: uint8(8 + i)}
+		raw, err := json.Marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Hold the previous file open, as a polling reader might be mid-read
+		var reader *os.File
+		if i > 0 {
+			if reader, err = os.Open(path); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writeFileAtomic(path, raw, 0o644); err != nil {
+			t.Fatalf("writeFileAtomic() = %v", err)
+		}
+		if reader != nil {
+			// A rename leaves the open file intact, where an in-place write would have changed it
+			var previous GnssData
+			if err := json.NewDecoder(reader).Decode(&previous); err != nil {
+				t.Errorf("reader of the previous file got a partial fix: %v", err)
+			} else if previous.Svnum != uint8(8+i-1) {
+				t.Errorf("reader of the previous file saw Svnum %d, want %d", previous.Svnum, 8+i-1)
+			}
+			reader.Close()
+		}
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got GnssData
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("latest fix file %s isn't valid JSON: %v", raw, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("latest fix file = %+v, want %+v", got, want)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o644 {
+		t.Errorf("file mode = %v, want 0644", perm)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("directory holds %v, want only the latest fix with no temporary files left", names)
+	}
+}
+
+func TestWriteFileAtomicMissingDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "latest.json")
+	if err := writeFileAtomic(path, []byte("{}"), 0o644); err == nil {
+		t.Error("writeFileAtomic() into a missing directory succeeded, want an error")
+	}
+}
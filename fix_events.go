@@ -0,0 +1,57 @@
+package main
+
+import "time"
+
+// FixEvent is published when the fix is lost or regained
+type FixEvent struct {
+	Event     string  `json:"event"`    // "fix_lost" or "fix_regained"
+	Time      string  `json:"time"`     // RFC3339 time of the transition
+	Latitude  float64 `json:"latitude"` // Last valid position for fix_lost, new position for fix_regained
+	Longitude float64 `json:"longitude"`
+	LastFix   string  `json:"last_fix"` // RFC3339 time of the last valid fix before the loss
+}
+
+// FixTracker detects valid→invalid and invalid→valid fix transitions
+type FixTracker struct {
+	lastValid     *GnssData // Most recent valid fix
+	lastValidTime time.Time
+	lost          bool
+}
+
+// NewFixTracker creates a tracker with no fix history
+func NewFixTracker() *FixTracker {
+	return &FixTracker{}
+}
+
+// Update records the fix and returns an event on a transition, or nil otherwise. The fix is
+// only reported lost after a valid one has been seen.
+func (t *FixTracker) Update(data *GnssData, now time.Time) *FixEvent {
+	if data.Valid == 0 {
+		if t.lastValid == nil || t.lost {
+			return nil
+		}
+		t.lost = true
+		return &FixEvent{
+			Event:     "fix_lost",
+			Time:      now.UTC().Format(time.RFC3339),
+			Latitude:  t.lastValid.Latitude,
+			Longitude: t.lastValid.Longitude,
+			LastFix:   t.lastValidTime.UTC().Format(time.RFC3339),
+		}
+	}
+	var event *FixEvent
+	if t.lost {
+		event = &FixEvent{
+			Event:     "fix_regained",
+			Time:      now.UTC().Format(time.RFC3339),
+			Latitude:  data.Latitude,
+			Longitude: data.Longitude,
+			LastFix:   t.lastValidTime.UTC().Format(time.RFC3339),
+		}
+		t.lost = false
+	}
+	fix := *data
+	t.lastValid = &fix
+	t.lastValidTime = now
+	return event
+}
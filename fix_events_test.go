@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFixTrackerTransitions(t *testing.T) {
+	tracker := NewFixTracker()
+	start := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	steps := []struct {
+		name  string
+		valid bool
+		lat   float64
+		want  *FixEvent
+	}{
+		{"no fix at startup", false, 0, nil},
+		{"first fix", true, 51.0, nil},
+		{"still valid", true, 51.1, nil},
+		{"lost", false, 0, &FixEvent{Event: "fix_lost", Time: "2024-01-01T08:00:30Z", Latitude: 51.1, Longitude: -1, LastFix: "2024-01-01T08:00:20Z"}},
+		{"still lost", false, 0, nil},
+		{"still lost again", false, 0, nil},
+		{"regained", true, 51.3, &FixEvent{Event: "fix_regained", Time: "2024-01-01T08:01:00Z", Latitude: 51.3, Longitude: -1, LastFix: "2024-01-01T08:00:20Z"}},
+		{"valid after regaining", true, 51.4, nil},
+		{"lost again", false, 0, &FixEvent{Event: "fix_lost", Time: "2024-01-01T08:01:20Z", Latitude: 51.4, Longitude: -1, LastFix: "2024-01-01T08:01:10Z"}},
+	}
+	counts := map[string]int{}
+	for i, step := range steps {
+		data := &GnssData{Latitude: step.lat, Longitude: -1}
+		if step.valid {
+			data.Valid = 1
+		}
+		got := tracker.Update(data, start.Add(time.Duration(i)*10*time.Second))
+		switch {
+		case got == nil && step.want == nil:
+		case got == nil || step.want == nil || *got != *step.want:
+			t.Errorf("%s: Update() = %+v, want %+v", step.name, got, step.want)
+		}
+		if got != nil {
+			counts[got.Event]++
+		}
+	}
+	if counts["fix_lost"] != 2 || counts["fix_regained"] != 1 {
+		t.Errorf("events %v, want fix_lost twice and fix_regained once", counts)
+	}
+}
+
+func TestFixTrackerKeepsCopy(t *testing.T) {
+	tracker := NewFixTracker()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := &GnssData{Valid: 1, Latitude: 51, Longitude: -1}
+	tracker.Update(data, now)
+	data.Latitude = 99 // The caller reuses its buffer
+	event := tracker.Update(&GnssData{}, now.Add(time.Second))
+	if event == nil || event.Latitude != 51 {
+		t.Errorf("fix_lost event %+v, want the position as it was when valid", event)
+	}
+}
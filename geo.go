@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// EarthRadiusMeters is the mean Earth radius used for great-circle calculations
+const EarthRadiusMeters = 6371000.0
+
+// Haversine returns the great-circle distance in meters between two coordinates in degrees
+func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * EarthRadiusMeters * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// Bearing returns the initial great-circle bearing in degrees from true north, in [0, 360),
+// for travel from the first coordinate to the second
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	deg := math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+	if deg >= 360 { // Rounding can push tiny negative angles up to exactly 360
+		deg = 0
+	}
+	return deg
+}
+
+// OffsetPosition moves a coordinate by the given distances in meters north and east. It uses a
+// local flat-earth approximation, accurate for offsets of up to a few kilometers.
+func OffsetPosition(lat, lon, north, east float64) (float64, float64) {
+	dLat := north / EarthRadiusMeters * 180 / math.Pi
+	dLon := east / (EarthRadiusMeters * math.Cos(lat*math.Pi/180)) * 180 / math.Pi
+	return lat + dLat, lon + dLon
+}
+
+// ENUOffset returns the east and north displacement in meters of a position from a reference
+// point, projected onto the local tangent plane at the reference. It's accurate for
+// displacements of up to a few kilometers.
+func ENUOffset(refLat, refLon, lat, lon float64) (east, north float64) {
+	north = (lat - refLat) * math.Pi / 180 * EarthRadiusMeters
+	east = (lon - refLon) * math.Pi / 180 * EarthRadiusMeters * math.Cos(refLat*math.Pi/180)
+	return east, north
+}
+
+// BoundingBox is a latitude/longitude rectangle, e.g. the extent of the positions seen
+// during a rollup period
+type BoundingBox struct {
+	MinLatitude  float64 `json:"min_latitude"`
+	MinLongitude float64 `json:"min_longitude"`
+	MaxLatitude  float64 `json:"max_latitude"`
+	MaxLongitude float64 `json:"max_longitude"`
+}
+
+// ParseBoundingBox parses "minLat,minLon,maxLat,maxLon" in degrees
+func ParseBoundingBox(s string) (BoundingBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, fmt.Errorf("expected minLat,minLon,maxLat,maxLon, got %q", s)
+	}
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BoundingBox{}, fmt.Errorf("invalid coordinate %q", part)
+		}
+		v[i] = f
+	}
+	bb := BoundingBox{MinLatitude: v[0], MinLongitude: v[1], MaxLatitude: v[2], MaxLongitude: v[3]}
+	if bb.MinLatitude < -90 || bb.MaxLatitude > 90 || bb.MinLatitude > bb.MaxLatitude {
+		return BoundingBox{}, fmt.Errorf("latitudes must satisfy -90 <= minLat <= maxLat <= 90")
+	}
+	if bb.MinLongitude < -180 || bb.MaxLongitude > 180 || bb.MinLongitude > bb.MaxLongitude {
+		return BoundingBox{}, fmt.Errorf("longitudes must satisfy -180 <= minLon <= maxLon <= 180")
+	}
+	return bb, nil
+}
+
+// Contains reports whether the point lies inside the box, edges included
+func (b *BoundingBox) Contains(lat, lon float64) bool {
+	return lat >= b.MinLatitude && lat <= b.MaxLatitude && lon >= b.MinLongitude && lon <= b.MaxLongitude
+}
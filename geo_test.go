@@ -0,0 +1,169 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSanityBoundingBox(t *testing.T) {
+	// Roughly Western Europe, far larger than normal operation around London
+	box, err := ParseBoundingBox("35,-15,65,20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     bool
+	}{
+		{"London", 51.5, -0.12, true},
+		{"Edinburgh", 55.95, -3.19, true},
+		{"on the edge", 35, -15, true},
+		{"New York glitch", 40.71, -74.0, false},
+		{"Sydney glitch", -33.87, 151.21, false},
+		{"null island", 0, 0, false},
+		{"just north", 65.0001, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := box.Contains(tt.lat, tt.lon); got != tt.want {
+				t.Errorf("Contains(%v, %v) = %t, want %t", tt.lat, tt.lon, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBoundingBox(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    BoundingBox
+		wantErr bool
+	}{
+		{"35,-15,65,20", BoundingBox{35, -15, 65, 20}, false},
+		{" -90 , -180 , 90 , 180 ", BoundingBox{-90, -180, 90, 180}, false},
+		{"35,-15,65", BoundingBox{}, true},
+		{"35,-15,65,20,1", BoundingBox{}, true},
+		{"north,-15,65,20", BoundingBox{}, true},
+		{"65,-15,35,20", BoundingBox{}, true},
+		{"35,20,65,-15", BoundingBox{}, true},
+		{"-91,-15,65,20", BoundingBox{}, true},
+		{"35,-15,65,181", BoundingBox{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBoundingBox(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseBoundingBox(%q) = %+v, %v, want %+v, error %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestENUOffset(t *testing.T) {
+	const metersPerDegree = EarthRadiusMeters * math.Pi / 180
+	tests := []struct {
+		name                string
+		refLat, refLon      float64
+		lat, lon            float64
+		wantEast, wantNorth float64
+	}{
+		{"same point", 51.5, -0.12, 51.5, -0.12, 0, 0},
+		{"north on the equator", 0, 0, 0.0001, 0, 0, 0.0001 * metersPerDegree},
+		{"east on the equator", 0, 0, 0, 0.0001, 0.0001 * metersPerDegree, 0},
+		{"east at 60 degrees", 60, 10, 60, 10.0002, 0.0001 * metersPerDegree, 0},
+		{"south west", 51.5, -0.12, 51.49991, -0.12009, -0.00009 * metersPerDegree * math.Cos(51.5*math.Pi/180), -0.00009 * metersPerDegree},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			east, north := ENUOffset(tt.refLat, tt.refLon, tt.lat, tt.lon)
+			if math.Abs(east-tt.wantEast) > 1e-6 || math.Abs(north-tt.wantNorth) > 1e-6 {
+				t.Errorf("ENUOffset() = %.6f E, %.6f N, want %.6f E, %.6f N", east, north, tt.wantEast, tt.wantNorth)
+			}
+		})
+	}
+}
+
+func TestENUOffsetMatchesHaversine(t *testing.T) {
+	// For a small displacement the projected distance agrees with the great-circle distance to
+	// well under a millimeter
+	refLat, refLon := 51.5007, -0.1246
+	lat, lon := OffsetPosition(refLat, refLon, 30, -40)
+	east, north := ENUOffset(refLat, refLon, lat, lon)
+	if math.Abs(east+40) > 1e-6 || math.Abs(north-30) > 1e-6 {
+		t.Errorf("ENUOffset() = %.6f E, %.6f N, want -40 E, 30 N", east, north)
+	}
+	if d := Haversine(refLat, refLon, lat, lon); math.Abs(math.Hypot(east, north)-d) > 1e-3 {
+		t.Errorf("projected distance %.4f m, great-circle distance %.4f m", math.Hypot(east, north), d)
+	}
+}
+
+func TestHaversine(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		wantKm                 float64
+		toleranceKm            float64
+	}{
+		{"London to Paris", 51.5074, -0.1278, 48.8566, 2.3522, 343.5, 1},
+		{"New York to Los Angeles", 40.7128, -74.0060, 34.0522, -118.2437, 3935.7, 5},
+		{"Sydney to Melbourne", -33.8688, 151.2093, -37.8136, 144.9631, 713.4, 2},
+		{"London to Sydney", 51.5074, -0.1278, -33.8688, 151.2093, 16993.9, 20},
+		{"same point", 51.5, -0.12, 51.5, -0.12, 0, 0},
+		{"quarter of the equator", 0, 0, 0, 90, EarthRadiusMeters * math.Pi / 2 / 1000, 1e-9},
+		{"antipodes", 0, 0, 0, 180, EarthRadiusMeters * math.Pi / 1000, 1e-9},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, EarthRadiusMeters * math.Pi / 180 / 1000, 1e-9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Haversine(tt.lat1, tt.lon1, tt.lat2, tt.lon2) / 1000
+			if math.Abs(got-tt.wantKm) > tt.toleranceKm {
+				t.Errorf("Haversine() = %.3f km, want %.3f ± %g km", got, tt.wantKm, tt.toleranceKm)
+			}
+			if back := Haversine(tt.lat2, tt.lon2, tt.lat1, tt.lon1) / 1000; math.Abs(back-got) > 1e-9 {
+				t.Errorf("Haversine() is %.3f km one way and %.3f km back", got, back)
+			}
+		})
+	}
+}
+
+func TestBearing(t *testing.T) {
+	const lat, lon = 51.5, -0.12
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{"north", lat, lon, lat + 0.01, lon, 0},
+		{"east", 0, lon, 0, lon + 0.01, 90},
+		{"south", lat, lon, lat - 0.01, lon, 180},
+		{"west", 0, lon, 0, lon - 0.01, 270},
+		{"north east", 0, 0, 0.001, 0.001, 45},
+		{"just west of north", lat, lon, lat + 0.01, lon - 0.0001, 359.6},
+		{"just east of north", lat, lon, lat + 0.01, lon + 0.0001, 0.4},
+		{"east across the antimeridian", 0, 179.99, 0, -179.99, 90},
+		{"west across the antimeridian", 0, -179.99, 0, 179.99, 270},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Bearing(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if got < 0 || got >= 360 {
+				t.Fatalf("Bearing() = %v, outside [0, 360)", got)
+			}
+			// Compare on the circle so 359.99 and 0.01 count as close
+			if diff := math.Abs(math.Mod(got-tt.want+540, 360) - 180); diff > 0.05 {
+				t.Errorf("Bearing() = %.3f, want %.1f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBearingWrapsToZero(t *testing.T) {
+	// A bearing a hair west of north must wrap to just under 360, never reach it
+	for _, dLon := range []float64{-1e-9, -1e-12, -1e-15, 0} {
+		got := Bearing(0, 0, 1, dLon)
+		if got < 0 || got >= 360 {
+			t.Errorf("Bearing() for a longitude offset of %g = %v, outside [0, 360)", dLon, got)
+		}
+		if got > 1e-6 && got < 360-1e-6 {
+			t.Errorf("Bearing() for a longitude offset of %g = %v, want about north", dLon, got)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// geocodeTimeout bounds a single reverse-geocoding request
+const geocodeTimeout = 10 * time.Second
+
+// Geocoder resolves coordinates to a human-readable address
+type Geocoder interface {
+	Reverse(ctx context.Context, lat, lon float64) (string, error)
+}
+
+// NominatimGeocoder reverse-geocodes using a Nominatim-compatible HTTP API
+type NominatimGeocoder struct {
+	Endpoint string       // Reverse endpoint, e.g. https://nominatim.openstreetmap.org/reverse
+	Key      string       // Optional API key sent as the "key" query parameter
+	Client   *http.Client // HTTP client used for requests
+}
+
+// Reverse resolves lat/lon to the display name returned by the Nominatim API
+func (g *NominatimGeocoder) Reverse(ctx context.Context, lat, lon float64) (string, error) {
+	u, err := url.Parse(g.Endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid geocoder endpoint: %w", err)
+	}
+	q := u.Query()
+	q.Set("format", "jsonv2")
+	q.Set("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	q.Set("lon", strconv.FormatFloat(lon, 'f', -1, 64))
+	if g.Key != "" {
+		q.Set("key", g.Key)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "particle-tachyon-gps-dbus")
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("geocoder returned %s", resp.Status)
+	}
+	var body struct {
+		DisplayName string `json:"display_name"`
+		Error       string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode geocoder response: %w", err)
+	}
+	if body.Error != "" {
+		return "", fmt.Errorf("geocoder error: %s", body.Error)
+	}
+	return body.DisplayName, nil
+}
+
+// AddressCache serves the last resolved address while the position stays within a radius of it,
+// and refreshes it in the background once the position has moved beyond, at most once per
+// interval, so lookups never block the caller
+type AddressCache struct {
+	geocoder    Geocoder
+	clock       Clock
+	minInterval time.Duration // Minimum time between geocoder requests
+	radius      float64       // Meters the position may move before the address is refreshed
+
+	mu          sync.Mutex
+	address     string
+	lat, lon    float64
+	resolved    bool
+	inFlight    bool
+	lastAttempt time.Time
+}
+
+// NewAddressCache creates a cache refreshing via geocoder at most once per minInterval
+func NewAddressCache(geocoder Geocoder, clock Clock, minInterval time.Duration, radius float64) *AddressCache {
+	return &AddressCache{geocoder: geocoder, clock: clock, minInterval: minInterval, radius: radius}
+}
+
+// Lookup returns the cached address if the position is within its radius. Otherwise it returns
+// "", rather than an address for somewhere else, and starts a background refresh if the rate
+// limit allows.
+func (c *AddressCache) Lookup(ctx context.Context, lat, lon float64) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resolved && Haversine(c.lat, c.lon, lat, lon) <= c.radius {
+		return c.address
+	}
+	now := c.clock.Now()
+	if !c.inFlight && now.Sub(c.lastAttempt) >= c.minInterval {
+		c.inFlight = true
+		c.lastAttempt = now
+		go c.refresh(ctx, lat, lon)
+	}
+	return ""
+}
+
+// refresh resolves lat/lon and stores the result, keeping the previous address and its position
+// on failure
+func (c *AddressCache) refresh(ctx context.Context, lat, lon float64) {
+	ctx, cancel := context.WithTimeout(ctx, geocodeTimeout)
+	defer cancel()
+	address, err := c.geocoder.Reverse(ctx, lat, lon)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight = false
+	if err != nil {
+		log.Printf("Reverse geocoding failed: %v", err)
+		return
+	}
+	c.address = address
+	c.lat, c.lon = lat, lon
+	c.resolved = true
+}
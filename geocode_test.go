@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockGeocoder names addresses after the coordinates it's asked for and counts the requests
+type mockGeocoder struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+func (g *mockGeocoder) Reverse(ctx context.Context, lat, lon float64) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.calls++
+	if g.err != nil {
+		return "", g.err
+	}
+	return fmt.Sprintf("%.3f,%.3f", lat, lon), nil
+}
+
+func (g *mockGeocoder) Calls() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.calls
+}
+
+// waitForRefresh waits for the cache's background refresh to finish
+func waitForRefresh(t *testing.T, c *AddressCache) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		inFlight := c.inFlight
+		c.mu.Unlock()
+		if !inFlight {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("geocoder refresh didn't finish")
+}
+
+func TestAddressCache(t *testing.T) {
+	geocoder := &mockGeocoder{}
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cache := NewAddressCache(geocoder, clock, time.Minute, 100)
+	ctx := context.Background()
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		lat     float64
+		want    string // Address after the refresh, if any, completes
+		calls   int
+	}{
+		{"first lookup resolves", 0, 51, "51.000,-1.000", 1},
+		{"within radius uses cache", 30 * time.Second, 51.0005, "51.000,-1.000", 1},
+		{"moved but rate limited", time.Second, 51.01, "", 1},
+		{"moved after interval", time.Minute, 51.01, "51.010,-1.000", 2},
+		{"back within new radius", time.Hour, 51.0101, "51.010,-1.000", 2},
+	}
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		cache.Lookup(ctx, step.lat, -1)
+		waitForRefresh(t, cache)
+		if got := cache.Lookup(ctx, step.lat, -1); got != step.want {
+			t.Errorf("%s: address %q, want %q", step.name, got, step.want)
+		}
+		if got := geocoder.Calls(); got != step.calls {
+			t.Errorf("%s: %d geocoder calls, want %d", step.name, got, step.calls)
+		}
+	}
+}
+
+func TestAddressCacheFailedRefresh(t *testing.T) {
+	geocoder := &mockGeocoder{}
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cache := NewAddressCache(geocoder, clock, 0, 100)
+	cache.Lookup(context.Background(), 51, -1)
+	waitForRefresh(t, cache)
+
+	geocoder.mu.Lock()
+	geocoder.err = errors.New("unavailable")
+	geocoder.mu.Unlock()
+	cache.Lookup(context.Background(), 52, -1)
+	waitForRefresh(t, cache)
+	if got := cache.Lookup(context.Background(), 52, -1); got != "" {
+		t.Errorf("address after a failed refresh = %q, want none for the new position", got)
+	}
+	if got := cache.Lookup(context.Background(), 51, -1); got != "51.000,-1.000" {
+		t.Errorf("address back at the cached position = %q, want the previous one", got)
+	}
+}
+
+func TestNominatimGeocoder(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{"address", http.StatusOK, `{"display_name": "10 Downing Street, London"}`, "10 Downing Street, London", false},
+		{"unable to geocode", http.StatusOK, `{"error": "Unable to geocode"}`, "", true},
+		{"server error", http.StatusInternalServerError, ``, "", true},
+		{"malformed body", http.StatusOK, `<html>`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var query map[string]string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				query = map[string]string{"format": q.Get("format"), "lat": q.Get("lat"), "lon": q.Get("lon"), "key": q.Get("key")}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+			g := &NominatimGeocoder{Endpoint: srv.URL + "/reverse", Key: "secret", Client: srv.Client()}
+			got, err := g.Reverse(context.Background(), 51.5034, -0.1276)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("Reverse() = %q, %v, want %q, error %t", got, err, tt.want, tt.wantErr)
+			}
+			want := map[string]string{"format": "jsonv2", "lat": "51.5034", "lon": "-0.1276", "key": "secret"}
+			for k, v := range want {
+				if query[k] != v {
+					t.Errorf("query %s = %q, want %q", k, query[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Geofence states reported in GeofenceEvent
+const (
+	GeofenceInside  = "inside"
+	GeofenceOutside = "outside"
+)
+
+// GeofenceEvent is published to <topic>/geofence when the device crosses the geofence boundary
+type GeofenceEvent struct {
+	Event     string  `json:"event"`              // GeofenceInside or GeofenceOutside
+	Previous  string  `json:"previous,omitempty"` // Prior state, empty for the first fix after startup
+	DistanceM float64 `json:"distance_m"`         // Distance from the geofence center
+	RadiusM   float64 `json:"radius_m"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// ParseGeofenceCenter parses a GEOFENCE_CENTER value, "lat,lon" in degrees
+func ParseGeofenceCenter(s string) (lat, lon float64, err error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected lat,lon, got %q", s)
+	}
+	if lat, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid latitude %q", parts[0])
+	}
+	if lon, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid longitude %q", parts[1])
+	}
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("coordinates out of range: %q", s)
+	}
+	return lat, lon, nil
+}
+
+// GeofenceTracker tests fixes against a circular geofence and reports boundary crossings
+type GeofenceTracker struct {
+	lat, lon float64 // Center in degrees
+	radius   float64 // Meters
+	state    string  // Last reported state, empty until the first fix
+}
+
+// NewGeofenceTracker creates a tracker for a circle of radius meters around lat, lon
+func NewGeofenceTracker(lat, lon, radius float64) *GeofenceTracker {
+	return &GeofenceTracker{lat: lat, lon: lon, radius: radius}
+}
+
+// Update evaluates the position and returns an event when the state differs from the previous
+// fix. The first fix after startup always produces one so subscribers learn the initial state.
+// A point exactly on the boundary counts as inside.
+func (t *GeofenceTracker) Update(lat, lon float64) (*GeofenceEvent, bool) {
+	distance := Haversine(t.lat, t.lon, lat, lon)
+	state := GeofenceOutside
+	if distance <= t.radius {
+		state = GeofenceInside
+	}
+	if state == t.state {
+		return nil, false
+	}
+	event := &GeofenceEvent{
+		Event:     state,
+		Previous:  t.state,
+		DistanceM: distance,
+		RadiusM:   t.radius,
+		Latitude:  lat,
+		Longitude: lon,
+	}
+	t.state = state
+	return event, true
+}
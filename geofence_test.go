@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGeofenceTransitions(t *testing.T) {
+	const lat, lon, radius = 51.5, -0.12, 100
+	type step struct {
+		meters float64 // Distance north of the center
+		want   string  // Event emitted, empty for none
+		prev   string
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"starts inside", []step{{0, GeofenceInside, ""}, {50, "", ""}, {99, "", ""}}},
+		{"starts outside", []step{{500, GeofenceOutside, ""}, {101, "", ""}, {1000, "", ""}}},
+		{"exit and re-enter", []step{
+			{10, GeofenceInside, ""}, {150, GeofenceOutside, GeofenceInside}, {200, "", ""},
+			{90, GeofenceInside, GeofenceOutside}, {80, "", ""},
+		}},
+		{"boundary counts as inside", []step{{101, GeofenceOutside, ""}, {99.999, GeofenceInside, GeofenceOutside}}},
+		{"jitter across the boundary", []step{
+			{99, GeofenceInside, ""}, {101, GeofenceOutside, GeofenceInside}, {99, GeofenceInside, GeofenceOutside},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fence := NewGeofenceTracker(lat, lon, radius)
+			for i, s := range tt.steps {
+				event, ok := fence.Update(OffsetPosition(lat, lon, s.meters, 0))
+				if s.want == "" {
+					if ok {
+						t.Errorf("step %d at %vm: got %+v, want no event", i, s.meters, event)
+					}
+					continue
+				}
+				if !ok {
+					t.Errorf("step %d at %vm: no event, want %s", i, s.meters, s.want)
+					continue
+				}
+				if event.Event != s.want || event.Previous != s.prev || event.RadiusM != radius ||
+					math.Abs(event.DistanceM-s.meters) > 0.01 || event.Longitude != lon {
+					t.Errorf("step %d at %vm: got %+v, want %s after %q", i, s.meters, event, s.want, s.prev)
+				}
+			}
+		})
+	}
+}
+
+func TestParseGeofenceCenter(t *testing.T) {
+	tests := []struct {
+		in       string
+		lat, lon float64
+		wantErr  bool
+	}{
+		{"51.5,-0.12", 51.5, -0.12, false},
+		{" -33.8688 , 151.2093 ", -33.8688, 151.2093, false},
+		{"90,180", 90, 180, false},
+		{"51.5", 0, 0, true},
+		{"51.5,-0.12,10", 0, 0, true},
+		{"north,-0.12", 0, 0, true},
+		{"51.5,west", 0, 0, true},
+		{"91,0", 0, 0, true},
+		{"0,-180.5", 0, 0, true},
+	}
+	for _, tt := range tests {
+		lat, lon, err := ParseGeofenceCenter(tt.in)
+		if (err != nil) != tt.wantErr || lat != tt.lat || lon != tt.lon {
+			t.Errorf("ParseGeofenceCenter(%q) = %v, %v, %v, want %v, %v with error %t", tt.in, lat, lon, err, tt.lat, tt.lon, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+// geohashBase32 is the geohash alphabet (digits and lowercase letters without a, i, l, o)
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// MaxGeohashPrecision is the longest supported geohash, roughly 3.7cm x 1.9cm
+const MaxGeohashPrecision = 12
+
+// Geohash encodes a coordinate as a geohash of the given number of characters
+func Geohash(lat, lon float64, precision int) string {
+	precision = max(1, min(precision, MaxGeohashPrecision))
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+	hash := make([]byte, 0, precision)
+	evenBit := true // Bits alternate starting with longitude
+	bit, ch := 0, 0
+	for len(hash) < precision {
+		if evenBit {
+			mid := (lonRange[0] + lonRange[1]) / 2
+			if lon >= mid {
+				ch = ch<<1 | 1
+				lonRange[0] = mid
+			} else {
+				ch <<= 1
+				lonRange[1] = mid
+			}
+		} else {
+			mid := (latRange[0] + latRange[1]) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				latRange[0] = mid
+			} else {
+				ch <<= 1
+				latRange[1] = mid
+			}
+		}
+		evenBit = !evenBit
+		if bit++; bit == 5 {
+			hash = append(hash, geohashBase32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return string(hash)
+}
+
+// GeohashEvent is published when the fix moves into a different geohash bucket
+type GeohashEvent struct {
+	Previous  string  `json:"previous,omitempty"` // Previous bucket, empty for the first fix
+	Geohash   string  `json:"geohash"`            // New bucket
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
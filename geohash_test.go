@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeohash(t *testing.T) {
+	tests := []struct {
+		name      string
+		lat, lon  float64
+		precision int
+		want      string
+	}{
+		{"Jutland reference", 57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{"Jutland reference, coarse", 57.64911, 10.40744, 5, "u4pru"},
+		{"Spain reference", 42.6, -5.6, 5, "ezs42"},
+		{"origin", 0, 0, 6, "s00000"},
+		{"south west corner", -90, -180, 4, "0000"},
+		{"north east corner", 90, 180, 4, "zzzz"},
+		{"precision below 1 clamps", 42.6, -5.6, 0, "e"},
+		{"precision above maximum clamps", 57.64911, 10.40744, 20, "u4pruydqqvj"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Geohash(tt.lat, tt.lon, tt.precision)
+			if tt.precision > MaxGeohashPrecision {
+				if len(got) != MaxGeohashPrecision || !strings.HasPrefix(got, tt.want) {
+					t.Errorf("Geohash(%v, %v, %d) = %q, want %d characters starting %q", tt.lat, tt.lon, tt.precision, got, MaxGeohashPrecision, tt.want)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Geohash(%v, %v, %d) = %q, want %q", tt.lat, tt.lon, tt.precision, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeohashNeighboursDiffer(t *testing.T) {
+	// Points either side of the prime meridian share no prefix at all
+	if a, b := Geohash(51.5, -0.0001, 7), Geohash(51.5, 0.0001, 7); a[0] == b[0] {
+		t.Errorf("Geohash either side of the meridian = %q and %q, want different first characters", a, b)
+	}
+	// A few meters apart falls in the same precision 6 bucket (about 1.2 km)
+	if a, b := Geohash(51.50070, -0.12460, 6), Geohash(51.50072, -0.12458, 6); a != b {
+		t.Errorf("nearby points hash to %q and %q, want the same bucket", a, b)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import "encoding/json"
+
+// geoJSONPoint is a GeoJSON Point geometry
+type geoJSONPoint struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"` // [longitude, latitude, altitude]
+}
+
+// geoJSONFeature is a GeoJSON Feature with a Point geometry
+type geoJSONFeature struct {
+	Type       string         `json:"type"`
+	Geometry   geoJSONPoint   `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// MarshalGeoJSON encodes the fix as a GeoJSON Feature. The Point geometry holds
+// [longitude, latitude, altitude] in GeoJSON's longitude-first order, and every other
+// field is carried in properties under its usual payload name.
+func (d *GnssData) MarshalGeoJSON() ([]byte, error) {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	var properties map[string]any
+	if err := json.Unmarshal(raw, &properties); err != nil {
+		return nil, err
+	}
+	delete(properties, "Latitude")
+	delete(properties, "Longitude")
+	delete(properties, "Altitude")
+	return json.Marshal(geoJSONFeature{
+		Type: "Feature",
+		Geometry: geoJSONPoint{
+			Type:        "Point",
+			Coordinates: []float64{d.Longitude, d.Latitude, d.Altitude},
+		},
+		Properties: properties,
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestMarshalGeoJSONCoordinateOrder guards against swapping GeoJSON's longitude-first order.
+// Sydney and Anchorage are picked so a swap puts the point outside the valid latitude range.
+func TestMarshalGeoJSONCoordinateOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		lat, lon, alt float64
+	}{
+		{"Sydney", -33.8688, 151.2093, 58},
+		{"London", 51.5007, -0.1246, 35.2},
+		{"Anchorage", 61.2181, -149.9003, -2.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := GnssData{Latitude: tt.lat, Longitude: tt.lon, Altitude: tt.alt}
+			payload, err := data.MarshalGeoJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var feature struct {
+				Type     string `json:"type"`
+				Geometry struct {
+					Type        string    `json:"type"`
+					Coordinates []float64 `json:"coordinates"`
+				} `json:"geometry"`
+			}
+			if err := json.Unmarshal(payload, &feature); err != nil {
+				t.Fatal(err)
+			}
+			if feature.Type != "Feature" || feature.Geometry.Type != "Point" {
+				t.Errorf("got a %s with a %s geometry, want a Feature with a Point", feature.Type, feature.Geometry.Type)
+			}
+			want := []float64{tt.lon, tt.lat, tt.alt}
+			if !reflect.DeepEqual(feature.Geometry.Coordinates, want) {
+				t.Errorf("coordinates = %v, want [longitude, latitude, altitude] %v", feature.Geometry.Coordinates, want)
+			}
+			if lat := feature.Geometry.Coordinates[1]; lat < -90 || lat > 90 {
+				t.Errorf("second coordinate %v isn't a latitude", lat)
+			}
+		})
+	}
+}
+
+func TestMarshalGeoJSONProperties(t *testing.T) {
+	data := GnssData{
+		DeviceID: "tachyon-1", Latitude: 51.5, Longitude: -0.12, Altitude: 35, Speed: 12.5, Svnum: 9,
+		Utc: NmeaUtcTime{Year: 2024, Month: 6, Date: 1, Hour: 12, Min: 30, Sec: 15},
+	}
+	payload, err := data.MarshalGeoJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feature struct {
+		Properties map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal(payload, &feature); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"Latitude", "Longitude", "Altitude"} {
+		if _, ok := feature.Properties[key]; ok {
+			t.Errorf("properties repeat %s, which belongs in the geometry", key)
+		}
+	}
+	if feature.Properties["Speed"] != 12.5 || feature.Properties["Svnum"] != 9.0 || feature.Properties["device_id"] != "tachyon-1" {
+		t.Errorf("properties %v, want Speed, Svnum and device_id carried over", feature.Properties)
+	}
+	utc, ok := feature.Properties["Utc"].(map[string]any)
+	if !ok || utc["Year"] != 2024.0 || utc["Sec"] != 15.0 {
+		t.Errorf("properties Utc = %v, want the fix time", feature.Properties["Utc"])
+	}
+}
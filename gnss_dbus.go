@@ -1,7 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/godbus/dbus/v5"
 )
@@ -32,55 +38,330 @@ type NmeaUtcTime struct {
 	Sec   int8  // Seconds (0-59)
 }
 
+// ErrNoUtcTime is returned by NmeaUtcTime.Time when the modem hasn't reported a time yet
+var ErrNoUtcTime = errors.New("no UTC time reported")
+
+// Time converts the UTC time to a time.Time, validating each field's range. Two digit years
+// are taken to be in the 2000s. It returns ErrNoUtcTime when all fields are zero.
+func (u NmeaUtcTime) Time() (time.Time, error) {
+	if u == (NmeaUtcTime{}) {
+		return time.Time{}, ErrNoUtcTime
+	}
+	year := int(u.Year)
+	if year >= 0 && year < 100 {
+		year += 2000
+	}
+	switch {
+	case year < 1980: // GPS epoch
+		return time.Time{}, fmt.Errorf("invalid UTC year %d", u.Year)
+	case u.Month < 1 || u.Month > 12:
+		return time.Time{}, fmt.Errorf("invalid UTC month %d", u.Month)
+	case u.Hour < 0 || u.Hour > 23:
+		return time.Time{}, fmt.Errorf("invalid UTC hour %d", u.Hour)
+	case u.Min < 0 || u.Min > 59:
+		return time.Time{}, fmt.Errorf("invalid UTC minute %d", u.Min)
+	case u.Sec < 0 || u.Sec > 59:
+		return time.Time{}, fmt.Errorf("invalid UTC second %d", u.Sec)
+	}
+	t := time.Date(year, time.Month(u.Month), int(u.Date), int(u.Hour), int(u.Min), int(u.Sec), 0, time.UTC)
+	if u.Date < 1 || t.Day() != int(u.Date) { // time.Date normalizes days past the end of the month
+		return time.Time{}, fmt.Errorf("invalid UTC date %d-%02d-%02d", year, u.Month, u.Date)
+	}
+	return t, nil
+}
+
 // GnssFullData represents complete GNSS data retrieved from the D-Bus interface
 type GnssFullData struct {
-	Valid          int32                                     // Validity flag for GPS data
-	LastLockTimeMs uint64                                    // Last GPS lock time in milliseconds
-	Svnum          uint8                                     // Number of satellites in view
-	BeidouSvnum    uint8                                     // Number of Beidou satellites in view
-	NSHemi         string                                    // North/South hemisphere indicator
-	EWHemi         string                                    // East/West hemisphere indicator
-	Latitude       float64                                   // Latitude coordinate
-	Longitude      float64                                   // Longitude coordinate
-	Gpssta         uint8                                     // GPS status
-	Posslnum       uint8                                     // Position solution number
-	Fixmode        uint8                                     // GPS fix mode
-	Pdop           float64                                   // Position dilution of precision
-	Hdop           float64                                   // Horizontal dilution of precision
-	Vdop           float64                                   // Vertical dilution of precision
-	Altitude       float64                                   // Altitude above sea level
-	Speed          float64                                   // Ground speed
-	Utc            NmeaUtcTime                               // UTC time information
-	Slmsg          [MaxSatelliteCount]NmeaSatelliteMsg       // Satellite message data
-	BeidouSlmsg    [MaxSatelliteCount]BeidouNmeaSatelliteMsg // Beidou satellite message data
-	Possl          [MaxSatelliteCount]uint8                  // Position solution levels
-}
-
-// GnssData represents simplified GNSS data for publishing
+	Valid          int32                    // Validity flag for GPS data
+	LastLockTimeMs uint64                   // Last GPS lock time in milliseconds
+	Svnum          uint8                    // Number of GPS satellites in view (NMEA GSV), not all of which are used in the solution
+	BeidouSvnum    uint8                    // Number of Beidou satellites in view
+	GlonassSvnum   uint8                    // Number of GLONASS satellites in view
+	GalileoSvnum   uint8                    // Number of Galileo satellites in view
+	NSHemi         string                   // North/South hemisphere indicator
+	EWHemi         string                   // East/West hemisphere indicator
+	Latitude       float64                  // Latitude coordinate
+	Longitude      float64                  // Longitude coordinate
+	Gpssta         uint8                    // GPS status
+	Posslnum       uint8                    // Number of satellites used in the position solution (NMEA GSA), see SatellitesUsed
+	Fixmode        uint8                    // GPS fix mode
+	Pdop           float64                  // Position dilution of precision
+	Hdop           float64                  // Horizontal dilution of precision
+	Vdop           float64                  // Vertical dilution of precision
+	Altitude       float64                  // Altitude above sea level
+	Speed          float64                  // Ground speed in km/h
+	Utc            NmeaUtcTime              // UTC time information
+	Slmsg          []NmeaSatelliteMsg       // Satellite message data, as many entries as the modem reports
+	BeidouSlmsg    []BeidouNmeaSatelliteMsg // Beidou satellite message data
+	GlonassSlmsg   []NmeaSatelliteMsg       // GLONASS satellite message data, nil if not reported
+	GalileoSlmsg   []NmeaSatelliteMsg       // Galileo satellite message data, nil if not reported
+	Possl          []uint8                  // PRNs of the satellites used in the position solution (NMEA GSA), zero padded
+}
+
+// GnssData represents GNSS data for publishing
 type GnssData struct {
-	Latitude       float64                                   // Latitude coordinate
-	Longitude      float64                                   // Longitude coordinate
-	Speed          float64                                   // Ground speed
-	Valid          int32                                     // Validity flag for GPS data
-	LastLockTimeMs uint64                                    // Last GPS lock time in milliseconds
-	Svnum          uint8                                     // Number of satellites in view
-	BeidouSvnum    uint8                                     // Number of Beidou satellites in view
-	NSHemi         string                                    // North/South hemisphere indicator
-	EWHemi         string                                    // East/West hemisphere indicator
-	Altitude       float64                                   // Altitude above sea level
-	Utc            NmeaUtcTime                               // UTC time information
-	Slmsg          [MaxSatelliteCount]NmeaSatelliteMsg       // Satellite message data
-	BeidouSlmsg    [MaxSatelliteCount]BeidouNmeaSatelliteMsg // Beidou satellite message data
-	Possl          [MaxSatelliteCount]uint8                  // Position solution levels
+	DeviceID           string                   `json:"device_id,omitempty"` // DEVICE_ID of the bridge that read the fix
+	Latitude           float64                  // Latitude coordinate
+	Longitude          float64                  // Longitude coordinate
+	Speed              float64                  // Ground speed
+	SpeedUnit          string                   `json:"speed_unit"` // SPEED_UNIT that Speed is expressed in
+	Valid              int32                    // Validity flag for GPS data
+	LastLockTimeMs     uint64                   // Last GPS lock time in milliseconds
+	Svnum              uint8                    // Number of GPS satellites in view (NMEA GSV), not all of which are used in the solution
+	BeidouSvnum        uint8                    // Number of Beidou satellites in view
+	GlonassSvnum       uint8                    // Number of GLONASS satellites in view
+	GalileoSvnum       uint8                    // Number of Galileo satellites in view
+	NSHemi             string                   // North/South hemisphere indicator
+	EWHemi             string                   // East/West hemisphere indicator
+	Altitude           float64                  // Altitude above sea level
+	Gpssta             uint8                    // GPS status
+	Posslnum           uint8                    // Number of satellites used in the position solution (NMEA GSA), see SatellitesUsed
+	Fixmode            uint8                    // GPS fix mode
+	FixModeText        string                   `json:"fix_mode_text"`   // Fixmode as text, see GnssFullData.FixModeString
+	GpsStatusText      string                   `json:"gps_status_text"` // Gpssta as text, see GnssFullData.GpsStatusString
+	Pdop               float64                  // Position dilution of precision
+	Hdop               float64                  // Horizontal dilution of precision
+	Vdop               float64                  // Vertical dilution of precision
+	Utc                NmeaUtcTime              // UTC time information
+	Timestamp          string                   `json:",omitempty"` // Utc as RFC3339, omitted until the modem reports a valid time
+	Slmsg              []NmeaSatelliteMsg       // Satellite message data, see ToGnssData for padding
+	BeidouSlmsg        []BeidouNmeaSatelliteMsg // Beidou satellite message data, see ToGnssData for padding
+	GlonassSlmsg       []NmeaSatelliteMsg       `json:",omitempty"` // GLONASS satellite message data, omitted if not reported
+	GalileoSlmsg       []NmeaSatelliteMsg       `json:",omitempty"` // Galileo satellite message data, omitted if not reported
+	Possl              []uint8                  // PRNs of the satellites used in the position solution (NMEA GSA), zero padded
+	Zones              []string                 `json:",omitempty"`                     // Names of the configured zones containing the fix
+	Address            string                   `json:",omitempty"`                     // Reverse-geocoded address of the position
+	FixType            string                   `json:",omitempty"`                     // Fix type from the GPS status, e.g. rtk_fixed, when NTRIP corrections are enabled
+	Geohash            string                   `json:",omitempty"`                     // Geohash of the position at the configured precision
+	LatDMS             string                   `json:"lat_dms,omitempty"`              // Latitude in degrees, minutes and seconds, e.g. 51°30'26.46"N
+	LonDMS             string                   `json:"lon_dms,omitempty"`              // Longitude in degrees, minutes and seconds, e.g. 0°7'39.94"W
+	UTMZone            *int                     `json:"utm_zone,omitempty"`             // UTM zone number, 1-60
+	UTMHemisphere      string                   `json:"utm_hemisphere,omitempty"`       // UTM hemisphere, N or S
+	UTMEasting         *float64                 `json:"utm_easting,omitempty"`          // UTM easting in meters
+	UTMNorthing        *float64                 `json:"utm_northing,omitempty"`         // UTM northing in meters, from the equator or 10,000 km south of it
+	RawLatitude        *float64                 `json:",omitempty"`                     // Unsmoothed latitude when SMOOTH_WINDOW is set
+	RawLongitude       *float64                 `json:",omitempty"`                     // Unsmoothed longitude when SMOOTH_WINDOW is set
+	RawAltitude        *float64                 `json:",omitempty"`                     // Unsmoothed altitude when SMOOTH_WINDOW is set
+	Stale              bool                     `json:"stale,omitempty"`                // Republished last known good fix, see REPUBLISH_STALE
+	AgeSeconds         float64                  `json:"age_seconds,omitempty"`          // Age of a stale fix
+	Confidence         *int                     `json:",omitempty"`                     // 0-100 fix confidence score, see ConfidenceScore
+	ReadStreak         *int                     `json:",omitempty"`                     // Consecutive successful D-Bus reads
+	PublishStreak      *int                     `json:",omitempty"`                     // Consecutive successful publishes before this one
+	ClockOffsetMs      *int64                   `json:",omitempty"`                     // Host clock minus GNSS UTC time in milliseconds
+	NetworkType        string                   `json:",omitempty"`                     // Cellular radio access technology, e.g. LTE
+	OffsetNorthM       *float64                 `json:",omitempty"`                     // Meters north of the surveyed reference point
+	OffsetEastM        *float64                 `json:",omitempty"`                     // Meters east of the surveyed reference point
+	OffsetDistanceM    *float64                 `json:",omitempty"`                     // Horizontal distance from the surveyed reference point
+	VerticalSpeedMs    *float64                 `json:",omitempty"`                     // Smoothed climb rate in m/s, negative when descending
+	AccuracyM          *float64                 `json:"accuracy_m,omitempty"`           // Estimated horizontal accuracy in meters, see EstimateAccuracyMeters
+	HeadingDeg         *float64                 `json:"heading_deg,omitempty"`          // Course over ground from the previous position, omitted unless the device moved
+	AvgSNRGPS          *float64                 `json:"avg_snr_gps,omitempty"`          // Mean SNR in dB-Hz of the GPS satellites reporting one
+	AvgSNRBeidou       *float64                 `json:"avg_snr_beidou,omitempty"`       // Mean SNR in dB-Hz of the BeiDou satellites reporting one
+	SatellitesAboveSNR *int                     `json:"satellites_above_snr,omitempty"` // Satellites in any constellation at or above USABLE_SNR_THRESHOLD
+	SatellitesUsed     int                      `json:"satellites_used"`                // Satellites used in the position solution, see GnssFullData.SatellitesUsed
+	SatellitesVisible  int                      `json:"satellites_visible"`             // Satellites in view across all constellations, tracked or not
+	SatellitesListed   int                      `json:"satellites_listed"`              // Real satellites in the satellite arrays, excluding padding
+	UsedPRNs           []int                    `json:"used_prns,omitempty"`            // PRNs of the satellites used in the position solution, decoded from Possl
+}
+
+// HasValidFix reports whether the data holds a usable position: the modem flags it valid, the
+// fix mode isn't "no fix", and the coordinates are in range and not the (0,0) "null island"
+// the modem reports before its first lock
+func (d *GnssFullData) HasValidFix() bool {
+	if d.Valid == 0 || d.Fixmode == 1 {
+		return false
+	}
+	if math.IsNaN(d.Latitude) || math.IsNaN(d.Longitude) ||
+		math.Abs(d.Latitude) > 90 || math.Abs(d.Longitude) > 180 {
+		return false
+	}
+	return d.Latitude != 0 || d.Longitude != 0
+}
+
+// FixModeString describes Fixmode, the NMEA GSA navigation mode:
+//
+//	1 "no fix"
+//	2 "2D"
+//	3 "3D"
+//
+// Any other value, including the 0 reported before the modem has decided, is "unknown".
+func (d *GnssFullData) FixModeString() string {
+	switch d.Fixmode {
+	case 1:
+		return "no fix"
+	case 2:
+		return "2D"
+	case 3:
+		return "3D"
+	default:
+		return "unknown"
+	}
+}
+
+// GpsStatusString describes Gpssta, the NMEA GGA fix quality indicator:
+//
+//	0 "invalid"
+//	1 "GPS"
+//	2 "DGPS"
+//	3 "PPS"
+//	4 "RTK fixed"
+//	5 "RTK float"
+//	6 "estimated"
+//	7 "manual"
+//	8 "simulation"
+//
+// Any other value is "unknown".
+func (d *GnssFullData) GpsStatusString() string {
+	switch d.Gpssta {
+	case 0:
+		return "invalid"
+	case 1:
+		return "GPS"
+	case 2:
+		return "DGPS"
+	case 3:
+		return "PPS"
+	case 4:
+		return "RTK fixed"
+	case 5:
+		return "RTK float"
+	case 6:
+		return "estimated"
+	case 7:
+		return "manual"
+	case 8:
+		return "simulation"
+	default:
+		return "unknown"
+	}
+}
+
+// ToGnssData copies the decoded D-Bus fields into a GnssData ready for publishing. The
+// all-zero entries padding the satellite arrays are dropped unless keepPadding is set, in
+// which case the arrays are published as the modem reports them.
+func (d *GnssFullData) ToGnssData(keepPadding bool) GnssData {
+	var timestamp string
+	if t, err := d.Utc.Time(); err == nil {
+		timestamp = t.Format(time.RFC3339)
+	}
+	data := GnssData{
+		Latitude:       d.Latitude,
+		Longitude:      d.Longitude,
+		Speed:          d.Speed,
+		Valid:          d.Valid,
+		LastLockTimeMs: d.LastLockTimeMs,
+		Svnum:          d.Svnum,
+		BeidouSvnum:    d.BeidouSvnum,
+		GlonassSvnum:   d.GlonassSvnum,
+		GalileoSvnum:   d.GalileoSvnum,
+		NSHemi:         d.NSHemi,
+		EWHemi:         d.EWHemi,
+		Altitude:       d.Altitude,
+		Gpssta:         d.Gpssta,
+		Posslnum:       d.Posslnum,
+		Fixmode:        d.Fixmode,
+		FixModeText:    d.FixModeString(),
+		GpsStatusText:  d.GpsStatusString(),
+		Pdop:           d.Pdop,
+		Hdop:           d.Hdop,
+		Vdop:           d.Vdop,
+		Utc:            d.Utc,
+		Timestamp:      timestamp,
+		Slmsg:          d.Slmsg,
+		BeidouSlmsg:    d.BeidouSlmsg,
+		GlonassSlmsg:   d.GlonassSlmsg,
+		GalileoSlmsg:   d.GalileoSlmsg,
+		Possl:          d.Possl,
+		SatellitesUsed: d.SatellitesUsed(),
+		UsedPRNs:       UsedSatellitePRNs(d.Possl),
+	}
+	data.SatellitesVisible = data.SatellitesInView()
+	slmsg, beidou := TrimSatellitePadding(d.Slmsg), TrimBeidouSatellitePadding(d.BeidouSlmsg)
+	glonass, galileo := TrimSatellitePadding(d.GlonassSlmsg), TrimSatellitePadding(d.GalileoSlmsg)
+	data.SatellitesListed = len(slmsg) + len(beidou) + len(glonass) + len(galileo)
+	if !keepPadding {
+		data.Slmsg, data.BeidouSlmsg, data.GlonassSlmsg, data.GalileoSlmsg = slmsg, beidou, glonass, galileo
+	}
+	return data
 }
 
+const (
+	// GnssDbusDest is the well-known bus name of the Tachyon GNSS service
+	GnssDbusDest = "io.particle.tachyon.GNSS"
+	// GnssDbusPath is the object path of the GNSS modem
+	GnssDbusPath = "/io/particle/tachyon/GNSS/Modem"
+	// GnssDbusInterface is the D-Bus interface implemented by the GNSS modem
+	GnssDbusInterface = "io.particle.tachyon.GNSS.Modem"
+	// GnssDbusMethod is the fully qualified method returning the GNSS property map
+	GnssDbusMethod = GnssDbusInterface + ".GetGnss"
+)
+
+// Supported values for DBUS_BUS
+const (
+	DbusBusSystem  = "system"
+	DbusBusSession = "session"
+)
+
+// GNSSDbus talks to the GNSS modem service. Dest, Path and Method default to GnssDbusDest,
+// GnssDbusPath and GnssDbusMethod, and can be overridden for other firmware revisions.
 type GNSSDbus struct {
-	conn *dbus.Conn
+	Bus     string // DbusBusSystem or DbusBusSession; empty means the system bus
+	Address string // When set, connect to this D-Bus address instead of Bus
+	Dest    string // Bus name of the GNSS service
+	Path    string // Object path of the GNSS modem
+	Method  string // Fully qualified method returning the GNSS property map
+	// RangeCheck is how decoded fixes with out-of-range values are treated, one of the
+	// RangeCheck constants
+	RangeCheck string
+	conn       *dbus.Conn
+}
+
+// NewGNSSDbus returns a GNSSDbus for the default service location on the system bus, with
+// strict range checking
+func NewGNSSDbus() *GNSSDbus {
+	return &GNSSDbus{Dest: GnssDbusDest, Path: GnssDbusPath, Method: GnssDbusMethod, RangeCheck: RangeCheckStrict}
+}
+
+// Interface returns the D-Bus interface of the GetGnss method, which is also the interface
+// whose property changes carry fixes
+func (g *GNSSDbus) Interface() string {
+	if i := strings.LastIndex(g.Method, "."); i > 0 {
+		return g.Method[:i]
+	}
+	return g.Method
+}
+
+// Validate checks the bus and service location settings
+func (g *GNSSDbus) Validate() error {
+	if g.Bus != "" && g.Bus != DbusBusSystem && g.Bus != DbusBusSession {
+		return fmt.Errorf("DBUS_BUS must be %q or %q, got %q", DbusBusSystem, DbusBusSession, g.Bus)
+	}
+	if g.Dest == "" {
+		return fmt.Errorf("GNSS_DBUS_DEST must not be empty")
+	}
+	if !dbus.ObjectPath(g.Path).IsValid() {
+		return fmt.Errorf("GNSS_DBUS_PATH %q is not a valid object path", g.Path)
+	}
+	if !strings.Contains(g.Method, ".") {
+		return fmt.Errorf("GNSS_DBUS_METHOD must be fully qualified, e.g. %s", GnssDbusMethod)
+	}
+	return nil
 }
 
-// Connect establishes a connection to the system D-Bus and stores it in GNSSDbus
+// Connect establishes a connection to the configured D-Bus and stores it in GNSSDbus
 func (g *GNSSDbus) Connect() error {
-	c, err := dbus.SystemBus()
+	var c *dbus.Conn
+	var err error
+	switch {
+	case g.Address != "":
+		c, err = dbus.Connect(g.Address)
+	case g.Bus == DbusBusSession:
+		c, err = dbus.SessionBus()
+	case g.Bus == "" || g.Bus == DbusBusSystem:
+		c, err = dbus.SystemBus()
+	default:
+		return fmt.Errorf("unsupported bus %q", g.Bus)
+	}
 	if err != nil {
 		return err
 	}
@@ -88,17 +369,58 @@ func (g *GNSSDbus) Connect() error {
 	return nil
 }
 
-// GetData retrieves GNSS data from the D-Bus interface and returns it as GnssFullData.
-func (g *GNSSDbus) GetData() (*GnssFullData, error) {
-	if g.conn == nil {
+// DefaultDbusCallTimeout bounds a single GetGnss call unless DBUS_CALL_TIMEOUT overrides it
+const DefaultDbusCallTimeout = 5 * time.Second
+
+// GnssReader reads the current GNSS state, giving up when ctx is done
+type GnssReader interface {
+	ReadGnss(ctx context.Context) (*GnssFullData, error)
+}
+
+// dbusGnssReader reads GNSS data by calling GetGnss on the modem over D-Bus
+type dbusGnssReader struct {
+	gnss    *GNSSDbus
+	timeout time.Duration // Per-call timeout, so a hung modem service can't stall the poll loop
+}
+
+// ReadGnss retrieves GNSS data from the D-Bus interface and returns it as GnssFullData
+func (r dbusGnssReader) ReadGnss(ctx context.Context) (*GnssFullData, error) {
+	if r.gnss.conn == nil {
 		return nil, fmt.Errorf("not connected to D-Bus: call Connect() first")
 	}
-	obj := g.conn.Object("io.particle.tachyon.GNSS", "/io/particle/tachyon/GNSS/Modem")
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	// The object is resolved by bus name on every call, so a service that starts, or restarts
+	// under a new unique name, is picked up by the next read
+	obj := r.gnss.conn.Object(r.gnss.Dest, dbus.ObjectPath(r.gnss.Path))
 	var result map[string]dbus.Variant
-	if err := obj.Call("io.particle.tachyon.GNSS.Modem.GetGnss", 0).Store(&result); err != nil {
+	if err := obj.CallWithContext(ctx, r.gnss.Method, 0).Store(&result); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%s timed out after %s", r.gnss.Method, r.timeout)
+		}
+		if isServiceUnknown(err) {
+			return nil, fmt.Errorf("%w: %s: %v", ErrGnssServiceUnavailable, r.gnss.Dest, err)
+		}
 		return nil, err
 	}
-	data := GnssFullData{}
+	data, err := decodeGnss(result, r.gnss.RangeCheck)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGnssData, err)
+	}
+	return data, nil
+}
+
+// decodeGnss converts the GNSS property map returned by GetGnss, or carried by a signal,
+// into GnssFullData. Missing or mistyped properties are left zero, or empty for the GPS and
+// Beidou satellite lists; a UTC array without exactly six fields is an error. Out-of-range
+// values are handled according to rangeCheck, one of the RangeCheck constants.
+func decodeGnss(result map[string]dbus.Variant, rangeCheck string) (*GnssFullData, error) {
+	data := GnssFullData{
+		Slmsg:       []NmeaSatelliteMsg{},
+		BeidouSlmsg: []BeidouNmeaSatelliteMsg{},
+		Possl:       []uint8{},
+	}
+	var problems decodeProblems
 	// Scalar fields
 	if v, ok := result["valid"]; ok {
 		data.Valid, _ = v.Value().(int32)
@@ -112,6 +434,12 @@ func (g *GNSSDbus) GetData() (*GnssFullData, error) {
 	if v, ok := result["beidou_svnum"]; ok {
 		data.BeidouSvnum, _ = v.Value().(uint8)
 	}
+	if v, ok := result["glonass_svnum"]; ok {
+		data.GlonassSvnum, _ = v.Value().(uint8)
+	}
+	if v, ok := result["galileo_svnum"]; ok {
+		data.GalileoSvnum, _ = v.Value().(uint8)
+	}
 	if v, ok := result["nshemi"]; ok {
 		data.NSHemi, _ = v.Value().(string)
 	}
@@ -150,46 +478,107 @@ func (g *GNSSDbus) GetData() (*GnssFullData, error) {
 	}
 	// UTC time
 	if v, ok := result["utc"]; ok {
-		if utcArr, ok := v.Value().([]any); ok && len(utcArr) == 6 {
-			data.Utc.Year = ToInt32(utcArr[0])
-			data.Utc.Month = ToInt8(utcArr[1])
-			data.Utc.Date = ToInt8(utcArr[2])
-			data.Utc.Hour = ToInt8(utcArr[3])
-			data.Utc.Min = ToInt8(utcArr[4])
-			data.Utc.Sec = ToInt8(utcArr[5])
+		if utcArr, ok := v.Value().([]any); ok {
+			if len(utcArr) != 6 {
+				return nil, fmt.Errorf("utc has %d fields, want 6", len(utcArr))
+			}
+			data.Utc.Year = problems.int32(utcArr[0], "utc year")
+			data.Utc.Month = problems.int8(utcArr[1], "utc month")
+			data.Utc.Date = problems.int8(utcArr[2], "utc date")
+			data.Utc.Hour = problems.int8(utcArr[3], "utc hour")
+			data.Utc.Min = problems.int8(utcArr[4], "utc minute")
+			data.Utc.Sec = problems.int8(utcArr[5], "utc second")
 		}
 	}
-	// Satellite arrays
+	// Satellite arrays; GLONASS and Galileo are only reported by some modems
 	if v, ok := result["slmsg"]; ok {
-		if arr, ok := v.Value().([][]any); ok {
-			for i := 0; i < len(arr) && i < MaxSatelliteCount; i++ {
-				if len(arr[i]) == 4 {
-					data.Slmsg[i].Num = ToInt8(arr[i][0])
-					data.Slmsg[i].Eledeg = ToInt8(arr[i][1])
-					data.Slmsg[i].Azideg = ToInt32(arr[i][2])
-					data.Slmsg[i].SN = ToInt8(arr[i][3])
-				}
-			}
-		}
+		data.Slmsg = decodeSatellites("slmsg", v, &problems)
 	}
 	if v, ok := result["beidou_slmsg"]; ok {
-		if arr, ok := v.Value().([][]any); ok {
-			for i := 0; i < len(arr) && i < MaxSatelliteCount; i++ {
-				if len(arr[i]) == 4 {
-					data.BeidouSlmsg[i].BeidouNum = ToInt8(arr[i][0])
-					data.BeidouSlmsg[i].BeidouEledeg = ToInt8(arr[i][1])
-					data.BeidouSlmsg[i].BeidouAzideg = ToInt32(arr[i][2])
-					data.BeidouSlmsg[i].BeidouSN = ToInt8(arr[i][3])
-				}
-			}
+		for _, s := range decodeSatellites("beidou_slmsg", v, &problems) {
+			data.BeidouSlmsg = append(data.BeidouSlmsg, BeidouNmeaSatelliteMsg{
+				BeidouNum:    s.Num,
+				BeidouEledeg: s.Eledeg,
+				BeidouAzideg: s.Azideg,
+				BeidouSN:     s.SN,
+			})
 		}
 	}
+	if v, ok := result["glonass_slmsg"]; ok {
+		data.GlonassSlmsg = decodeSatellites("glonass_slmsg", v, &problems)
+	}
+	if v, ok := result["galileo_slmsg"]; ok {
+		data.GalileoSlmsg = decodeSatellites("galileo_slmsg", v, &problems)
+	}
 	if v, ok := result["possl"]; ok {
-		if arr, ok := v.Value().([]any); ok {
-			for i := 0; i < len(arr) && i < MaxSatelliteCount; i++ {
-				data.Possl[i] = ToUint8(arr[i])
-			}
+		for i, prn := range possLevels(v) {
+			data.Possl = append(data.Possl, problems.uint8(prn, "possl[%d]", i))
 		}
 	}
+	if len(problems) > 0 {
+		log.Printf("GNSS values not decoded exactly: %s", strings.Join(problems, ", "))
+	}
+	data.applyRangeCheck(rangeCheck)
 	return &data, nil
 }
+
+// possLevels returns the elements of the possl array. Most firmware sends a flat array, but
+// some nest it like the satellite arrays: either the whole list wrapped in a single inner array,
+// or one inner array per entry whose first element is the PRN. A byte array is accepted too.
+func possLevels(v dbus.Variant) []any {
+	switch arr := v.Value().(type) {
+	case []any:
+		return arr
+	case [][]any:
+		if len(arr) == 1 {
+			return arr[0]
+		}
+		prns := make([]any, len(arr))
+		for i, entry := range arr {
+			if len(entry) > 0 {
+				prns[i] = entry[0]
+			} else {
+				prns[i] = uint8(0) // Padding, like a zero in the flat array
+			}
+		}
+		return prns
+	case []byte: // An ay signature
+		prns := make([]any, len(arr))
+		for i, prn := range arr {
+			prns[i] = prn
+		}
+		return prns
+	default:
+		return nil
+	}
+}
+
+// decodeSatellites decodes the satellite array named field, of [num, elevation, azimuth, snr]
+// entries, one per reported entry with malformed entries left zero. Values that don't fit are
+// clamped and recorded in problems.
+func decodeSatellites(field string, v dbus.Variant, problems *decodeProblems) []NmeaSatelliteMsg {
+	arr, ok := v.Value().([][]any)
+	if !ok {
+		return []NmeaSatelliteMsg{}
+	}
+	sats := make([]NmeaSatelliteMsg, len(arr))
+	for i := range arr {
+		if len(arr[i]) == 4 {
+			sats[i].Num = problems.int8(arr[i][0], "%s[%d] num", field, i)
+			sats[i].Eledeg = problems.int8(arr[i][1], "%s[%d] elevation", field, i)
+			sats[i].Azideg = problems.int32(arr[i][2], "%s[%d] azimuth", field, i)
+			sats[i].SN = problems.int8(arr[i][3], "%s[%d] snr", field, i)
+		}
+	}
+	return sats
+}
+
+// InjectRTCM passes an RTCM 3 correction frame to the GNSS modem via the given D-Bus method,
+// giving up when ctx is done
+func (g *GNSSDbus) InjectRTCM(ctx context.Context, method string, frame []byte) error {
+	if g.conn == nil {
+		return fmt.Errorf("not connected to D-Bus: call Connect() first")
+	}
+	obj := g.conn.Object(g.Dest, dbus.ObjectPath(g.Path))
+	return obj.CallWithContext(ctx, method, 0, frame).Err
+}
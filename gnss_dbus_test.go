@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestHasValidFix(t *testing.T) {
+	valid := GnssFullData{Valid: 1, Fixmode: 3, Latitude: 51.5, Longitude: -0.12}
+	with := func(f func(d *GnssFullData)) GnssFullData {
+		d := valid
+		f(&d)
+		return d
+	}
+	tests := []struct {
+		name string
+		data GnssFullData
+		want bool
+	}{
+		{"3D fix", valid, true},
+		{"2D fix", with(func(d *GnssFullData) { d.Fixmode = 2 }), true},
+		{"fix mode not reported", with(func(d *GnssFullData) { d.Fixmode = 0 }), true},
+		{"flagged invalid", with(func(d *GnssFullData) { d.Valid = 0 }), false},
+		{"no fix mode", with(func(d *GnssFullData) { d.Fixmode = 1 }), false},
+		{"null island", with(func(d *GnssFullData) { d.Latitude, d.Longitude = 0, 0 }), false},
+		{"on the equator", with(func(d *GnssFullData) { d.Latitude = 0 }), true},
+		{"on the prime meridian", with(func(d *GnssFullData) { d.Longitude = 0 }), true},
+		{"north pole", with(func(d *GnssFullData) { d.Latitude = 90 }), true},
+		{"beyond north pole", with(func(d *GnssFullData) { d.Latitude = 90.000001 }), false},
+		{"beyond south pole", with(func(d *GnssFullData) { d.Latitude = -90.5 }), false},
+		{"antimeridian", with(func(d *GnssFullData) { d.Longitude = -180 }), true},
+		{"beyond antimeridian", with(func(d *GnssFullData) { d.Longitude = 180.1 }), false},
+		{"NaN latitude", with(func(d *GnssFullData) { d.Latitude = math.NaN() }), false},
+		{"NaN longitude", with(func(d *GnssFullData) { d.Longitude = math.NaN() }), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.data.HasValidFix(); got != tt.want {
+				t.Errorf("HasValidFix() = %t, want %t for %+v", got, tt.want, tt.data)
+			}
+		})
+	}
+}
+
+// satelliteArray builds an slmsg style array of n satellites numbered from 1
+func satelliteArray(n int) [][]any {
+	arr := make([][]any, n)
+	for i := range arr {
+		arr[i] = []any{int32(i + 1), int32(10 + i), int32(20 * i), int32(30 + i)}
+	}
+	return arr
+}
+
+func TestDecodeGnssPartialMaps(t *testing.T) {
+	tests := []struct {
+		name   string
+		result map[string]dbus.Variant
+		want   GnssFullData
+	}{
+		{
+			name:   "empty",
+			result: map[string]dbus.Variant{},
+			want:   GnssFullData{},
+		},
+		{
+			name: "position only",
+			result: map[string]dbus.Variant{
+				"latitude":  dbus.MakeVariant(51.5007),
+				"longitude": dbus.MakeVariant("-0.1246"),
+			},
+			want: GnssFullData{Latitude: 51.5007, Longitude: -0.1246},
+		},
+		{
+			name: "fix without satellites",
+			result: map[string]dbus.Variant{
+				"valid":    dbus.MakeVariant(int32(1)),
+				"fixmode":  dbus.MakeVariant(uint8(3)),
+				"svnum":    dbus.MakeVariant(uint8(9)),
+				"altitude": dbus.MakeVariant(uint32(35)),
+				"utc":      dbus.MakeVariant([]any{int32(2024), int32(6), int32(1), int32(12), int32(30), int32(15)}),
+			},
+			want: GnssFullData{
+				Valid: 1, Fixmode: 3, Svnum: 9, Altitude: 35,
+				Utc: NmeaUtcTime{Year: 2024, Month: 6, Date: 1, Hour: 12, Min: 30, Sec: 15},
+			},
+		},
+		{
+			name: "mistyped values left zero",
+			result: map[string]dbus.Variant{
+				"valid":    dbus.MakeVariant("1"),
+				"svnum":    dbus.MakeVariant(int32(9)),
+				"latitude": dbus.MakeVariant(true),
+				"slmsg":    dbus.MakeVariant("none"),
+			},
+			want: GnssFullData{},
+		},
+		{
+			name: "malformed satellite entry",
+			result: map[string]dbus.Variant{
+				"slmsg": dbus.MakeVariant([][]any{{int32(5), int32(40), int32(120), int32(38)}, {int32(7), int32(12)}}),
+			},
+			want: GnssFullData{Slmsg: []NmeaSatelliteMsg{{Num: 5, Eledeg: 40, Azideg: 120, SN: 38}, {}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeGnss(tt.result, RangeCheckOff)
+			if err != nil {
+				t.Fatalf("decodeGnss() = %v", err)
+			}
+			want := tt.want
+			if want.Slmsg == nil {
+				want.Slmsg = []NmeaSatelliteMsg{}
+			}
+			want.BeidouSlmsg, want.Possl = []BeidouNmeaSatelliteMsg{}, []uint8{}
+			if !reflect.DeepEqual(*got, want) {
+				t.Errorf("decodeGnss() = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeGnssUtcLength(t *testing.T) {
+	for _, n := range []int{0, 3, 5, 7} {
+		utc := make([]any, n)
+		for i := range utc {
+			utc[i] = int32(1)
+		}
+		result := map[string]dbus.Variant{"latitude": dbus.MakeVariant(51.5), "utc": dbus.MakeVariant(utc)}
+		if _, err := decodeGnss(result, RangeCheckOff); err == nil {
+			t.Errorf("decodeGnss() with %d UTC fields succeeded, want an error", n)
+		}
+	}
+}
+
+func TestDecodeGnssOversizedSatelliteArrays(t *testing.T) {
+	for _, n := range []int{12, 13, 20, 32} {
+		result := map[string]dbus.Variant{
+			"slmsg":         dbus.MakeVariant(satelliteArray(n)),
+			"beidou_slmsg":  dbus.MakeVariant(satelliteArray(n)),
+			"glonass_slmsg": dbus.MakeVariant(satelliteArray(n)),
+		}
+		got, err := decodeGnss(result, RangeCheckOff)
+		if err != nil {
+			t.Fatalf("decodeGnss() with %d satellites = %v", n, err)
+		}
+		if len(got.Slmsg) != n || len(got.BeidouSlmsg) != n || len(got.GlonassSlmsg) != n {
+			t.Errorf("decoded %d GPS, %d BeiDou and %d GLONASS satellites, want all %d",
+				len(got.Slmsg), len(got.BeidouSlmsg), len(got.GlonassSlmsg), n)
+			continue
+		}
+		last := got.Slmsg[n-1]
+		if want := (NmeaSatelliteMsg{Num: int8(n), Eledeg: int8(10 + n - 1), Azideg: int32(20 * (n - 1)), SN: int8(30 + n - 1)}); last != want {
+			t.Errorf("satellite %d = %+v, want %+v", n, last, want)
+		}
+		if b := got.BeidouSlmsg[n-1]; b.BeidouNum != int8(n) || b.BeidouAzideg != int32(20*(n-1)) {
+			t.Errorf("BeiDou satellite %d = %+v, want it decoded like GPS", n, b)
+		}
+	}
+}
+
+func TestDecodeGnssConstellations(t *testing.T) {
+	sats := [][]any{{int32(65), int32(40), int32(120), int32(38)}, {int32(72), int32(15), int32(300), int32(0)}}
+	decoded := []NmeaSatelliteMsg{{Num: 65, Eledeg: 40, Azideg: 120, SN: 38}, {Num: 72, Eledeg: 15, Azideg: 300}}
+	tests := []struct {
+		name               string
+		svnumKey, slmsgKey string
+		get                func(d *GnssFullData) (uint8, []NmeaSatelliteMsg)
+	}{
+		{"GPS", "svnum", "slmsg", func(d *GnssFullData) (uint8, []NmeaSatelliteMsg) { return d.Svnum, d.Slmsg }},
+		{"GLONASS", "glonass_svnum", "glonass_slmsg", func(d *GnssFullData) (uint8, []NmeaSatelliteMsg) { return d.GlonassSvnum, d.GlonassSlmsg }},
+		{"Galileo", "galileo_svnum", "galileo_slmsg", func(d *GnssFullData) (uint8, []NmeaSatelliteMsg) { return d.GalileoSvnum, d.GalileoSlmsg }},
+		{"BeiDou", "beidou_svnum", "beidou_slmsg", func(d *GnssFullData) (uint8, []NmeaSatelliteMsg) {
+			var sats []NmeaSatelliteMsg
+			for _, s := range d.BeidouSlmsg {
+				sats = append(sats, NmeaSatelliteMsg{Num: s.BeidouNum, Eledeg: s.BeidouEledeg, Azideg: s.BeidouAzideg, SN: s.BeidouSN})
+			}
+			return d.BeidouSvnum, sats
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := decodeGnss(map[string]dbus.Variant{
+				tt.svnumKey: dbus.MakeVariant(uint8(7)),
+				tt.slmsgKey: dbus.MakeVariant(sats),
+			}, RangeCheckOff)
+			if err != nil {
+				t.Fatal(err)
+			}
+			svnum, got := tt.get(data)
+			if svnum != 7 || !reflect.DeepEqual(got, decoded) {
+				t.Errorf("decoded %d in view with %+v, want 7 with %+v", svnum, got, decoded)
+			}
+			if n := data.ToGnssData(false).SatellitesVisible; n != 7 {
+				t.Errorf("SatellitesVisible = %d, want the 7 %s satellites", n, tt.name)
+			}
+		})
+	}
+}
+
+func TestDecodeGnssWithoutGlonassOrGalileo(t *testing.T) {
+	data, err := decodeGnss(map[string]dbus.Variant{
+		"svnum": dbus.MakeVariant(uint8(9)),
+		"slmsg": dbus.MakeVariant([][]any{{int32(5), int32(40), int32(120), int32(38)}}),
+	}, RangeCheckOff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.GlonassSlmsg != nil || data.GalileoSlmsg != nil || data.GlonassSvnum != 0 || data.GalileoSvnum != 0 {
+		t.Errorf("decoded GLONASS %d %v and Galileo %d %v from a modem not reporting them",
+			data.GlonassSvnum, data.GlonassSlmsg, data.GalileoSvnum, data.GalileoSlmsg)
+	}
+	raw, err := json.Marshal(data.ToGnssData(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{`"GlonassSlmsg"`, `"GalileoSlmsg"`} {
+		if strings.Contains(string(raw), key) {
+			t.Errorf("payload %s includes %s, want it omitted when not reported", raw, key)
+		}
+	}
+}
+
+func TestFixModeString(t *testing.T) {
+	tests := []struct {
+		fixmode uint8
+		want    string
+	}{
+		{0, "unknown"}, {1, "no fix"}, {2, "2D"}, {3, "3D"}, {4, "unknown"}, {255, "unknown"},
+	}
+	for _, tt := range tests {
+		d := GnssFullData{Fixmode: tt.fixmode}
+		if got := d.FixModeString(); got != tt.want {
+			t.Errorf("FixModeString() for %d = %q, want %q", tt.fixmode, got, tt.want)
+		}
+	}
+}
+
+func TestGpsStatusString(t *testing.T) {
+	tests := []struct {
+		gpssta uint8
+		want   string
+	}{
+		{0, "invalid"}, {1, "GPS"}, {2, "DGPS"}, {3, "PPS"}, {4, "RTK fixed"}, {5, "RTK float"},
+		{6, "estimated"}, {7, "manual"}, {8, "simulation"}, {9, "unknown"}, {255, "unknown"},
+	}
+	for _, tt := range tests {
+		d := GnssFullData{Gpssta: tt.gpssta}
+		if got := d.GpsStatusString(); got != tt.want {
+			t.Errorf("GpsStatusString() for %d = %q, want %q", tt.gpssta, got, tt.want)
+		}
+	}
+}
+
+func TestToGnssDataStatusText(t *testing.T) {
+	d := GnssFullData{Fixmode: 3, Gpssta: 4}
+	data := d.ToGnssData(false)
+	if data.Fixmode != 3 || data.Gpssta != 4 || data.FixModeText != "3D" || data.GpsStatusText != "RTK fixed" {
+		t.Errorf("ToGnssData() = Fixmode %d %q, Gpssta %d %q, want 3 \"3D\" and 4 \"RTK fixed\"",
+			data.Fixmode, data.FixModeText, data.Gpssta, data.GpsStatusText)
+	}
+}
+
+func TestPossLevels(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  []any
+	}{
+		{"flat", []any{uint8(4), uint8(9), uint8(0)}, []any{uint8(4), uint8(9), uint8(0)}},
+		{"single wrapper", [][]any{{int32(4), int32(9), int32(0)}}, []any{int32(4), int32(9), int32(0)}},
+		{"one entry per PRN", [][]any{{int32(4), int32(45)}, {int32(9)}, {}}, []any{int32(4), int32(9), uint8(0)}},
+		{"byte array", []byte{4, 9, 0}, []any{byte(4), byte(9), byte(0)}},
+		{"unsupported", "4,9", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := possLevels(dbus.MakeVariant(tt.value)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("possLevels(%v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeGnssPossl(t *testing.T) {
+	want := []uint8{4, 5, 9, 0}
+	tests := []struct {
+		name  string
+		value any
+		want  []uint8
+	}{
+		{"flat", []any{uint8(4), uint8(5), uint8(9), uint8(0)}, want},
+		{"flat int32", []any{int32(4), int32(5), int32(9), int32(0)}, want},
+		{"single wrapper", [][]any{{int32(4), int32(5), int32(9), int32(0)}}, want},
+		{"one entry per PRN", [][]any{{int32(4)}, {int32(5)}, {int32(9)}, {int32(0)}}, want},
+		{"empty entry as padding", [][]any{{int32(4)}, {int32(5)}, {int32(9)}, {}}, want},
+		{"byte array", []byte{4, 5, 9, 0}, want},
+		{"out of range PRN clamped", []any{int32(4), int32(5), int32(9), int32(-1)}, want},
+		{"unsupported", "4,5,9", []uint8{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validFixVariants()
+			result["possl"] = dbus.MakeVariant(tt.value)
+			data, err := decodeGnss(result, RangeCheckOff)
+			if err != nil {
+				t.Fatalf("decodeGnss() = %v", err)
+			}
+			if !reflect.DeepEqual(data.Possl, tt.want) {
+				t.Errorf("Possl = %v, want %v", data.Possl, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"maps"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// dbusPropertiesInterface is the standard interface whose PropertiesChanged signal carries property updates
+const dbusPropertiesInterface = "org.freedesktop.DBus.Properties"
+
+// subscribeGnssSignals registers a match rule for signals from the GNSS modem object and
+// returns a channel of fixes decoded from them by a gnssSignalDecoder. When the consumer falls
+// behind only the latest fix is kept. The channel is closed when the connection is closed.
+func subscribeGnssSignals(conn *dbus.Conn, path dbus.ObjectPath, iface, rangeCheck string) (<-chan *GnssFullData, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("not connected to D-Bus: call Connect() first")
+	}
+	if err := conn.AddMatchSignal(dbus.WithMatchObjectPath(path)); err != nil {
+		return nil, fmt.Errorf("failed to add D-Bus match rule: %w", err)
+	}
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+
+	out := make(chan *GnssFullData, 1)
+	go relayGnssSignals(signals, out, newGnssSignalDecoder(path, iface, rangeCheck))
+	return out, nil
+}
+
+// relayGnssSignals decodes each signal into a fix sent on out, until signals is closed, and
+// then closes out
+func relayGnssSignals(signals <-chan *dbus.Signal, out chan *GnssFullData, decoder *gnssSignalDecoder) {
+	defer close(out)
+	for sig := range signals {
+		data, err := decoder.Decode(sig)
+		if err != nil {
+			log.Printf("Ignoring GNSS signal: %v", err)
+			continue
+		}
+		if data != nil {
+			sendLatest(out, data)
+		}
+	}
+	log.Println("D-Bus signal channel closed")
+}
+
+// sendLatest sends data on out, which must have a buffer of one and no other sender, replacing
+// any fix the consumer hasn't picked up yet so a slow consumer only sees the newest
+func sendLatest(out chan *GnssFullData, data *GnssFullData) {
+	select {
+	case out <- data:
+	default:
+		select {
+		case <-out:
+		default:
+		}
+		out <- data
+	}
+}
+
+// gnssSignalDecoder decodes fixes from the signals of the GNSS modem object. It accepts
+// PropertiesChanged signals for the modem interface as well as any modem signal carrying a
+// GetGnss-style property map. Signals may only carry the properties that changed, so each
+// update is merged over the properties seen so far before decoding.
+type gnssSignalDecoder struct {
+	path       dbus.ObjectPath
+	iface      string
+	rangeCheck string
+	props      map[string]dbus.Variant // Latest value of every property seen so far
+}
+
+// newGnssSignalDecoder creates a decoder for the signals of the modem object at path
+func newGnssSignalDecoder(path dbus.ObjectPath, iface, rangeCheck string) *gnssSignalDecoder {
+	return &gnssSignalDecoder{path: path, iface: iface, rangeCheck: rangeCheck, props: make(map[string]dbus.Variant)}
+}
+
+// Decode merges the properties carried by sig and decodes the fix they add up to. It returns
+// nil without an error for signals that don't carry GNSS properties. A fix that fails to
+// decode still leaves its properties merged, for later updates to build on.
+func (d *gnssSignalDecoder) Decode(sig *dbus.Signal) (*GnssFullData, error) {
+	if sig.Path != d.path {
+		return nil, nil
+	}
+	changed, ok := gnssSignalProperties(sig, d.iface)
+	if !ok {
+		return nil, nil
+	}
+	maps.Copy(d.props, changed)
+	return decodeGnss(d.props, d.rangeCheck)
+}
+
+// gnssSignalProperties extracts the GNSS property map from a modem signal
+func gnssSignalProperties(sig *dbus.Signal, iface string) (map[string]dbus.Variant, bool) {
+	if sig.Name == dbusPropertiesInterface+".PropertiesChanged" {
+		if len(sig.Body) < 2 {
+			return nil, false
+		}
+		if changedIface, _ := sig.Body[0].(string); changedIface != iface {
+			return nil, false
+		}
+		changed, ok := sig.Body[1].(map[string]dbus.Variant)
+		return changed, ok
+	}
+	for _, arg := range sig.Body {
+		if props, ok := arg.(map[string]dbus.Variant); ok {
+			return props, true
+		}
+	}
+	return nil, false
+}
+
+// Subscribe returns a channel of fixes pushed by the modem over D-Bus signals
+func (g *GNSSDbus) Subscribe() (<-chan *GnssFullData, error) {
+	return subscribeGnssSignals(g.conn, dbus.ObjectPath(g.Path), g.Interface(), g.RangeCheck)
+}
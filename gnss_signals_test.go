@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	testModemPath  = dbus.ObjectPath(GnssDbusPath)
+	testModemIface = GnssDbusInterface
+)
+
+// propertiesChanged builds a PropertiesChanged signal from the test modem carrying props
+func propertiesChanged(props map[string]dbus.Variant) *dbus.Signal {
+	return &dbus.Signal{
+		Path: testModemPath,
+		Name: dbusPropertiesInterface + ".PropertiesChanged",
+		Body: []any{testModemIface, props, []string{}},
+	}
+}
+
+func TestGnssSignalDecoderMergesPartialUpdates(t *testing.T) {
+	d := newGnssSignalDecoder(testModemPath, testModemIface, RangeCheckOff)
+	data, err := d.Decode(propertiesChanged(map[string]dbus.Variant{
+		"valid":     dbus.MakeVariant(int32(1)),
+		"latitude":  dbus.MakeVariant(51.5),
+		"longitude": dbus.MakeVariant(-0.12),
+		"svnum":     dbus.MakeVariant(uint8(9)),
+	}))
+	if err != nil || data == nil {
+		t.Fatalf("Decode() = %+v, %v", data, err)
+	}
+
+	// Only the latitude changed, so the rest carries over from the first signal
+	data, err = d.Decode(propertiesChanged(map[string]dbus.Variant{"latitude": dbus.MakeVariant(51.6)}))
+	if err != nil || data == nil {
+		t.Fatalf("Decode() of a partial update = %+v, %v", data, err)
+	}
+	if data.Valid != 1 || data.Latitude != 51.6 || data.Longitude != -0.12 || data.Svnum != 9 {
+		t.Errorf("partial update decoded to %+v, want the new latitude over the earlier fields", data)
+	}
+}
+
+func TestGnssSignalDecoderFullUpdates(t *testing.T) {
+	d := newGnssSignalDecoder(testModemPath, testModemIface, RangeCheckOff)
+	full := func(lat float64, svnum uint8) map[string]dbus.Variant {
+		return map[string]dbus.Variant{
+			"valid":     dbus.MakeVariant(int32(1)),
+			"latitude":  dbus.MakeVariant(lat),
+			"longitude": dbus.MakeVariant(-0.12),
+			"svnum":     dbus.MakeVariant(uint8(svnum)),
+		}
+	}
+	if _, err := d.Decode(propertiesChanged(full(51.5, 9))); err != nil {
+		t.Fatal(err)
+	}
+	// A GetGnss-style signal with the whole map replaces every field
+	data, err := d.Decode(&dbus.Signal{Path: testModemPath, Name: testModemIface + ".GnssUpdated", Body: []any{full(52, 4)}})
+	if err != nil || data == nil {
+		t.Fatalf("Decode() of a full update = %+v, %v", data, err)
+	}
+	if data.Latitude != 52 || data.Svnum != 4 {
+		t.Errorf("full update decoded to %+v, want latitude 52 with 4 satellites", data)
+	}
+}
+
+func TestGnssSignalDecoderIgnoresOtherSignals(t *testing.T) {
+	d := newGnssSignalDecoder(testModemPath, testModemIface, RangeCheckOff)
+	props := map[string]dbus.Variant{"latitude": dbus.MakeVariant(51.5)}
+	signals := map[string]*dbus.Signal{
+		"another object": {Path: "/io/particle/tachyon/Other", Name: testModemIface + ".GnssUpdated", Body: []any{props}},
+		"another interface": {
+			Path: testModemPath, Name: dbusPropertiesInterface + ".PropertiesChanged",
+			Body: []any{"org.freedesktop.ModemManager1.Modem", props, []string{}},
+		},
+		"no property map": {Path: testModemPath, Name: testModemIface + ".Reset", Body: []any{"cold"}},
+	}
+	for name, sig := range signals {
+		if data, err := d.Decode(sig); data != nil || err != nil {
+			t.Errorf("Decode() of a signal from %s = %+v, %v, want it ignored", name, data, err)
+		}
+	}
+	if len(d.props) != 0 {
+		t.Errorf("ignored signals merged %v", d.props)
+	}
+}
+
+func TestRelayGnssSignalsKeepsLatestForSlowConsumer(t *testing.T) {
+	signals := make(chan *dbus.Signal, 3)
+	for _, lat := range []float64{51.1, 51.2, 51.3} {
+		signals <- propertiesChanged(map[string]dbus.Variant{"latitude": dbus.MakeVariant(lat)})
+	}
+	close(signals)
+	out := make(chan *GnssFullData, 1)
+
+	// Nothing is received until the relay has finished, so every fix but the last is replaced
+	relayGnssSignals(signals, out, newGnssSignalDecoder(testModemPath, testModemIface, RangeCheckOff))
+	data, ok := <-out
+	if !ok || data.Latitude != 51.3 {
+		t.Fatalf("first fix received = %+v, want the latest at 51.3", data)
+	}
+	if data, ok := <-out; ok {
+		t.Errorf("received %+v after the latest fix, want the channel closed", data)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// DefaultGPXMaxBytes is the size a GPX file may reach before a new one is started
+	DefaultGPXMaxBytes = 10 << 20
+	// MinGPXMaxBytes leaves room for the header, footer and a reasonable number of points
+	MinGPXMaxBytes = 4 << 10
+)
+
+// gpxFooter closes the track; it's kept at the end of the file after every point so the
+// file is well-formed even if the process dies between appends
+const gpxFooter = "  </trkseg>\n </trk>\n</gpx>\n"
+
+// GPXWriter appends valid fixes as track points to one GPX file per UTC day in a directory,
+// starting a numbered continuation file when the current one would exceed maxBytes
+type GPXWriter struct {
+	dir      string
+	maxBytes int64
+	f        *os.File
+	day      string // UTC date of the open file
+	seq      int    // Continuation number of the open file, 0 for the first file of the day
+	end      int64  // Offset of the footer in the open file
+}
+
+// NewGPXWriter creates a writer for dir, creating the directory if needed. Files are opened
+// lazily on the first point.
+func NewGPXWriter(dir string, maxBytes int64) (*GPXWriter, error) {
+	if maxBytes < MinGPXMaxBytes {
+		return nil, fmt.Errorf("max file size must be at least %d bytes", MinGPXMaxBytes)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create GPX directory: %w", err)
+	}
+	return &GPXWriter{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// gpxPath returns the file for a UTC date and continuation number, e.g. 2024-05-01.gpx
+// followed by 2024-05-01-1.gpx
+func (w *GPXWriter) gpxPath(day string, seq int) string {
+	if seq == 0 {
+		return filepath.Join(w.dir, day+".gpx")
+	}
+	return filepath.Join(w.dir, fmt.Sprintf("%s-%d.gpx", day, seq))
+}
+
+// gpxHeader opens the document and a single track segment named after the day
+func gpxHeader(day string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<gpx version="1.1" creator="particle-tachyon-gps-dbus" xmlns="http://www.topografix.com/GPX/1/1">` + "\n" +
+		" <trk>\n  <name>" + day + "</name>\n  <trkseg>\n"
+}
+
+// Append adds a track point for a valid fix, taking its time from the modem's UTC time when
+// available or at otherwise
+func (w *GPXWriter) Append(data *GnssData, at time.Time) error {
+	if t, err := data.Utc.Time(); err == nil {
+		at = t
+	}
+	at = at.UTC()
+	point := fmt.Sprintf("   <trkpt lat=\"%.7f\" lon=\"%.7f\"><ele>%.1f</ele><time>%s</time></trkpt>\n",
+		data.Latitude, data.Longitude, data.Altitude, at.Format(time.RFC3339))
+	if day := at.Format(time.DateOnly); day != w.day {
+		if err := w.Close(); err != nil {
+			return err
+		}
+		w.day, w.seq = day, 0
+	}
+	// A fresh file always has room, so this ends at the latest on a new continuation file
+	for w.f == nil || w.end+int64(len(point)+len(gpxFooter)) > w.maxBytes {
+		if w.f != nil {
+			if err := w.Close(); err != nil {
+				return err
+			}
+			w.seq++
+		}
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	if _, err := w.f.WriteAt([]byte(point+gpxFooter), w.end); err != nil {
+		return fmt.Errorf("failed to append GPX point: %w", err)
+	}
+	w.end += int64(len(point))
+	return nil
+}
+
+// open opens the first file for the current day, from the current continuation number, that
+// is either new or a well-formed GPX file from an earlier run, positioning at its footer
+func (w *GPXWriter) open() error {
+	for ; ; w.seq++ {
+		path := w.gpxPath(w.day, w.seq)
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			header := gpxHeader(w.day)
+			if _, err := f.WriteString(header + gpxFooter); err != nil {
+				f.Close()
+				return fmt.Errorf("failed to create GPX file: %w", err)
+			}
+			w.f, w.end = f, int64(len(header))
+			return nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("failed to create GPX file: %w", err)
+		}
+		// Resume an existing file written by an earlier run, unless it wasn't closed off with
+		// our footer; Append moves on if it's full
+		if f, err = os.OpenFile(path, os.O_RDWR, 0o644); err != nil {
+			return fmt.Errorf("failed to open GPX file: %w", err)
+		}
+		if end, ok := gpxFooterOffset(f); ok {
+			w.f, w.end = f, end
+			return nil
+		}
+		f.Close()
+	}
+}
+
+// gpxFooterOffset returns the offset of the footer if the file ends with it
+func gpxFooterOffset(f *os.File) (int64, bool) {
+	info, err := f.Stat()
+	if err != nil || info.Size() < int64(len(gpxFooter)) {
+		return 0, false
+	}
+	end := info.Size() - int64(len(gpxFooter))
+	tail := make([]byte, len(gpxFooter))
+	if _, err := f.ReadAt(tail, end); err != nil && err != io.EOF {
+		return 0, false
+	}
+	return end, bytes.Equal(tail, []byte(gpxFooter))
+}
+
+// Close closes the current file, which is already well-formed
+func (w *GPXWriter) Close() error {
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
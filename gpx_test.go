@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// gpxFile is the part of a GPX document the writer produces
+type gpxFile struct {
+	XMLName xml.Name `xml:"http://www.topografix.com/GPX/1/1 gpx"`
+	Track   struct {
+		Name     string `xml:"name"`
+		Segments []struct {
+			Points []struct {
+				Lat  float64 `xml:"lat,attr"`
+				Lon  float64 `xml:"lon,attr"`
+				Ele  float64 `xml:"ele"`
+				Time string  `xml:"time"`
+			} `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+// parseGPX parses the GPX file at path, failing the test if it isn't well-formed
+func parseGPX(t *testing.T, path string) gpxFile {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc gpxFile
+	if err := xml.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("%s isn't valid GPX: %v\n%s", filepath.Base(path), err, raw)
+	}
+	if len(doc.Track.Segments) != 1 {
+		t.Fatalf("%s has %d track segments, want 1", filepath.Base(path), len(doc.Track.Segments))
+	}
+	return doc
+}
+
+func TestGPXWriterAppendAndReopen(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewGPXWriter(dir, DefaultGPXMaxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	fix := GnssData{Latitude: 51.5007, Longitude: -0.1246, Altitude: 35.2}
+	for i := range 3 {
+		fix.Latitude += 0.0001
+		if err := w.Append(&fix, at.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "2024-05-01.gpx")
+	// Readable mid-run, before Close
+	if doc := parseGPX(t, path); len(doc.Track.Segments[0].Points) != 3 {
+		t.Errorf("open file has %d points, want 3", len(doc.Track.Segments[0].Points))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A restart the same day resumes the file
+	w, err = NewGPXWriter(dir, DefaultGPXMaxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix = GnssData{
+		Latitude: -33.8688, Longitude: 151.2093, Altitude: 58,
+		Utc: NmeaUtcTime{Year: 2024, Month: 5, Date: 1, Hour: 23, Min: 59, Sec: 59},
+	}
+	if err := w.Append(&fix, at); err != nil { // The modem's UTC time wins over at
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := parseGPX(t, path)
+	if doc.Track.Name != "2024-05-01" {
+		t.Errorf("track name = %q, want the day", doc.Track.Name)
+	}
+	points := doc.Track.Segments[0].Points
+	if len(points) != 4 {
+		t.Fatalf("file has %d points after reopening, want 4", len(points))
+	}
+	if p := points[0]; p.Lat != 51.5008 || p.Lon != -0.1246 || p.Ele != 35.2 || p.Time != "2024-05-01T09:00:00Z" {
+		t.Errorf("first point = %+v", p)
+	}
+	if p := points[3]; p.Lat != -33.8688 || p.Lon != 151.2093 || p.Ele != 58 || p.Time != "2024-05-01T23:59:59Z" {
+		t.Errorf("last point = %+v", p)
+	}
+}
+
+func TestGPXWriterRotation(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewGPXWriter(dir, MinGPXMaxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	at := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	fix := GnssData{Latitude: 51.5, Longitude: -0.12}
+	// About 100 bytes a point, so 100 points spill into continuation files
+	for i := range 100 {
+		if err := w.Append(&fix, at.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// UTC midnight starts the next day's file
+	if err := w.Append(&fix, time.Date(2024, 5, 2, 0, 0, 1, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]bool{}
+	total := 0
+	for _, e := range entries {
+		files[e.Name()] = true
+		path := filepath.Join(dir, e.Name())
+		if info, err := os.Stat(path); err != nil || info.Size() > MinGPXMaxBytes {
+			t.Errorf("%s is larger than the %d byte limit", e.Name(), MinGPXMaxBytes)
+		}
+		total += len(parseGPX(t, path).Track.Segments[0].Points)
+	}
+	for _, name := range []string{"2024-05-01.gpx", "2024-05-01-1.gpx", "2024-05-02.gpx"} {
+		if !files[name] {
+			t.Errorf("no %s among %v", name, files)
+		}
+	}
+	if total != 101 {
+		t.Errorf("files hold %d points in total, want 101", total)
+	}
+}
+
+func TestGPXWriterSkipsUnterminatedFile(t *testing.T) {
+	dir := t.TempDir()
+	// A file from a run that died before the footer was written
+	if err := os.WriteFile(filepath.Join(dir, "2024-05-01.gpx"), []byte(gpxHeader("2024-05-01")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewGPXWriter(dir, DefaultGPXMaxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix := GnssData{Latitude: 51.5, Longitude: -0.12}
+	if err := w.Append(&fix, time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if doc := parseGPX(t, filepath.Join(dir, "2024-05-01-1.gpx")); len(doc.Track.Segments[0].Points) != 1 {
+		t.Errorf("continuation file has %d points, want 1", len(doc.Track.Segments[0].Points))
+	}
+}
+
+func TestNewGPXWriterMinimumSize(t *testing.T) {
+	if _, err := NewGPXWriter(t.TempDir(), MinGPXMaxBytes-1); err == nil {
+		t.Error("NewGPXWriter() below the minimum size succeeded, want an error")
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthReport is the JSON body served by /healthz
+type HealthReport struct {
+	Status        string  `json:"status"`                    // "ok", or "stale" when no data has been read within the maximum age
+	Uptime        float64 `json:"uptime"`                    // Seconds since startup
+	LastError     string  `json:"last_error,omitempty"`      // Most recent error from the main loop
+	LastErrorTime string  `json:"last_error_time,omitempty"` // RFC3339 time of LastError
+	LastFixTime   string  `json:"last_fix_time,omitempty"`   // RFC3339 time of the last valid fix
+	LastReadTime  string  `json:"last_read_time,omitempty"`  // RFC3339 time of the last successful D-Bus read
+	ReadStreak    int     `json:"read_streak"`               // Consecutive successful D-Bus reads
+	ReadFailures  int     `json:"read_failures"`             // Consecutive failed D-Bus reads
+	PublishStreak int     `json:"publish_streak"`            // Consecutive successful publishes
+	Satellites    int     `json:"satellites"`                // Satellites in view in the latest fix
+}
+
+// HealthStatus is the payload periodically published to <topic>/health
+type HealthStatus struct {
+	HealthReport
+	Time          string   `json:"time"`                 // RFC3339 time the status was taken
+	MQTTConnected bool     `json:"mqtt_connected"`       // Whether the broker connection is currently up
+	OdometerM     *float64 `json:"odometer_m,omitempty"` // Total distance traveled, when ODOMETER_PATH is set
+}
+
+// NewHealthStatus combines a health report with the MQTT connection state
+func NewHealthStatus(report HealthReport, mqttConnected bool, now time.Time) HealthStatus {
+	return HealthStatus{
+		HealthReport:  report,
+		Time:          now.UTC().Format(time.RFC3339),
+		MQTTConnected: mqttConnected,
+	}
+}
+
+// HealthTracker records the main loop's errors and fixes for the health endpoint
+type HealthTracker struct {
+	clock   Clock
+	started time.Time
+
+	mu            sync.Mutex
+	maxAge        time.Duration // Report stale when nothing has been read for this long, 0 to always report ok
+	lastError     string
+	lastErrorTime time.Time
+	lastFixTime   time.Time
+	lastReadTime  time.Time
+	readStreak    int
+	readFailures  int
+	publishStreak int
+	satellites    int
+}
+
+// NewHealthTracker creates a tracker whose uptime starts now
+func NewHealthTracker(clock Clock) *HealthTracker {
+	return &HealthTracker{clock: clock, started: clock.Now()}
+}
+
+// RecordError stores err as the most recent error
+func (h *HealthTracker) RecordError(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.recordErrorLocked(err)
+}
+
+func (h *HealthTracker) recordErrorLocked(err error) {
+	h.lastError = err.Error()
+	h.lastErrorTime = h.clock.Now()
+}
+
+// RecordRead extends the read streak and resets the failure count, or on err records it,
+// resets the streak to 0 and counts the failure
+func (h *HealthTracker) RecordRead(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		h.recordErrorLocked(err)
+		h.readStreak = 0
+		h.readFailures++
+		return
+	}
+	h.readStreak++
+	h.readFailures = 0
+	h.lastReadTime = h.clock.Now()
+}
+
+// RecordPublish extends the publish streak, or records err and resets the streak to 0
+func (h *HealthTracker) RecordPublish(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		h.recordErrorLocked(err)
+		h.publishStreak = 0
+		return
+	}
+	h.publishStreak++
+}
+
+// SetMaxAge sets how long without data before the report turns stale, 0 to always report ok.
+// It can change while the health endpoint is being served, e.g. with the poll interval.
+func (h *HealthTracker) SetMaxAge(maxAge time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.maxAge = maxAge
+}
+
+// Streaks returns the current consecutive read and publish success counts
+func (h *HealthTracker) Streaks() (read, publish int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.readStreak, h.publishStreak
+}
+
+// RecordSatellites stores the number of satellites in view in the latest fix
+func (h *HealthTracker) RecordSatellites(n int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.satellites = n
+}
+
+// RecordFix notes that a valid fix was just read
+func (h *HealthTracker) RecordFix() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastFixTime = h.clock.Now()
+}
+
+// Report returns a snapshot of the current health
+func (h *HealthTracker) Report() HealthReport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.clock.Now()
+	report := HealthReport{
+		Status:        "ok",
+		Uptime:        now.Sub(h.started).Seconds(),
+		LastError:     h.lastError,
+		ReadStreak:    h.readStreak,
+		ReadFailures:  h.readFailures,
+		PublishStreak: h.publishStreak,
+		Satellites:    h.satellites,
+	}
+	if !h.lastErrorTime.IsZero() {
+		report.LastErrorTime = h.lastErrorTime.UTC().Format(time.RFC3339)
+	}
+	if !h.lastFixTime.IsZero() {
+		report.LastFixTime = h.lastFixTime.UTC().Format(time.RFC3339)
+	}
+	if !h.lastReadTime.IsZero() {
+		report.LastReadTime = h.lastReadTime.UTC().Format(time.RFC3339)
+	}
+	// Replayed fixes only update lastFixTime, so either counts as recent data
+	lastSeen := h.started
+	if h.lastReadTime.After(lastSeen) {
+		lastSeen = h.lastReadTime
+	}
+	if h.lastFixTime.After(lastSeen) {
+		lastSeen = h.lastFixTime
+	}
+	if h.maxAge > 0 && now.Sub(lastSeen) > h.maxAge {
+		report.Status = "stale"
+	}
+	return report
+}
+
+// ServeHTTP serves the health report as JSON, with status 503 when it's stale
+func (h *HealthTracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := h.Report()
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
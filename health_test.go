@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// getHealth serves /healthz from h and decodes the response
+func getHealth(t *testing.T, h *HealthTracker) (int, HealthReport) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var report HealthReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body, err)
+	}
+	return rec.Code, report
+}
+
+func TestHealthReportsInjectedError(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	health := NewHealthTracker(clock)
+
+	_, report := getHealth(t, health)
+	if report.LastError != "" || report.LastErrorTime != "" || report.LastFixTime != "" {
+		t.Errorf("fresh report = %+v, want no error or fix", report)
+	}
+
+	clock.Advance(90 * time.Second)
+	health.RecordFix()
+	clock.Advance(30 * time.Second)
+	health.RecordError(errors.New("dbus: connection reset"))
+
+	code, report := getHealth(t, health)
+	want := HealthReport{
+		Status:        "ok",
+		Uptime:        120,
+		LastError:     "dbus: connection reset",
+		LastErrorTime: "2024-01-01T12:02:00Z",
+		LastFixTime:   "2024-01-01T12:01:30Z",
+	}
+	if code != http.StatusOK || report != want {
+		t.Errorf("GET /healthz = %d %+v, want 200 %+v", code, report, want)
+	}
+}
+
+func TestHealthKeepsLatestError(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	health := NewHealthTracker(clock)
+	health.RecordError(errors.New("first"))
+	clock.Advance(time.Minute)
+	health.RecordPublish(errors.New("publish timed out"))
+	if report := health.Report(); report.LastError != "publish timed out" || report.LastErrorTime != "2024-01-01T00:01:00Z" {
+		t.Errorf("Report() error = %q at %q, want the publish error at 00:01", report.LastError, report.LastErrorTime)
+	}
+}
+
+func TestHealthStreaks(t *testing.T) {
+	health := NewHealthTracker(newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	errRead := errors.New("read failed")
+	steps := []struct {
+		name          string
+		record        func()
+		read, publish int
+		failures      int // Consecutive read failures
+	}{
+		{"read", func() { health.RecordRead(nil) }, 1, 0, 0},
+		{"read", func() { health.RecordRead(nil) }, 2, 0, 0},
+		{"publish", func() { health.RecordPublish(nil) }, 2, 1, 0},
+		{"publish", func() { health.RecordPublish(nil) }, 2, 2, 0},
+		{"read error", func() { health.RecordRead(errRead) }, 0, 2, 1},
+		{"read error", func() { health.RecordRead(errRead) }, 0, 2, 2},
+		{"publish error", func() { health.RecordPublish(errors.New("timeout")) }, 0, 0, 2},
+		{"read", func() { health.RecordRead(nil) }, 1, 0, 0},
+		{"publish", func() { health.RecordPublish(nil) }, 1, 1, 0},
+		{"other error", func() { health.RecordError(errors.New("geocoder")) }, 1, 1, 0},
+	}
+	for i, step := range steps {
+		step.record()
+		read, publish := health.Streaks()
+		report := health.Report()
+		if read != step.read || publish != step.publish || report.ReadStreak != step.read ||
+			report.PublishStreak != step.publish || report.ReadFailures != step.failures {
+			t.Errorf("step %d %s: streaks %d/%d, report %d/%d with %d failures, want %d/%d with %d failures", i, step.name,
+				read, publish, report.ReadStreak, report.PublishStreak, report.ReadFailures, step.read, step.publish, step.failures)
+		}
+	}
+}
+
+func TestHealthStatusSerialization(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	health := NewHealthTracker(clock)
+	health.RecordRead(nil)
+	health.RecordFix()
+	health.RecordSatellites(11)
+	clock.Advance(10 * time.Second)
+	health.RecordRead(errors.New("dbus: timeout"))
+	health.RecordRead(errors.New("dbus: timeout"))
+	clock.Advance(5 * time.Second)
+
+	status := NewHealthStatus(health.Report(), true, clock.Now().In(time.FixedZone("CET", 3600)))
+	raw, err := json.Marshal(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"status":          "ok",
+		"uptime":          15.0,
+		"last_error":      "dbus: timeout",
+		"last_error_time": "2024-01-01T12:00:10Z",
+		"last_fix_time":   "2024-01-01T12:00:00Z",
+		"last_read_time":  "2024-01-01T12:00:00Z",
+		"read_streak":     0.0,
+		"read_failures":   2.0,
+		"publish_streak":  0.0,
+		"satellites":      11.0,
+		"time":            "2024-01-01T12:00:15Z",
+		"mqtt_connected":  true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("health status JSON = %s, want %v", raw, want)
+	}
+
+	status.OdometerM = new(float64)
+	*status.OdometerM = 1234.5
+	if raw, err = json.Marshal(status); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), `"odometer_m":1234.5`) {
+		t.Errorf("health status JSON = %s, want the odometer included when set", raw)
+	}
+}
+
+func TestHealthReportStale(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	health := NewHealthTracker(clock)
+	health.SetMaxAge(30 * time.Second)
+	clock.Advance(20 * time.Second)
+	health.RecordRead(nil)
+	clock.Advance(30 * time.Second)
+	if code, report := getHealth(t, health); code != http.StatusOK || report.Status != "ok" {
+		t.Errorf("health 30s after a read = %d %q, want 200 ok", code, report.Status)
+	}
+	clock.Advance(time.Second)
+	if code, report := getHealth(t, health); code != http.StatusServiceUnavailable || report.Status != "stale" {
+		t.Errorf("health 31s after a read = %d %q, want 503 stale", code, report.Status)
+	}
+	health.SetMaxAge(0)
+	if _, report := getHealth(t, health); report.Status != "ok" {
+		t.Errorf("health without a maximum age = %q, want ok", report.Status)
+	}
+}
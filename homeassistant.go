@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// homeAssistantDiscoveryPrefix is Home Assistant's default MQTT discovery topic prefix
+const homeAssistantDiscoveryPrefix = "homeassistant"
+
+// haDevice is the device block grouping the discovered entities in Home Assistant
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+	SWVersion    string   `json:"sw_version"`
+}
+
+// haEntityConfig is a Home Assistant MQTT discovery payload
+type haEntityConfig struct {
+	Name                   string   `json:"name"`
+	UniqueID               string   `json:"unique_id"`
+	StateTopic             string   `json:"state_topic,omitempty"`
+	ValueTemplate          string   `json:"value_template,omitempty"`
+	JSONAttributesTopic    string   `json:"json_attributes_topic,omitempty"`
+	JSONAttributesTemplate string   `json:"json_attributes_template,omitempty"`
+	SourceType             string   `json:"source_type,omitempty"`
+	UnitOfMeasurement      string   `json:"unit_of_measurement,omitempty"`
+	DeviceClass            string   `json:"device_class,omitempty"`
+	StateClass             string   `json:"state_class,omitempty"`
+	Icon                   string   `json:"icon,omitempty"`
+	AvailabilityTopic      string   `json:"availability_topic"`
+	PayloadAvailable       string   `json:"payload_available"`
+	PayloadNotAvailable    string   `json:"payload_not_available"`
+	Device                 haDevice `json:"device"`
+}
+
+// publishHomeAssistantDiscovery publishes retained discovery configs for a GPS device tracker
+// and speed, altitude and satellite count sensors, all reading the JSON fix payloads on
+// <topic>/gnss, so the device appears in Home Assistant without manual configuration.
+// speedUnit is the configured SPEED_UNIT of the published Speed.
+func publishHomeAssistantDiscovery(client mqtt.Client, topic, deviceID, speedUnit string) error {
+	stateTopic := fmt.Sprintf("%s/gnss", topic)
+	device := haDevice{
+		Identifiers:  []string{deviceID},
+		Name:         deviceID,
+		Manufacturer: "Particle",
+		Model:        "Tachyon GNSS",
+		SWVersion:    version,
+	}
+	tracker := haEntityConfig{
+		Name:                "Location",
+		UniqueID:            deviceID + "_location",
+		JSONAttributesTopic: stateTopic,
+		// Home Assistant reads the position from lowercase attributes; HDOP stands in for accuracy
+		JSONAttributesTemplate: `{"latitude": {{ value_json.Latitude }}, "longitude": {{ value_json.Longitude }}, ` +
+			`"gps_accuracy": {{ (value_json.Hdop * 5) | round(1) }}, "altitude": {{ value_json.Altitude }}}`,
+		SourceType: "gps",
+		Device:     device,
+	}
+	configs := map[string]haEntityConfig{
+		fmt.Sprintf("%s/device_tracker/%s/config", homeAssistantDiscoveryPrefix, deviceID): tracker,
+		fmt.Sprintf("%s/sensor/%s_speed/config", homeAssistantDiscoveryPrefix, deviceID): {
+			Name:              "Speed",
+			UniqueID:          deviceID + "_speed",
+			StateTopic:        stateTopic,
+			ValueTemplate:     "{{ value_json.Speed }}",
+			UnitOfMeasurement: homeAssistantSpeedUnit(speedUnit),
+			DeviceClass:       "speed",
+			StateClass:        "measurement",
+			Device:            device,
+		},
+		fmt.Sprintf("%s/sensor/%s_altitude/config", homeAssistantDiscoveryPrefix, deviceID): {
+			Name:              "Altitude",
+			UniqueID:          deviceID + "_altitude",
+			StateTopic:        stateTopic,
+			ValueTemplate:     "{{ value_json.Altitude }}",
+			UnitOfMeasurement: "m",
+			DeviceClass:       "distance",
+			StateClass:        "measurement",
+			Device:            device,
+		},
+		fmt.Sprintf("%s/sensor/%s_satellites/config", homeAssistantDiscoveryPrefix, deviceID): {
+			Name:          "Satellites",
+			UniqueID:      deviceID + "_satellites",
+			StateTopic:    stateTopic,
+			ValueTemplate: "{{ value_json.Svnum + value_json.BeidouSvnum + (value_json.GlonassSvnum | default(0)) + (value_json.GalileoSvnum | default(0)) }}",
+			StateClass:    "measurement",
+			Icon:          "mdi:satellite-variant",
+			Device:        device,
+		},
+	}
+	for configTopic, config := range configs {
+		// Entities go unavailable when the status topic's will reports the bridge offline
+		config.AvailabilityTopic = fmt.Sprintf("%s/status", topic)
+		config.PayloadAvailable = StatusOnline
+		config.PayloadNotAvailable = StatusOffline
+		if err := publishRetainedJSON(client, configTopic, config); err != nil {
+			return fmt.Errorf("failed to publish %s: %w", configTopic, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// httpShutdownTimeout bounds how long in-flight requests may take once shutdown begins
+const httpShutdownTimeout = 5 * time.Second
+
+// runHTTPServer serves handler on addr until ctx is cancelled, then shuts the server down
+func runHTTPServer(ctx context.Context, name, addr string, handler http.Handler) {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(_ net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Failed to shut down %s server: %v", name, err)
+		}
+	}()
+	go func() {
+		log.Printf("Serving %s on %s", name, addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("%s server error: %v", name, err)
+		}
+	}()
+}
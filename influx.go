@@ -0,0 +1,93 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultInfluxMeasurement is the measurement name used when INFLUX_MEASUREMENT is unset
+const DefaultInfluxMeasurement = "gnss"
+
+// Line protocol escaping: measurements escape commas and spaces, while tag keys, tag values
+// and field keys also escape equals signs
+var (
+	influxMeasurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `)
+	influxKeyEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+)
+
+// ToLineProtocol formats the fix as a single InfluxDB line protocol point. Tags are written in
+// key order, skipping empty values, which line protocol can't represent. Counts and flags are
+// integer fields and measurements are floats; NaN and infinite values are left out. The
+// timestamp in nanoseconds comes from the UTC fields; it's omitted, leaving InfluxDB to use
+// its receive time, until the modem reports one.
+func (d *GnssData) ToLineProtocol(measurement string, tags map[string]string) string {
+	var b strings.Builder
+	b.WriteString(influxMeasurementEscaper.Replace(measurement))
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "" || tags[key] == "" {
+			continue
+		}
+		b.WriteByte(',')
+		b.WriteString(influxKeyEscaper.Replace(key))
+		b.WriteByte('=')
+		b.WriteString(influxKeyEscaper.Replace(tags[key]))
+	}
+
+	sep := byte(' ')
+	float := func(key string, v float64) {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return // Not representable in line protocol
+		}
+		b.WriteByte(sep)
+		sep = ','
+		b.WriteString(influxKeyEscaper.Replace(key))
+		b.WriteByte('=')
+		b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	integer := func(key string, v int64) {
+		b.WriteByte(sep)
+		sep = ','
+		b.WriteString(influxKeyEscaper.Replace(key))
+		b.WriteByte('=')
+		b.WriteString(strconv.FormatInt(v, 10))
+		b.WriteByte('i')
+	}
+	float("latitude", d.Latitude)
+	float("longitude", d.Longitude)
+	float("altitude", d.Altitude)
+	float("speed", d.Speed)
+	integer("valid", int64(d.Valid))
+	integer("fixmode", int64(d.Fixmode))
+	integer("gpssta", int64(d.Gpssta))
+	integer("svnum", int64(d.Svnum))
+	integer("beidou_svnum", int64(d.BeidouSvnum))
+	integer("glonass_svnum", int64(d.GlonassSvnum))
+	integer("galileo_svnum", int64(d.GalileoSvnum))
+	integer("satellites_in_view", int64(d.SatellitesInView()))
+	float("pdop", d.Pdop)
+	float("hdop", d.Hdop)
+	float("vdop", d.Vdop)
+	if d.AccuracyM != nil {
+		float("accuracy_m", *d.AccuracyM)
+	}
+	if d.HeadingDeg != nil {
+		float("heading_deg", *d.HeadingDeg)
+	}
+	if d.VerticalSpeedMs != nil {
+		float("vertical_speed_ms", *d.VerticalSpeedMs)
+	}
+
+	if t, err := d.Utc.Time(); err == nil {
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatInt(t.UnixNano(), 10))
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestToLineProtocol(t *testing.T) {
+	accuracy := 4.5
+	data := GnssData{
+		Latitude: 51.5007, Longitude: -0.1246, Altitude: 35, Speed: 4.25, Valid: 1, Fixmode: 3, Gpssta: 1,
+		Svnum: 9, BeidouSvnum: 3, Pdop: 1.8, Hdop: 0.9, Vdop: 1.5, AccuracyM: &accuracy,
+		Utc: NmeaUtcTime{Year: 2024, Month: 6, Date: 1, Hour: 12, Min: 30, Sec: 15},
+	}
+	want := "gnss,device=tachyon-1 latitude=51.5007,longitude=-0.1246,altitude=35,speed=4.25,valid=1i,fixmode=3i," +
+		"gpssta=1i,svnum=9i,beidou_svnum=3i,glonass_svnum=0i,galileo_svnum=0i,satellites_in_view=12i," +
+		"pdop=1.8,hdop=0.9,vdop=1.5,accuracy_m=4.5 1717245015000000000"
+	if got := data.ToLineProtocol(DefaultInfluxMeasurement, map[string]string{"device": "tachyon-1"}); got != want {
+		t.Errorf("ToLineProtocol() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestToLineProtocolEscaping(t *testing.T) {
+	data := GnssData{}
+	tests := []struct {
+		name        string
+		measurement string
+		tags        map[string]string
+		wantPrefix  string
+	}{
+		{"plain", "gnss", nil, "gnss latitude="},
+		{"measurement with a space and comma", "gps fix,v2", nil, `gps\ fix\,v2 latitude=`},
+		{"equals is literal in a measurement", "a=b", nil, "a=b latitude="},
+		{"tag value with a space", "gnss", map[string]string{"site": "north yard"}, `gnss,site=north\ yard latitude=`},
+		{"tag value with a comma and equals", "gnss", map[string]string{"site": "a,b=c"}, `gnss,site=a\,b\=c latitude=`},
+		{"tag key with special characters", "gnss", map[string]string{"my tag=x,y": "v"}, `gnss,my\ tag\=x\,y=v latitude=`},
+		{"tags in key order", "gnss", map[string]string{"z": "1", "a": "2", "m": "3"}, "gnss,a=2,m=3,z=1 latitude="},
+		{"empty tag values and keys skipped", "gnss", map[string]string{"fleet": "", "": "x", "device": "t1"}, "gnss,device=t1 latitude="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := data.ToLineProtocol(tt.measurement, tt.tags); !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("ToLineProtocol() = %s, want it to start %s", got, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func TestToLineProtocolSkipsUnrepresentableValues(t *testing.T) {
+	heading := math.Inf(1)
+	data := GnssData{Latitude: math.NaN(), Longitude: -0.1246, HeadingDeg: &heading}
+	got := data.ToLineProtocol("gnss", nil)
+	if !strings.HasPrefix(got, "gnss longitude=-0.1246,") {
+		t.Errorf("ToLineProtocol() = %s, want it to start with the longitude", got)
+	}
+	for _, field := range []string{"latitude", "heading_deg", "NaN", "Inf"} {
+		if strings.Contains(got, field) {
+			t.Errorf("ToLineProtocol() = %s, want no %s", got, field)
+		}
+	}
+	// Without a UTC time there's no timestamp, so the line ends with the last field
+	if fields := strings.Split(got, " "); len(fields) != 2 {
+		t.Errorf("ToLineProtocol() = %s, want a measurement and fields with no timestamp", got)
+	}
+}
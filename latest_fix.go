@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// LatestFix holds the most recently read fix for the HTTP API
+type LatestFix struct {
+	mu   sync.Mutex
+	data *GnssData
+}
+
+// Set stores a copy of data as the latest fix
+func (l *LatestFix) Set(data *GnssData) {
+	fix := *data
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.data = &fix
+}
+
+// Get returns the latest fix, or nil before the first one is read
+func (l *LatestFix) Get() *GnssData {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.data
+}
+
+// ServeHTTP serves the latest fix as JSON, or 404 before the first one is read
+func (l *LatestFix) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data := l.Get()
+	if data == nil {
+		http.Error(w, "no fix read yet", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(data)
+}
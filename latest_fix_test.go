@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a loopback address with a port nothing is listening on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+// getURL retries url until the server is listening, returning the status and body
+func getURL(t *testing.T, url string) (int, []byte) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get(url)
+		if err == nil {
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return resp.StatusCode, body
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("GET %s: %v", url, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestGnssEndpointServesLatestFix(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	latestFix := &LatestFix{}
+	mux := http.NewServeMux()
+	mux.Handle("GET /gnss", latestFix)
+	addr := freeAddr(t)
+	runHTTPServer(ctx, "HTTP", addr, mux)
+
+	if status, body := getURL(t, "http://"+addr+"/gnss"); status != http.StatusNotFound {
+		t.Errorf("GET /gnss before a fix = %d %s, want 404", status, body)
+	}
+
+	fix := GnssData{
+		DeviceID: "tachyon-1", Latitude: 51.5007, Longitude: -0.1246, Altitude: 35.2, Speed: 4.5, Svnum: 9,
+		Utc: NmeaUtcTime{Year: 2024, Month: 6, Date: 1, Hour: 12, Min: 30, Sec: 15},
+	}
+	latestFix.Set(&fix)
+	fix.Latitude = 0 // Set must have taken a copy
+
+	status, body := getURL(t, "http://"+addr+"/gnss")
+	if status != http.StatusOK {
+		t.Fatalf("GET /gnss = %d %s, want 200", status, body)
+	}
+	var got GnssData
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("decoding %s: %v", body, err)
+	}
+	want := fix
+	want.Latitude = 51.5007
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GET /gnss = %+v, want %+v", got, want)
+	}
+
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get("http://" + addr + "/gnss")
+		if err != nil {
+			break
+		}
+		resp.Body.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("server still serving after the context was cancelled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
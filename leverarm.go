@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// HeadingMinDistance is how far in meters the device must move between fixes before a new
+// heading is derived from them; shorter hops are dominated by position noise
+const HeadingMinDistance = 2.0
+
+// LeverArm is the body-frame offset in meters from the GNSS antenna to the vehicle's reference point
+type LeverArm struct {
+	Forward float64 // Toward the front of the vehicle, negative for behind the antenna
+	Right   float64 // Toward the right of the vehicle, negative for left of the antenna
+}
+
+// ParseLeverArm parses a "forward,right" offset in meters, e.g. "-1.2,0.4"
+func ParseLeverArm(s string) (LeverArm, error) {
+	forwardStr, rightStr, ok := strings.Cut(s, ",")
+	if !ok {
+		return LeverArm{}, fmt.Errorf("expected forward,right in meters, got %q", s)
+	}
+	forward, err := strconv.ParseFloat(strings.TrimSpace(forwardStr), 64)
+	if err != nil {
+		return LeverArm{}, fmt.Errorf("invalid forward offset %q", forwardStr)
+	}
+	right, err := strconv.ParseFloat(strings.TrimSpace(rightStr), 64)
+	if err != nil {
+		return LeverArm{}, fmt.Errorf("invalid right offset %q", rightStr)
+	}
+	return LeverArm{Forward: forward, Right: right}, nil
+}
+
+// Apply translates the antenna position to the reference point for a vehicle heading in
+// degrees from true north, rotating the body-frame offset into north and east components
+func (a LeverArm) Apply(lat, lon, heading float64) (float64, float64) {
+	h := heading * math.Pi / 180
+	north := a.Forward*math.Cos(h) - a.Right*math.Sin(h)
+	east := a.Forward*math.Sin(h) + a.Right*math.Cos(h)
+	return OffsetPosition(lat, lon, north, east)
+}
+
+// HeadingTracker derives the direction of travel from consecutive positions, keeping the last
+// heading while the device is stationary
+type HeadingTracker struct {
+	minDistance float64
+	lat, lon    float64 // Position the current heading was measured from
+	havePos     bool
+	heading     float64
+	haveHeading bool
+}
+
+// NewHeadingTracker creates a tracker that only updates the heading after moving minDistance meters
+func NewHeadingTracker(minDistance float64) *HeadingTracker {
+	return &HeadingTracker{minDistance: minDistance}
+}
+
+// Update feeds a new position and returns the current heading in degrees from true north,
+// or false until the device has moved far enough to measure one
+func (t *HeadingTracker) Update(lat, lon float64) (float64, bool) {
+	t.Measure(lat, lon)
+	return t.heading, t.haveHeading
+}
+
+// Measure feeds a new position and returns a heading only when this position is far enough
+// from the one the last heading was measured from to give a new measurement
+func (t *HeadingTracker) Measure(lat, lon float64) (float64, bool) {
+	if !t.havePos {
+		t.lat, t.lon, t.havePos = lat, lon, true
+		return 0, false
+	}
+	if Haversine(t.lat, t.lon, lat, lon) < t.minDistance {
+		return 0, false
+	}
+	t.heading = Bearing(t.lat, t.lon, lat, lon)
+	t.haveHeading = true
+	t.lat, t.lon = lat, lon
+	return t.heading, true
+}
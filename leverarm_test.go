@@ -0,0 +1,91 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLeverArmApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		arm         LeverArm
+		heading     float64
+		north, east float64 // Expected shift in meters
+	}{
+		{"forward facing north", LeverArm{Forward: 2}, 0, 2, 0},
+		{"forward facing east", LeverArm{Forward: 2}, 90, 0, 2},
+		{"forward facing south", LeverArm{Forward: 2}, 180, -2, 0},
+		{"behind and right facing north", LeverArm{Forward: -1.2, Right: 0.4}, 0, -1.2, 0.4},
+		{"right facing west", LeverArm{Right: 1}, 270, 1, 0},
+		{"forward facing north east", LeverArm{Forward: math.Sqrt2}, 45, 1, 1},
+		{"no offset", LeverArm{}, 123, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lat, lon := tt.arm.Apply(51.5, -0.12, tt.heading)
+			east, north := ENUOffset(51.5, -0.12, lat, lon)
+			if math.Abs(north-tt.north) > 1e-6 || math.Abs(east-tt.east) > 1e-6 {
+				t.Errorf("Apply() moved %.4f m north, %.4f m east, want %.4f, %.4f", north, east, tt.north, tt.east)
+			}
+		})
+	}
+}
+
+func TestLeverArmApplyKnownCoordinates(t *testing.T) {
+	// A thousandth of a degree of latitude on the 6371 km sphere
+	arm := LeverArm{Forward: 6371000 * math.Pi / 180 / 1000}
+	lat, lon := arm.Apply(0, 0, 0)
+	if math.Abs(lat-0.001) > 1e-12 || lon != 0 {
+		t.Errorf("Apply() = %v, %v, want 0.001, 0", lat, lon)
+	}
+	// Facing east at 60°N a degree of longitude is half as long
+	arm = LeverArm{Forward: 6371000 * math.Pi / 180 / 2}
+	lat, lon = arm.Apply(60, 10, 90)
+	if math.Abs(lat-60) > 1e-12 || math.Abs(lon-11) > 1e-9 {
+		t.Errorf("Apply() = %v, %v, want 60, 11", lat, lon)
+	}
+}
+
+func TestParseLeverArm(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    LeverArm
+		wantErr bool
+	}{
+		{"-1.2,0.4", LeverArm{Forward: -1.2, Right: 0.4}, false},
+		{" 2 , -0.5 ", LeverArm{Forward: 2, Right: -0.5}, false},
+		{"0,0", LeverArm{}, false},
+		{"1.5", LeverArm{}, true},
+		{"a,1", LeverArm{}, true},
+		{"1,b", LeverArm{}, true},
+		{"", LeverArm{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLeverArm(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLeverArm(%q) = %+v, %v, want %+v, error %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestHeadingTracker(t *testing.T) {
+	tracker := NewHeadingTracker(HeadingMinDistance)
+	steps := []struct {
+		name     string
+		lat, lon float64
+		want     float64
+		wantOK   bool
+	}{
+		{"first position", 51, -1, 0, false},
+		{"jitter", 51.000001, -1, 0, false},
+		{"moved north", 51.0001, -1, 0, true},
+		{"stationary keeps heading", 51.0001, -1, 0, true},
+		{"moved east", 51.0001, -0.9999, 90, true},
+	}
+	for _, step := range steps {
+		got, ok := tracker.Update(step.lat, step.lon)
+		if ok != step.wantOK || math.Abs(got-step.want) > 0.01 {
+			t.Errorf("%s: Update() = %.3f, %t, want %.3f, %t", step.name, got, ok, step.want, step.wantOK)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import "time"
+
+// DefaultStaleMaxAge is how long the last known good fix is republished for when
+// STALE_MAX_AGE_SECONDS is unset
+const DefaultStaleMaxAge = 5 * time.Minute
+
+// LastKnownGood caches the most recent valid fix so it can be republished, flagged stale,
+// while reads fail or the modem has lost its fix
+type LastKnownGood struct {
+	maxAge time.Duration // Stop republishing once the fix is older than this
+	data   *GnssData
+	at     time.Time
+}
+
+// NewLastKnownGood creates an empty cache republishing fixes up to maxAge old
+func NewLastKnownGood(maxAge time.Duration) *LastKnownGood {
+	return &LastKnownGood{maxAge: maxAge}
+}
+
+// Store caches a copy of a valid fix read at now
+func (l *LastKnownGood) Store(data *GnssData, now time.Time) {
+	fix := *data
+	l.data, l.at = &fix, now
+}
+
+// Stale returns a copy of the cached fix flagged stale with its age at now, or false when
+// there's no fix yet or it's older than the maximum age
+func (l *LastKnownGood) Stale(now time.Time) (*GnssData, bool) {
+	if l.data == nil {
+		return nil, false
+	}
+	age := now.Sub(l.at)
+	if age > l.maxAge {
+		return nil, false
+	}
+	fix := *l.data
+	fix.Stale = true
+	fix.AgeSeconds = age.Seconds()
+	return &fix, true
+}
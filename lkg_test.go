@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLastKnownGoodTransitions(t *testing.T) {
+	lkg := NewLastKnownGood(DefaultStaleMaxAge)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if fix, ok := lkg.Stale(start); ok {
+		t.Fatalf("Stale() before any fix = %+v, want nothing to republish", fix)
+	}
+
+	// Fresh
+	fresh := &GnssData{Latitude: 51.5, Longitude: -0.12, Svnum: 9}
+	lkg.Store(fresh, start)
+	fresh.Latitude = 0 // Store must have taken a copy
+
+	// Stale while reads fail, with a growing age
+	for _, age := range []time.Duration{time.Second, 90 * time.Second, DefaultStaleMaxAge} {
+		fix, ok := lkg.Stale(start.Add(age))
+		if !ok {
+			t.Fatalf("Stale() %v after the fix = nothing, want it republished", age)
+		}
+		if !fix.Stale || fix.AgeSeconds != age.Seconds() || fix.Latitude != 51.5 || fix.Svnum != 9 {
+			t.Errorf("Stale() %v after the fix = %+v, want the fix flagged stale at %vs", age, fix, age.Seconds())
+		}
+	}
+	if fix, ok := lkg.Stale(start.Add(DefaultStaleMaxAge + time.Second)); ok {
+		t.Errorf("Stale() past the maximum age = %+v, want nothing to republish", fix)
+	}
+
+	// Fresh again, after which staleness is measured from the new fix
+	later := start.Add(10 * time.Minute)
+	lkg.Store(&GnssData{Latitude: 52.0, Longitude: -0.2}, later)
+	fix, ok := lkg.Stale(later.Add(30 * time.Second))
+	if !ok || fix.Latitude != 52.0 || fix.AgeSeconds != 30 {
+		t.Errorf("Stale() after a new fix = %+v, %t, want the new fix 30s old", fix, ok)
+	}
+	// Stale returns a copy, so changing it leaves the cache alone
+	fix.Latitude = 0
+	if again, _ := lkg.Stale(later.Add(31 * time.Second)); again.Latitude != 52.0 {
+		t.Errorf("Stale() returned the cached fix rather than a copy")
+	}
+}
@@ -3,23 +3,38 @@ package main
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	// MaxSatelliteCount defines the maximum number of satellites that can be tracked
-	MaxSatelliteCount = 12
+	// StatusOnline and StatusOffline are the retained payloads of <topic>/status
+	StatusOnline  = "online"
+	StatusOffline = "offline"
+	// DefaultPollInterval is how often the modem is polled for a fix when POLL_INTERVAL_SECONDS is unset
+	DefaultPollInterval = 10 * time.Second
 )
 
 func init() {
@@ -38,7 +53,116 @@ func getEnv(key string) (string, error) {
 	return val, nil
 }
 
+// getEnvDefault retrieves an environment variable value, falling back to def when it's unset
+func getEnvDefault(key, def string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return def
+}
+
+// getEnvBool parses an optional boolean environment variable, returning def when it's unset
+func getEnvBool(key string, def bool) (bool, error) {
+	val := os.Getenv(key)
+	if val == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for %s: %q is not a boolean", key, val)
+	}
+	return b, nil
+}
+
+// getEnvInt parses an optional integer environment variable, returning def when it's unset
+func getEnvInt(key string, def int) (int, error) {
+	val := os.Getenv(key)
+	if val == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for %s: %q is not an integer", key, val)
+	}
+	return i, nil
+}
+
+// getEnvFloat parses an optional floating point environment variable, returning def when it's unset
+func getEnvFloat(key string, def float64) (float64, error) {
+	val := os.Getenv(key)
+	if val == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for %s: %q is not a number", key, val)
+	}
+	return f, nil
+}
+
+// ParsePollInterval parses a POLL_INTERVAL_SECONDS value, which must be a positive number of seconds
+func ParsePollInterval(val string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for POLL_INTERVAL_SECONDS: %q is not a number", val)
+	}
+	interval := time.Duration(seconds * float64(time.Second))
+	if !(seconds > 0) || interval <= 0 { // Also rejects NaN and values overflowing a Duration
+		return 0, fmt.Errorf("invalid value for POLL_INTERVAL_SECONDS: %q must be positive", val)
+	}
+	return interval, nil
+}
+
+// publish sends payload to topic at QoS 0 and waits for the broker to acknowledge it. It fails
+// while the client is reconnecting, when paho would otherwise silently drop QoS 0 messages.
+func publish(client mqtt.Client, topic string, payload []byte) error {
+	return publishQoS(client, topic, 0, false, payload)
+}
+
+// publishQoS is publish with the given QoS and retained flag
+func publishQoS(client mqtt.Client, topic string, qos byte, retained bool, payload []byte) error {
+	if !client.IsConnectionOpen() {
+		return mqtt.ErrNotConnected
+	}
+	token := client.Publish(topic, qos, retained, payload)
+	token.Wait()
+	return token.Error()
+}
+
+// publishJSON marshals v as JSON and publishes it to topic
+func publishJSON(client mqtt.Client, topic string, v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return publish(client, topic, payload)
+}
+
+// publishRetained sends payload to topic as a retained QoS 1 message and waits for the acknowledgement
+func publishRetained(client mqtt.Client, topic string, payload []byte) error {
+	token := client.Publish(topic, 1, true, payload)
+	token.Wait()
+	return token.Error()
+}
+
+// publishRetainedJSON marshals v as JSON and publishes it to topic as a retained message
+func publishRetainedJSON(client mqtt.Client, topic string, v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return publishRetained(client, topic, payload)
+}
+
 func main() {
+	// Everything else is configured through the environment
+	showVersion := flag.Bool("version", false, "print the build version and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(versionString())
+		return
+	}
+
 	// Set up graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -51,82 +175,1285 @@ func main() {
 		log.Println("Received shutdown signal, gracefully shutting down...")
 		cancel()
 	}()
+	// SIGHUP republishes the birth message
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
 
-	// Load environment variables with error handling
-	mqttBrokerPort, err := getEnv("MQTT_BROKER_PORT")
-	if err != nil {
+	if err := loadConfigFile(); err != nil {
 		log.Fatalf("Environment setup failed: %v", err)
 	}
-	mqttBrokerURL, err := getEnv("MQTT_BROKER_URL")
+	cfg, err := readConfig()
 	if err != nil {
-		log.Fatalf("Environment setup failed: %v", err)
+		log.Fatalf("Environment setup failed:\n%v", err)
 	}
-	mqttTopic, err := getEnv("MQTT_TOPIC")
+	// Apply runtime tuning first so it covers everything that follows
+	ApplyRuntimeLimits(cfg.MemoryLimit, cfg.MaxProcs)
+
+	shutdownTracing, err := setupTracing(ctx, cfg.OTLPEndpoint, cfg.DeviceID)
 	if err != nil {
-		log.Fatalf("Environment setup failed: %v", err)
+		log.Fatalf("Environment setup failed: tracing: %v", err)
 	}
-	mqttUsername, err := getEnv("MQTT_USERNAME")
+	defer func() {
+		// ctx is already cancelled by now, so give the final spans their own deadline
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+	}()
+
+	encoder, err := NewPayloadEncoder(cfg.PayloadFormat, CloudEventSource(cfg.DeviceID))
 	if err != nil {
 		log.Fatalf("Environment setup failed: %v", err)
 	}
-	mqttPassword, err := getEnv("MQTT_PASSWORD")
-	if err != nil {
-		log.Fatalf("Environment setup failed: %v", err)
+	encoder.CRC = cfg.PayloadCRC
+	encoder.EncKey = cfg.PayloadEncKey
+	encoder.Compression = cfg.PayloadCompression
+	encoder.NMEASplit = cfg.NMEASplit
+	encoder.BeidouTalker = cfg.NMEABeidouTalker
+	encoder.Rounding = cfg.Rounding
+	encoder.InfluxMeasurement = cfg.InfluxMeasurement
+	encoder.InfluxTags = cfg.InfluxTags
+
+	var validator *PayloadValidator
+	if cfg.ValidateSchema != "" {
+		if validator, err = NewPayloadValidator(); err != nil {
+			log.Fatalf("Failed to load payload schema: %v", err)
+		}
 	}
 
-	rootCAs, err := x509.SystemCertPool()
-	if err != nil {
-		log.Fatalf("Failed to load system cert pool: %v", err)
+	var zoneTracker *ZoneTracker
+	if cfg.ZonesFile != "" {
+		zones, err := LoadZones(cfg.ZonesFile)
+		if err != nil {
+			log.Fatalf("Failed to load zones: %v", err)
+		}
+		zoneTracker = NewZoneTracker(zones)
+		log.Printf("Loaded %d zones from %s", len(zones), cfg.ZonesFile)
+	}
+
+	var geofence *GeofenceTracker
+	if cfg.GeofenceCenter != nil {
+		geofence = NewGeofenceTracker(cfg.GeofenceCenter[0], cfg.GeofenceCenter[1], cfg.GeofenceRadiusM)
+	}
+
+	var medianFilter *MedianFilter
+	if cfg.MedianFilterWindow != 0 {
+		medianFilter, _ = NewMedianFilter(cfg.MedianFilterWindow) // Validated by readConfig
+	}
+
+	var outlierFilter *OutlierFilter
+	if cfg.MaxSpeedMs > 0 {
+		outlierFilter = NewOutlierFilter(cfg.MaxSpeedMs)
+	}
+
+	var smoothing *SmoothingFilter
+	if cfg.SmoothWindow != 0 {
+		smoothing, _ = NewSmoothingFilter(cfg.SmoothWindow) // Validated by readConfig
+	}
+
+	var headingTracker *HeadingTracker
+	if cfg.LeverArm != nil {
+		headingTracker = NewHeadingTracker(HeadingMinDistance)
+	}
+
+	// Course over ground, only measured once the device has moved far enough to outweigh noise
+	courseTracker := NewHeadingTracker(cfg.HeadingMinDistanceM)
+
+	var odometer *Odometer
+	if cfg.OdometerPath != "" {
+		if odometer, err = LoadOdometer(cfg.OdometerPath, cfg.OdometerMaxSpeedMs); err != nil {
+			log.Fatalf("Failed to load odometer: %v", err)
+		}
+		log.Printf("Odometer at %.0f m", odometer.Meters())
+	}
+
+	var verticalSpeed *VerticalSpeedTracker
+	if cfg.VerticalSpeed {
+		verticalSpeed = &VerticalSpeedTracker{}
+	}
+
+	var distanceSampler *DistanceSampler
+	if cfg.SampleEveryM > 0 {
+		distanceSampler = NewDistanceSampler(cfg.SampleEveryM)
+		log.Printf("Publishing a record every %.1f m traveled", cfg.SampleEveryM)
+	}
+
+	// A SIGHUP reload can change the interval, creating or dropping the debouncer
+	eventMinInterval := cfg.EventMinInterval
+	var debouncer *EventDebouncer
+	if eventMinInterval > 0 {
+		debouncer = NewEventDebouncer(eventMinInterval)
+	}
+
+	var fixTracker *FixTracker
+	if cfg.FixEvents {
+		fixTracker = NewFixTracker()
+	}
+
+	var networkReader *NetworkTypeReader
+	if cfg.IncludeNetworkType {
+		if networkReader, err = NewNetworkTypeReader(); err != nil {
+			log.Fatalf("Failed to connect to D-Bus for network type: %v", err)
+		}
+	}
+	networkErrorLogged := false
+
+	// Poll interval and invalid-fix publishing can be changed at runtime over <topic>/cmd
+	settings := NewRuntimeSettings(cfg.PollInterval, cfg.PublishInvalid)
+	commandTopic := fmt.Sprintf("%s/cmd", cfg.DeviceTopic)
+	commandAckTopic := fmt.Sprintf("%s/cmd/ack", cfg.DeviceTopic)
+	intervalChanged := make(chan struct{}, 1)
+	handleCommand := func(c mqtt.Client, msg mqtt.Message) {
+		ack, changed := settings.HandleCommand(msg.Payload())
+		if ack.OK {
+			log.Printf("Applied command: poll interval %s, publish invalid fixes %t", settings.PollInterval(), ack.PublishInvalid)
+		} else {
+			log.Printf("Rejected command: %s", ack.Error)
+		}
+		if changed {
+			select {
+			case intervalChanged <- struct{}{}:
+			default:
+			}
+		}
+		// Waiting on a publish from inside a message handler can stall paho's router
+		go func() {
+			if err := publishJSON(c, commandAckTopic, ack); err != nil {
+				log.Printf("Failed to publish command ack: %v", err)
+			}
+		}()
+	}
+
+	var clock Clock = systemClock{}
+
+	var lastSNRHistogram time.Time
+
+	var grace *StartupGrace
+	if cfg.StartupGrace > 0 {
+		grace = NewStartupGrace(clock.Now(), cfg.StartupGrace)
+		log.Printf("Waiting at least %.0f s and for a valid fix before publishing birth and online status", cfg.StartupGrace.Seconds())
+	}
+
+	if cfg.MaxRuntime > 0 {
+		log.Printf("Maximum runtime %s, stopping at %s", cfg.MaxRuntime, clock.Now().Add(cfg.MaxRuntime).Format(time.RFC3339))
+		go StopAfter(ctx, clock, cfg.MaxRuntime, cancel)
+	}
+
+	var driftMonitor *ClockDriftMonitor
+	if cfg.ClockDriftThreshold > 0 {
+		driftMonitor = NewClockDriftMonitor(cfg.ClockDriftThreshold)
+	}
+
+	var rollup *DailyRollup
+	if cfg.DailyRollup {
+		rollup = NewDailyRollup(clock, cfg.DailyRollupHour, cfg.DailyRollupMinute)
+		log.Printf("Daily rollup scheduled, next at %s", rollup.Next().Format(time.RFC3339))
+	}
+
+	var addressCache *AddressCache
+	if cfg.GeocoderURL != "" {
+		geocoder := &NominatimGeocoder{
+			Endpoint: cfg.GeocoderURL,
+			Key:      cfg.GeocoderKey,
+			Client:   &http.Client{Timeout: geocodeTimeout},
+		}
+		addressCache = NewAddressCache(geocoder, clock, cfg.GeocoderMinInterval, cfg.GeocoderCacheMeters)
+	}
+
+	gate := &PublishGate{
+		Windows:         cfg.PublishWindows,
+		RequireMoving:   cfg.PublishOnlyWhenMoving,
+		MovingThreshold: cfg.MovingThreshold,
+		MinMoveMeters:   cfg.MinMoveMeters,
+		Heartbeat:       cfg.Heartbeat,
+	}
+
+	// Adaptive polling slows the ticker to the idle interval while the device is parked
+	var motion *MotionState
+	if cfg.IdleInterval > 0 {
+		motion = NewMotionState(cfg.StationaryPeriod, cfg.MovingFixes)
+	}
+	// currentPollInterval is the poll interval for the current motion state
+	currentPollInterval := func() time.Duration {
+		if motion != nil && !motion.Moving() {
+			return cfg.IdleInterval
+		}
+		return settings.PollInterval()
+	}
+
+	metrics := NewMetrics()
+	var remoteWriter *RemoteWriter
+	if cfg.PromRemoteWriteURL != "" && cfg.DryRun {
+		log.Println("Dry run: not pushing metrics to PROM_REMOTE_WRITE_URL")
+	} else if cfg.PromRemoteWriteURL != "" {
+		remoteWriter = &RemoteWriter{
+			URL:      cfg.PromRemoteWriteURL,
+			Client:   &http.Client{Timeout: remoteWriteTimeout},
+			Gatherer: metrics.Registry,
+			Labels:   map[string]string{"job": "particle-tachyon-gps-dbus", "instance": cfg.DeviceID},
+			Clock:    clock,
+		}
+	}
+
+	// Additional destinations for fix payloads alongside the MQTT broker
+	var sinks []Publisher
+	if cfg.AzureIoTHostName != "" && cfg.DryRun {
+		sinks = append(sinks, &StdoutPublisher{Name: "azure_iot", W: os.Stdout})
+	} else if cfg.AzureIoTHostName != "" {
+		azure := &AzureIoTPublisher{
+			HostName:    cfg.AzureIoTHostName,
+			DeviceID:    cfg.AzureIoTDeviceID,
+			Key:         cfg.AzureIoTKey,
+			ContentType: encoder.ContentType(),
+			Client:      &http.Client{Timeout: azureIoTTimeout},
+			Clock:       clock,
+		}
+		if encoder.Gzipped() {
+			azure.ContentEncoding = PayloadCompressionGzip
+		} else {
+			azure.ContentEncoding = encoder.Charset()
+		}
+		sinks = append(sinks, azure)
+		log.Printf("Publishing to Azure IoT Hub %s as device %s", cfg.AzureIoTHostName, cfg.AzureIoTDeviceID)
+	}
+	// The webhook is kept apart from the other sinks since its failures are queued like MQTT's
+	var webhook *WebhookPublisher
+	if cfg.WebhookURL != "" && cfg.DryRun {
+		// Printed like the other sinks, as there are no failures to queue
+		sinks = append(sinks, &StdoutPublisher{Name: WebhookSink, W: os.Stdout})
+	} else if cfg.WebhookURL != "" {
+		webhook = &WebhookPublisher{
+			URL:         cfg.WebhookURL,
+			ContentType: encoder.ContentType(),
+			Headers:     cfg.WebhookHeaders,
+			MaxBackoff:  cfg.WebhookMaxBackoff,
+			Client:      &http.Client{Timeout: cfg.WebhookTimeout},
+			Clock:       clock,
+		}
+		if encoder.Gzipped() {
+			// Encryption wraps a compressed payload in base64, so it's no longer gzip on the wire
+			webhook.ContentEncoding = PayloadCompressionGzip
+		}
+		// Only the host is logged, as the path or query may hold a token
+		if u, err := url.Parse(cfg.WebhookURL); err == nil {
+			log.Printf("Publishing to webhook on %s", u.Host)
+		}
+	}
+
+	if cfg.MetricsListenAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics.Handler())
+		runHTTPServer(ctx, "metrics", cfg.MetricsListenAddr, mux)
+	}
+
+	if cfg.PprofListenAddr != "" {
+		if !isLoopbackAddr(cfg.PprofListenAddr) {
+			log.Printf("Warning: PPROF_LISTEN_ADDR %s isn't a loopback address, so profiles are reachable from the network", cfg.PprofListenAddr)
+		}
+		runHTTPServer(ctx, "pprof", cfg.PprofListenAddr, NewPprofMux())
+	}
+
+	var pgSink *PostgresSink
+	if cfg.PGDSN != "" && cfg.DryRun {
+		log.Println("Dry run: not connecting to the PG_DSN database")
+	} else if cfg.PGDSN != "" {
+		if pgSink, err = OpenPostgresSink(cfg.PGDSN, cfg.PGTable, cfg.DeviceID, cfg.PGBatchSize); err != nil {
+			log.Fatalf("Environment setup failed: Postgres sink: %v", err)
+		}
+		defer func() {
+			if err := pgSink.Close(); err != nil {
+				log.Printf("Failed to flush fixes to Postgres on shutdown: %v", err)
+			}
+		}()
+		go pgSink.Run(ctx, cfg.PGFlushInterval)
+	}
+
+	health := NewHealthTracker(clock)
+	// updateHealthMaxAge applies HEALTH_MAX_AGE_SECONDS, or by default three of the longest poll
+	// interval in effect, so a device polling slowly while idle or after a command isn't stale
+	updateHealthMaxAge := func() {
+		if cfg.HealthMaxAge != nil {
+			health.SetMaxAge(*cfg.HealthMaxAge)
+		} else {
+			health.SetMaxAge(3 * max(settings.PollInterval(), cfg.IdleInterval))
+		}
+	}
+	updateHealthMaxAge()
+	latestFix := &LatestFix{}
+	var sseBroker *SSEBroker
+	if cfg.HTTPListenAddr != "" {
+		sseBroker = NewSSEBroker()
+		mux := http.NewServeMux()
+		mux.Handle("GET /healthz", health)
+		mux.Handle("GET /gnss", latestFix)
+		mux.Handle("GET /events", sseBroker)
+		runHTTPServer(ctx, "HTTP", cfg.HTTPListenAddr, mux)
+	}
+
+	var queue *PersistentQueue
+	if cfg.QueueDir != "" {
+		if queue, err = OpenPersistentQueue(cfg.QueueDir, cfg.QueueMaxBytes, cfg.QueueMaxEntries); err != nil {
+			log.Fatalf("Failed to open publish queue: %v", err)
+		}
+		log.Printf("Publish queue in %s holds %d messages", cfg.QueueDir, queue.Len())
+	}
+
+	// Dry runs never connect, so skip loading certificates
+	var tlsConfig *tls.Config
+	if !cfg.DryRun && !cfg.WebhookOnly {
+		if tlsConfig, err = loadMQTTTLSConfig(cfg.MQTTCACert, cfg.MQTTClientCert, cfg.MQTTClientKey); err != nil {
+			log.Fatalf("MQTT TLS setup failed: %v", err)
+		}
 	}
 
 	opts := mqtt.NewClientOptions()
-	opts.AddBroker(fmt.Sprintf("ssl://%s:%s", mqttBrokerURL, mqttBrokerPort))
-	opts.SetUsername(mqttUsername)
-	opts.SetPassword(mqttPassword)
-	opts.SetTLSConfig(&tls.Config{RootCAs: rootCAs})
+	opts.AddBroker(fmt.Sprintf("ssl://%s:%s", cfg.MQTTBrokerURL, cfg.MQTTBrokerPort))
+	opts.SetClientID(cfg.MQTTClientID)
+	opts.SetCleanSession(cfg.MQTTCleanSession)
+	opts.SetUsername(cfg.MQTTUsername)
+	opts.SetPassword(cfg.MQTTPassword)
+	opts.SetTLSConfig(tlsConfig)
+	// Keep retrying the initial connection and reconnect after a drop, backing off
+	// exponentially from one second up to the configured maximum
+	opts.SetAutoReconnect(true)
+	opts.SetConnectRetry(true)
+	opts.SetConnectRetryInterval(time.Second)
+	opts.SetMaxReconnectInterval(cfg.MQTTMaxReconnect)
+	opts.SetWriteTimeout(mqttPublishTimeout)
+	opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		log.Printf("MQTT connection lost: %v", err)
+	})
+	opts.SetReconnectingHandler(func(mqtt.Client, *mqtt.ClientOptions) {
+		log.Println("Reconnecting to MQTT broker...")
+	})
+	// The broker publishes the retained "offline" will if the connection drops without a
+	// clean disconnect; "online" replaces it on every connect
+	statusTopic := fmt.Sprintf("%s/status", cfg.DeviceTopic)
+	opts.SetWill(statusTopic, StatusOffline, 1, true)
+	// Signal the main loop on every (re)connect so it can drain the publish queue
+	connected := make(chan struct{}, 1)
+	opts.SetOnConnectHandler(func(c mqtt.Client) {
+		if !cfg.WebhookOnly {
+			log.Println("Connected to MQTT broker")
+		}
+		if grace == nil || grace.Done() {
+			if err := publishRetained(c, statusTopic, []byte(StatusOnline)); err != nil {
+				log.Printf("Failed to publish online status: %v", err)
+			}
+		}
+		if cfg.MQTTCommands {
+			// Subscriptions don't survive a clean session, so renew on every connect
+			c.Subscribe(commandTopic, 1, handleCommand)
+		}
+		select {
+		case connected <- struct{}{}:
+		default:
+		}
+	})
 
-	client := mqtt.NewClient(opts)
-	if token := client.Connect(); token.Wait() && token.Error() != nil {
-		log.Fatalf("MQTT connection error: %v", token.Error())
+	var client mqtt.Client
+	switch {
+	case cfg.DryRun:
+		log.Println("Dry run: printing payloads to stdout instead of publishing to MQTT")
+		client = NewStdoutClient(opts, os.Stdout)
+	case cfg.WebhookOnly:
+		// Status, events and the other MQTT-only topics have nowhere to go
+		log.Println("MQTT_BROKER_URL is unset, publishing fixes to the webhook only")
+		client = NewStdoutClient(opts, io.Discard)
+	default:
+		client = mqtt.NewClient(opts)
+	}
+	// With connect retry the token only completes once connected, so also watch for shutdown
+	token := client.Connect()
+	select {
+	case <-token.Done():
+		if token.Error() != nil {
+			log.Fatalf("MQTT connection error: %v", token.Error())
+		}
+	case <-ctx.Done():
+		client.Disconnect(0)
+		log.Println("Shut down before connecting to MQTT broker")
+		return
+	}
+	var mqttPublisher Publisher = &MQTTPublisher{Client: client, QoS: cfg.MQTTQoS, Retain: cfg.MQTTRetain}
+	if cfg.MQTTProtocol == MQTTProtocol5 {
+		// Fix payloads move to their own MQTT 5 connection; status, events and discovery stay on 3.1.1
+		conn, err := ConnectMQTT5(ctx, cfg, tlsConfig)
+		if err != nil {
+			if ctx.Err() != nil {
+				client.Disconnect(0)
+				log.Println("Shut down before connecting to MQTT 5 broker")
+				return
+			}
+			log.Fatalf("MQTT 5 connection error: %v", err)
+		}
+		defer conn.Disconnect(context.Background())
+		mqttPublisher = &MQTT5Publisher{
+			Conn:           conn,
+			QoS:            cfg.MQTTQoS,
+			Retain:         cfg.MQTTRetain,
+			ContentType:    encoder.ContentType(),
+			UserProperties: cfg.MQTTUserProps,
+		}
+	}
+	log.Println(versionString())
+
+	if cfg.HADiscovery {
+		if err := publishHomeAssistantDiscovery(client, cfg.DeviceTopic, cfg.DeviceID, cfg.SpeedUnit); err != nil {
+			log.Printf("Failed to publish Home Assistant discovery: %v", err)
+		} else {
+			log.Println("Published Home Assistant discovery configs")
+		}
 	}
-	log.Println("Connected to MQTT broker")
 
-	gnss := GNSSDbus{}
+	gnss := NewGNSSDbus()
+	gnss.Bus = cfg.DbusBus
+	gnss.Address = cfg.DbusAddress
+	gnss.Dest = cfg.GnssDbusDest
+	gnss.Path = cfg.GnssDbusPath
+	gnss.Method = cfg.GnssDbusMethod
+	gnss.RangeCheck = cfg.RangeCheck
+	var reader GnssReader = dbusGnssReader{gnss: gnss, timeout: cfg.DbusCallTimeout}
+	if cfg.DbusRetryAttempts > 1 {
+		reader = &RetryingGnssReader{
+			Reader:    reader,
+			Attempts:  cfg.DbusRetryAttempts,
+			BaseDelay: cfg.DbusRetryDelay,
+		}
+	}
 
-	if err := gnss.Connect(); err != nil {
+	// When replaying a recording, fixes come from the file instead of D-Bus
+	var replayCh chan *GnssFullData
+	if cfg.ReplayPath != "" {
+		replayCh = make(chan *GnssFullData)
+		go func() {
+			defer close(replayCh)
+			log.Printf("Replaying %s at %gx speed", cfg.ReplayPath, cfg.ReplaySpeed)
+			if err := Replay(ctx, cfg.ReplayPath, cfg.ReplaySpeed, replayCh); err != nil && ctx.Err() == nil {
+				log.Printf("Replay failed: %v", err)
+			}
+		}()
+	} else if err := gnss.Connect(); err != nil {
 		log.Fatalf("Failed to connect to D-Bus: %v", err)
 	}
 
+	// With signals enabled the modem pushes fixes and polling only resumes when they stop arriving
+	var signalCh <-chan *GnssFullData
+	var lastSignal time.Time
+	if cfg.GnssSignals && replayCh == nil {
+		if signalCh, err = gnss.Subscribe(); err != nil {
+			log.Fatalf("Failed to subscribe to GNSS signals: %v", err)
+		}
+		log.Printf("Subscribed to GNSS signals, polling after %s without one", cfg.GnssSignalTimeout)
+	}
+
+	var gpxWriter *GPXWriter
+	if cfg.GPXOutputDir != "" {
+		if gpxWriter, err = NewGPXWriter(cfg.GPXOutputDir, cfg.GPXMaxBytes); err != nil {
+			log.Fatalf("Environment setup failed: GPX_OUTPUT_DIR: %v", err)
+		}
+		defer gpxWriter.Close()
+		log.Printf("Writing GPX tracks to %s", cfg.GPXOutputDir)
+	}
+
+	var recorder *Recorder
+	if cfg.RecordPath != "" {
+		if recorder, err = NewRecorder(cfg.RecordPath); err != nil {
+			log.Fatalf("Failed to start recording: %v", err)
+		}
+		defer recorder.Close()
+		log.Printf("Recording fixes to %s", cfg.RecordPath)
+	}
+
+	publishEvent := func(ev DebouncedEvent) {
+		if err := publishJSON(client, ev.Topic, ev.Payload); err != nil {
+			log.Printf("Failed to publish %s event: %v", ev.Key, err)
+			health.RecordError(err)
+		} else {
+			log.Printf("Published %s event: %s", ev.Key, ev.State)
+		}
+	}
+	// emitEvent publishes ev immediately unless it repeats the source's current state or the
+	// debouncer holds it back
+	eventStates := newEventState()
+	emitEvent := func(ev DebouncedEvent) {
+		if !eventStates.transition(ev.Key, ev.State) {
+			return
+		}
+		if debouncer == nil || debouncer.Offer(ev, clock.Now()) {
+			publishEvent(ev)
+		}
+	}
+
+	// drainQueue republishes the messages queued for sink oldest first. Each sink is drained
+	// once it's reachable again, so an outage of one doesn't hold up the other's backlog.
+	drainQueue := func(sink string) {
+		if queue == nil || queue.Len() == 0 {
+			return
+		}
+		sent, err := queue.Drain(sink, func(msg QueuedMessage) error {
+			if sink == MQTTSink {
+				return mqttPublisher.Publish(ctx, msg.Topic, msg.Payload, nil)
+			}
+			if webhook == nil {
+				log.Println("Dropping queued webhook message, WEBHOOK_URL is unset")
+				return nil
+			}
+			return webhook.Publish(ctx, msg.Topic, msg.Payload, nil)
+		})
+		if sent > 0 {
+			log.Printf("Published %d queued messages, %d remaining", sent, queue.Len())
+		}
+		if err != nil {
+			log.Printf("Failed to drain publish queue: %v", err)
+		}
+	}
+	if webhook == nil {
+		// Nothing else drains messages queued for a webhook that's since been unset
+		drainQueue(WebhookSink)
+	}
+
+	// payloadTopic returns the topic to publish an encoded payload to. Compressed payloads are
+	// flagged with a content-encoding property, which only MQTT 5 can carry, so under MQTT 3.1.1
+	// they go to a /gz subtopic instead.
+	payloadTopic := func(topic string, properties map[string]string) string {
+		if encoder.Compression == "" {
+			return topic
+		}
+		properties["content-encoding"] = encoder.Compression
+		if cfg.MQTTProtocol != MQTTProtocol5 {
+			topic += GzipTopicSuffix
+		}
+		return topic
+	}
+	var lastSatellites time.Time
+	publishFix := func(ctx context.Context, data *GnssData) {
+		ctx, span := tracer.Start(ctx, "gnss.publish", trace.WithAttributes(fixAttributes(data)...))
+		defer span.End()
+		if ok, reason := gate.Allow(data, clock.Now()); !ok {
+			log.Printf("Suppressed GNSS publish: %s", reason)
+			span.SetAttributes(attribute.String("gnss.suppressed", reason))
+			return
+		}
+		if validator != nil {
+			raw, err := json.Marshal(data)
+			if err == nil {
+				err = validator.Validate(raw)
+			}
+			if err != nil {
+				log.Printf("GNSS payload does not match schema: %v", err)
+				if cfg.ValidateSchema == SchemaModeDrop {
+					metrics.PublishFailures.Inc()
+					return
+				}
+			}
+		}
+		if sseBroker != nil || cfg.LatestFixPath != "" {
+			raw, err := json.Marshal(data)
+			if err != nil {
+				log.Printf("Failed to marshal GNSS data: %v", err)
+			} else {
+				if sseBroker != nil {
+					sseBroker.Broadcast(raw)
+				}
+				// Readers polling the file only ever see a complete fix thanks to the atomic replace
+				if cfg.LatestFixPath != "" {
+					if err := writeFileAtomic(cfg.LatestFixPath, raw, 0o644); err != nil {
+						log.Printf("Failed to write latest fix: %v", err)
+					}
+				}
+			}
+		}
+		encode := encoder.Encode
+		if cfg.PayloadSplit {
+			encode = encoder.EncodePosition
+		}
+		payload, err := encode(data)
+		if err != nil {
+			log.Printf("Failed to marshal GNSS data: %v", err)
+			health.RecordPublish(err)
+			metrics.PublishFailures.Inc()
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "encode failed")
+			return
+		}
+		properties := map[string]string{"valid": strconv.FormatBool(data.Valid != 0)}
+		topic := payloadTopic(fmt.Sprintf("%s/gnss", cfg.DeviceTopic), properties)
+		for _, sink := range sinks {
+			if err := sink.Publish(ctx, topic, payload, properties); err != nil {
+				log.Printf("Failed to publish GNSS data to %T: %v", sink, err)
+				health.RecordError(err)
+			}
+		}
+		if webhook != nil {
+			err := webhook.Publish(ctx, topic, payload, properties)
+			if err != nil {
+				log.Printf("Failed to publish GNSS data to webhook: %v", err)
+				if queue != nil {
+					if err := queue.Enqueue(QueuedMessage{Topic: topic, Payload: payload, Sink: WebhookSink}); err != nil {
+						log.Printf("Failed to queue GNSS data: %v", err)
+					}
+				}
+			} else {
+				// Catch up on anything queued while the endpoint was failing
+				drainQueue(WebhookSink)
+			}
+			if cfg.WebhookOnly {
+				health.RecordPublish(err)
+				if err != nil {
+					metrics.PublishFailures.Inc()
+				} else {
+					metrics.PublishSuccesses.Inc()
+				}
+			} else if err != nil {
+				health.RecordError(err)
+			}
+		}
+		if cfg.WebhookOnly {
+			// No broker to publish to
+		} else if err := mqttPublisher.Publish(ctx, topic, payload, properties); err != nil {
+			log.Printf("Failed to publish GNSS data: %v", err)
+			health.RecordPublish(err)
+			metrics.PublishFailures.Inc()
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "MQTT publish failed")
+			if queue != nil {
+				if err := queue.Enqueue(QueuedMessage{Topic: topic, Payload: payload}); err != nil {
+					log.Printf("Failed to queue GNSS data: %v", err)
+				}
+			}
+		} else {
+			metrics.PublishSuccesses.Inc()
+			health.RecordPublish(nil)
+			log.Printf("Published full GNSS data to MQTT %s", time.Now().UTC())
+		}
+		if cfg.PayloadSplit && clock.Now().Sub(lastSatellites) >= cfg.SatellitesInterval {
+			payload, err := encoder.EncodeSatellites(data)
+			if err == nil {
+				properties := make(map[string]string)
+				topic := payloadTopic(fmt.Sprintf("%s/gnss/satellites", cfg.DeviceTopic), properties)
+				err = mqttPublisher.Publish(ctx, topic, payload, properties)
+			}
+			if err != nil {
+				log.Printf("Failed to publish satellite data: %v", err)
+				health.RecordError(err)
+			} else {
+				lastSatellites = clock.Now()
+			}
+		}
+	}
+
+	ntripEnabled := cfg.NTRIPAddress != ""
+	if ntripEnabled {
+		rtcmMethod := cfg.GnssDbusRTCMMethod
+		if rtcmMethod == "" {
+			rtcmMethod = gnss.Interface() + ".InjectRtcm"
+		}
+		dialer := &net.Dialer{Timeout: 10 * time.Second}
+		ntrip := &NTRIPClient{
+			Address:    cfg.NTRIPAddress,
+			Mountpoint: cfg.NTRIPMountpoint,
+			Username:   cfg.NTRIPUsername,
+			Password:   cfg.NTRIPPassword,
+			Sink: RTCMSinkFunc(func(ctx context.Context, frame []byte) error {
+				// Bounded like reads, so a stuck modem can't stall the correction stream
+				ctx, cancel := context.WithTimeout(ctx, cfg.DbusCallTimeout)
+				defer cancel()
+				return gnss.InjectRTCM(ctx, rtcmMethod, frame)
+			}),
+			Dial: dialer.DialContext,
+		}
+		go ntrip.Run(ctx)
+	}
+
+	// recordFix appends a fix read from the modem to the recording, if enabled
+	recordFix := func(fullData *GnssFullData) {
+		if recorder == nil {
+			return
+		}
+		if err := recorder.Record(clock.Now(), fullData); err != nil {
+			log.Printf("Failed to record GNSS data: %v", err)
+		}
+	}
+
+	var lkg *LastKnownGood
+	if cfg.RepublishStale {
+		lkg = NewLastKnownGood(cfg.StaleMaxAge)
+	}
+	// publishStale republishes the last known good fix in place of a failed read or invalid
+	// fix, reporting whether there was one recent enough
+	publishStale := func(ctx context.Context) bool {
+		if lkg == nil {
+			return false
+		}
+		stale, ok := lkg.Stale(clock.Now())
+		if ok {
+			log.Printf("Republishing last known good fix from %.0fs ago", stale.AgeSeconds)
+			publishFix(ctx, stale)
+		}
+		return ok
+	}
+
+	var lastGeohash string
+
+	// handleFix derives, gates and publishes everything produced by a single fix
+	handleFix := func(ctx context.Context, fullData *GnssFullData) {
+		data := fullData.ToGnssData(cfg.KeepSatellitePadding)
+		data.DeviceID = cfg.DeviceID
+		data.Speed, _ = convertSpeed(data.Speed, cfg.SpeedUnit)
+		data.SpeedUnit = cfg.SpeedUnit
+		annotateFix(ctx, &data)
+		validFix := fullData.HasValidFix()
+		if grace != nil {
+			grace.ObserveFix(validFix)
+		}
+		if cfg.SanityBox != nil && data.Valid != 0 && !cfg.SanityBox.Contains(data.Latitude, data.Longitude) {
+			log.Printf("Dropped fix at %f,%f outside SANITY_BBOX as a glitch", data.Latitude, data.Longitude)
+			return
+		}
+		if outlierFilter != nil && validFix {
+			// Prefer the modem's own timestamps so a delayed read doesn't shrink the implied speed
+			at, err := fullData.Utc.Time()
+			if err != nil && fullData.LastLockTimeMs > 0 {
+				at, err = time.UnixMilli(int64(fullData.LastLockTimeMs)), nil
+			}
+			if err != nil {
+				at = clock.Now()
+			}
+			if ok, speed := outlierFilter.Accept(data.Latitude, data.Longitude, at); !ok {
+				log.Printf("Dropped fix at %f,%f implying %.0f m/s as an outlier", data.Latitude, data.Longitude, speed)
+				return
+			}
+		}
+		if motion != nil && validFix && motion.Update(gate.IsMoving(&data), clock.Now()) {
+			if motion.Moving() {
+				log.Printf("Device moving, polling every %s", currentPollInterval())
+			} else {
+				log.Printf("Device stationary, polling every %s", currentPollInterval())
+			}
+			select {
+			case intervalChanged <- struct{}{}:
+			default:
+			}
+		}
+		metrics.ObserveFix(&data, validFix)
+		health.RecordSatellites(data.SatellitesInView())
+		if data.Valid != 0 {
+			health.RecordFix()
+		}
+		if cfg.SNRHistogramInterval > 0 && clock.Now().Sub(lastSNRHistogram) >= cfg.SNRHistogramInterval {
+			lastSNRHistogram = clock.Now()
+			histogram := NewSNRHistogram(data.Satellites(), lastSNRHistogram)
+			if err := publishJSON(client, fmt.Sprintf("%s/snr_histogram", cfg.DeviceTopic), histogram); err != nil {
+				log.Printf("Failed to publish SNR histogram: %v", err)
+				health.RecordError(err)
+			}
+		}
+		if fixTracker != nil {
+			if event := fixTracker.Update(&data, clock.Now()); event != nil {
+				emitEvent(DebouncedEvent{
+					Topic:   fmt.Sprintf("%s/events/%s", cfg.DeviceTopic, event.Event),
+					Key:     "fix",
+					State:   event.Event,
+					Payload: event,
+				})
+			}
+		}
+		if smoothing != nil && validFix {
+			rawLat, rawLon, rawAlt := data.Latitude, data.Longitude, data.Altitude
+			data.RawLatitude, data.RawLongitude, data.RawAltitude = &rawLat, &rawLon, &rawAlt
+		}
+		if medianFilter != nil && validFix {
+			data.Latitude, data.Longitude = medianFilter.Apply(data.Latitude, data.Longitude)
+		}
+		if smoothing != nil && validFix {
+			data.Latitude, data.Longitude, data.Altitude = smoothing.Apply(data.Latitude, data.Longitude, data.Altitude, data.Hdop)
+		}
+		if cfg.LeverArm != nil && data.Valid != 0 {
+			// Until the device has moved there's no heading to rotate the offset by
+			if heading, ok := headingTracker.Update(data.Latitude, data.Longitude); ok {
+				data.Latitude, data.Longitude = cfg.LeverArm.Apply(data.Latitude, data.Longitude, heading)
+			}
+		}
+		// Signal quality is reported with or without a fix, for antenna placement
+		data.ApplySNRStats(cfg.UsableSNR)
+		if cfg.SatelliteMinSNR > 0 {
+			// After the SNR stats, which still cover every satellite the modem reported
+			data.DropWeakSatellites(cfg.SatelliteMinSNR)
+		}
+		if validFix {
+			if accuracy := EstimateAccuracyMeters(data.Hdop, cfg.UEREMeters); accuracy > 0 {
+				data.AccuracyM = &accuracy
+			}
+			if heading, ok := courseTracker.Measure(data.Latitude, data.Longitude); ok {
+				data.HeadingDeg = &heading
+			}
+		}
+		if odometer != nil && validFix {
+			at, err := fullData.Utc.Time()
+			if err != nil {
+				at = clock.Now()
+			}
+			if odometer.Add(data.Latitude, data.Longitude, at) {
+				log.Printf("Odometer ignored an implausible jump to %.6f,%.6f", data.Latitude, data.Longitude)
+			}
+			if err := odometer.SaveIfDue(clock.Now()); err != nil {
+				log.Printf("Failed to save odometer: %v", err)
+				health.RecordError(err)
+			}
+		}
+		if verticalSpeed != nil && validFix {
+			// Prefer the modem's time so replayed recordings give the same rates
+			at, err := fullData.Utc.Time()
+			if err != nil {
+				at = clock.Now()
+			}
+			if rate, ok := verticalSpeed.Update(data.Altitude, at); ok {
+				data.VerticalSpeedMs = &rate
+			}
+		}
+		if cfg.RefPoint != nil && data.Valid != 0 {
+			east, north := ENUOffset(cfg.RefPoint[0], cfg.RefPoint[1], data.Latitude, data.Longitude)
+			distance := math.Hypot(east, north)
+			data.OffsetNorthM, data.OffsetEastM, data.OffsetDistanceM = &north, &east, &distance
+		}
+		if zoneTracker != nil && validFix {
+			var events []ZoneEvent
+			data.Zones, events = zoneTracker.Update(data.Latitude, data.Longitude)
+			for _, event := range events {
+				emitEvent(DebouncedEvent{
+					Topic:   fmt.Sprintf("%s/events/zone", cfg.DeviceTopic),
+					Key:     "zone:" + event.Zone,
+					State:   event.Event,
+					Payload: event,
+				})
+			}
+		}
+		if geofence != nil && validFix {
+			if event, ok := geofence.Update(data.Latitude, data.Longitude); ok {
+				emitEvent(DebouncedEvent{
+					Topic:   fmt.Sprintf("%s/geofence", cfg.DeviceTopic),
+					Key:     "geofence",
+					State:   event.Event,
+					Payload: event,
+				})
+			}
+		}
+		if cfg.CoordFormats[CoordFormatDMS] && data.Valid != 0 {
+			data.LatDMS = FormatDMS(data.Latitude, 'N', 'S')
+			data.LonDMS = FormatDMS(data.Longitude, 'E', 'W')
+		}
+		if cfg.CoordFormats[CoordFormatUTM] && data.Valid != 0 {
+			// Left out near the poles, where UTM isn't defined
+			if zone, hemisphere, easting, northing, err := ToUTM(data.Latitude, data.Longitude); err == nil {
+				data.UTMZone, data.UTMHemisphere = &zone, hemisphere
+				data.UTMEasting, data.UTMNorthing = &easting, &northing
+			}
+		}
+		if cfg.GeohashPrecision > 0 && validFix {
+			data.Geohash = Geohash(data.Latitude, data.Longitude, cfg.GeohashPrecision)
+			if data.Geohash != lastGeohash {
+				emitEvent(DebouncedEvent{
+					Topic: fmt.Sprintf("%s/events/geohash", cfg.DeviceTopic),
+					Key:   "geohash",
+					State: data.Geohash,
+					Payload: GeohashEvent{
+						Previous:  lastGeohash,
+						Geohash:   data.Geohash,
+						Latitude:  data.Latitude,
+						Longitude: data.Longitude,
+					},
+				})
+				lastGeohash = data.Geohash
+			}
+		}
+		if cfg.IncludeConfidence {
+			confidence := data.ConfidenceScore()
+			data.Confidence = &confidence
+		}
+		if driftMonitor != nil && data.Valid != 0 {
+			if offset, event, ok := driftMonitor.Check(data.Utc, clock.Now()); ok {
+				offsetMs := offset.Milliseconds()
+				data.ClockOffsetMs = &offsetMs
+				if event != nil {
+					if event.Event == "drift" {
+						log.Printf("Warning: host clock is %d ms off GNSS time, threshold %d ms", event.OffsetMs, event.ThresholdMs)
+					}
+					emitEvent(DebouncedEvent{
+						Topic:   fmt.Sprintf("%s/events/clock_drift", cfg.DeviceTopic),
+						Key:     "clock_drift",
+						State:   event.Event,
+						Payload: event,
+					})
+				}
+			}
+		}
+		if networkReader != nil {
+			// Bounded like GNSS reads, as this runs inside the poll loop
+			mmCtx, cancel := context.WithTimeout(ctx, cfg.DbusCallTimeout)
+			networkType, err := networkReader.NetworkType(mmCtx)
+			cancel()
+			if err != nil && !networkErrorLogged {
+				// Keep publishing without it; ModemManager may not be running on every device
+				log.Printf("Failed to read network type, omitting it until available: %v", err)
+				networkErrorLogged = true
+			} else if err == nil {
+				networkErrorLogged = false
+			}
+			data.NetworkType = networkType
+		}
+		if cfg.IncludeStreaks {
+			readStreak, publishStreak := health.Streaks()
+			data.ReadStreak, data.PublishStreak = &readStreak, &publishStreak
+		}
+		if ntripEnabled {
+			data.FixType = FixTypeFromQuality(data.Gpssta)
+		}
+		if addressCache != nil && data.Valid != 0 {
+			data.Address = addressCache.Lookup(ctx, data.Latitude, data.Longitude)
+		}
+		if cfg.DisplayStatusPath != "" {
+			status := data.DisplayStatus(cfg.DisplayWidth) + "\n"
+			if err := writeFileAtomic(cfg.DisplayStatusPath, []byte(status), 0o644); err != nil {
+				log.Printf("Failed to write display status: %v", err)
+			}
+		}
+		if rollup != nil && data.Valid != 0 {
+			rollup.Add(&data)
+		}
+		latestFix.Set(&data)
+		if gpxWriter != nil && validFix {
+			if err := gpxWriter.Append(&data, clock.Now()); err != nil {
+				log.Printf("Failed to write GPX track point: %v", err)
+			}
+		}
+		if pgSink != nil && validFix {
+			pgSink.Add(&data, clock.Now())
+		}
+		if lkg != nil && validFix {
+			lkg.Store(&data, clock.Now())
+		}
+		if !validFix && publishStale(ctx) {
+			return
+		}
+		if !validFix && !settings.PublishInvalid() {
+			log.Println("Skipped publishing GNSS data without a valid fix")
+			return
+		}
+		if distanceSampler != nil {
+			// Distance sampling replaces the per-tick publish; invalid fixes carry no usable position
+			if data.Valid == 0 {
+				return
+			}
+			for _, record := range distanceSampler.Add(data) {
+				publishFix(ctx, &record)
+			}
+			return
+		}
+		publishFix(ctx, &data)
+	}
+
+	started := clock.Now()
+	sinkNames := []string{"mqtt"}
+	if cfg.MQTTProtocol == MQTTProtocol5 {
+		sinkNames[0] = "mqtt5"
+	}
+	if cfg.WebhookOnly {
+		sinkNames = nil
+	}
+	for _, sink := range sinks {
+		switch sink := sink.(type) {
+		case *AzureIoTPublisher:
+			sinkNames = append(sinkNames, "azure_iot")
+		case *StdoutPublisher:
+			sinkNames = append(sinkNames, sink.Name)
+		}
+	}
+	if webhook != nil {
+		sinkNames = append(sinkNames, WebhookSink)
+	}
+	features := map[string]bool{
+		"schema_validation":        validator != nil,
+		"zones":                    zoneTracker != nil,
+		"geofence":                 geofence != nil,
+		"sanity_bbox":              cfg.SanityBox != nil,
+		"outlier_filter":           outlierFilter != nil,
+		"median_filter":            medianFilter != nil,
+		"smoothing":                smoothing != nil,
+		"reference_offset":         cfg.RefPoint != nil,
+		"fix_events":               fixTracker != nil,
+		"snr_histogram":            cfg.SNRHistogramInterval > 0,
+		"ha_discovery":             cfg.HADiscovery,
+		"lever_arm":                cfg.LeverArm != nil,
+		"vertical_speed":           verticalSpeed != nil,
+		"odometer":                 odometer != nil,
+		"distance_sampling":        distanceSampler != nil,
+		"event_debounce":           debouncer != nil,
+		"geohash":                  cfg.GeohashPrecision > 0,
+		"dms":                      cfg.CoordFormats[CoordFormatDMS],
+		"utm":                      cfg.CoordFormats[CoordFormatUTM],
+		"confidence":               cfg.IncludeConfidence,
+		"streaks":                  cfg.IncludeStreaks,
+		"network_type":             networkReader != nil,
+		"clock_drift":              driftMonitor != nil,
+		"daily_rollup":             rollup != nil,
+		"geocoding":                addressCache != nil,
+		"publish_windows":          len(gate.Windows) > 0,
+		"publish_only_when_moving": gate.RequireMoving,
+		"adaptive_polling":         motion != nil,
+		"min_move":                 gate.MinMoveMeters > 0,
+		"remote_write":             remoteWriter != nil,
+		"postgres":                 pgSink != nil,
+		"gpx":                      gpxWriter != nil,
+		"health_status":            cfg.HealthPublishInterval > 0,
+		"http":                     cfg.HTTPListenAddr != "",
+		"queue":                    queue != nil,
+		"replay":                   replayCh != nil,
+		"recording":                recorder != nil,
+		"ntrip":                    ntripEnabled,
+		"dbus_signals":             signalCh != nil,
+		"display_status":           cfg.DisplayStatusPath != "",
+		"latest_fix_file":          cfg.LatestFixPath != "",
+		"crc":                      encoder.CRC,
+		"payload_split":            cfg.PayloadSplit,
+		"compression":              encoder.Compression != "",
+		"encryption":               encoder.EncKey != nil,
+		"rounding":                 len(encoder.Rounding) > 0,
+		"startup_grace":            grace != nil,
+		"dry_run":                  cfg.DryRun,
+		"republish_stale":          lkg != nil,
+		"commands":                 cfg.MQTTCommands,
+	}
+	birthTopic := fmt.Sprintf("%s/birth", cfg.DeviceTopic)
+	publishBirth := func() {
+		if !cfg.BirthMessage {
+			return
+		}
+		birth := NewBirthMessage(cfg.DeviceID, started, clock.Now(), settings.PollInterval(), encoder.Format, sinkNames, features)
+		if err := publishRetainedJSON(client, birthTopic, birth); err != nil {
+			log.Printf("Failed to publish birth message: %v", err)
+			health.RecordError(err)
+		} else {
+			log.Printf("Published birth message to %s", birthTopic)
+		}
+	}
+	if grace == nil {
+		publishBirth()
+	}
+
+	// Periodic bridge health, separate from the fix payloads
+	var healthTick <-chan time.Time
+	if cfg.HealthPublishInterval > 0 {
+		healthTicker := time.NewTicker(cfg.HealthPublishInterval)
+		defer healthTicker.Stop()
+		healthTick = healthTicker.C
+	}
+	healthTopic := fmt.Sprintf("%s/health", cfg.DeviceTopic)
+
+	// reloadConfig applies the hot-reloadable settings from CONFIG_FILE on SIGHUP, logging what
+	// changed, and reports whether the poll interval did
+	reloadConfig := func() bool {
+		next, changed, restart, err := ReloadConfig()
+		for _, key := range restart {
+			log.Printf("Config reload: %s changed but requires restart, ignoring", key)
+		}
+		if err != nil {
+			log.Printf("Config reload failed, keeping current settings: %v", err)
+			return false
+		}
+		// Settings the file didn't change keep their current value, which may have been set
+		// through the command topic
+		pollInterval, publishInvalid := settings.PollInterval(), settings.PublishInvalid()
+		var changes []string
+		if slices.Contains(changed, "POLL_INTERVAL_SECONDS") && next.PollInterval != pollInterval {
+			changes = append(changes, fmt.Sprintf("poll interval %s -> %s", pollInterval, next.PollInterval))
+			pollInterval = next.PollInterval
+		}
+		if slices.Contains(changed, "PUBLISH_INVALID_FIXES") && next.PublishInvalid != publishInvalid {
+			changes = append(changes, fmt.Sprintf("publish invalid fixes %t -> %t", publishInvalid, next.PublishInvalid))
+			publishInvalid = next.PublishInvalid
+		}
+		if slices.Contains(changed, "MOVING_SPEED_THRESHOLD") && next.MovingThreshold != gate.MovingThreshold {
+			changes = append(changes, fmt.Sprintf("moving speed threshold %g -> %g", gate.MovingThreshold, next.MovingThreshold))
+			gate.MovingThreshold = next.MovingThreshold
+		}
+		if slices.Contains(changed, "MIN_MOVE_METERS") && next.MinMoveMeters != gate.MinMoveMeters {
+			changes = append(changes, fmt.Sprintf("minimum move %g m -> %g m", gate.MinMoveMeters, next.MinMoveMeters))
+			gate.MinMoveMeters = next.MinMoveMeters
+		}
+		if slices.Contains(changed, "HEARTBEAT_SECONDS") && next.Heartbeat != gate.Heartbeat {
+			changes = append(changes, fmt.Sprintf("heartbeat %s -> %s", gate.Heartbeat, next.Heartbeat))
+			gate.Heartbeat = next.Heartbeat
+		}
+		if slices.Contains(changed, "EVENT_MIN_INTERVAL_SECONDS") && next.EventMinInterval != eventMinInterval {
+			changes = append(changes, fmt.Sprintf("event minimum interval %s -> %s", eventMinInterval, next.EventMinInterval))
+			eventMinInterval = next.EventMinInterval
+			switch {
+			case eventMinInterval == 0:
+				// Release the events held back before dropping the debouncer
+				debouncer.SetMinInterval(0)
+				for _, ev := range debouncer.Due(clock.Now()) {
+					publishEvent(ev)
+				}
+				debouncer = nil
+			case debouncer == nil:
+				debouncer = NewEventDebouncer(eventMinInterval)
+			default:
+				debouncer.SetMinInterval(eventMinInterval)
+			}
+		}
+		if len(changes) == 0 {
+			log.Println("Config reloaded, no changes")
+			return false
+		}
+		log.Printf("Config reloaded: %s", strings.Join(changes, ", "))
+		return settings.Set(pollInterval, publishInvalid)
+	}
+
+	// poll reads and handles a single fix, traced as one span with the read and publish as
+	// children
+	waitingForService := false
+	poll := func(ctx context.Context) {
+		ctx, span := tracer.Start(ctx, "gnss.poll")
+		defer span.End()
+		readCtx, readSpan := tracer.Start(ctx, "gnss.read")
+		fullData, err := reader.ReadGnss(readCtx)
+		if err != nil {
+			readSpan.RecordError(err)
+			readSpan.SetStatus(codes.Error, "read failed")
+		}
+		readSpan.End()
+		if ctx.Err() != nil {
+			return // Shutting down; the next select returns
+		}
+		health.RecordRead(err)
+		if errors.Is(err, ErrGnssServiceUnavailable) {
+			// Expected at boot, so log once rather than every tick
+			if !waitingForService {
+				log.Printf("Waiting for GNSS service %s to register on D-Bus", gnss.Dest)
+				waitingForService = true
+			}
+			publishStale(ctx)
+			return
+		}
+		if waitingForService {
+			log.Printf("GNSS service %s is available", gnss.Dest)
+			waitingForService = false
+		}
+		if err != nil {
+			log.Printf("Failed to get GNSS data: %v", err)
+			publishStale(ctx)
+			return
+		}
+		if fullData == nil {
+			return
+		}
+		recordFix(fullData)
+		handleFix(ctx, fullData)
+	}
+
 	// Main processing loop with graceful shutdown support
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(cfg.PollInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			log.Println("Shutting down gracefully...")
+			if odometer != nil {
+				if err := odometer.Save(clock.Now()); err != nil {
+					log.Printf("Failed to save odometer: %v", err)
+				}
+			}
+			if err := publishRetained(client, statusTopic, []byte(StatusOffline)); err != nil {
+				log.Printf("Failed to publish offline status: %v", err)
+			}
 			client.Disconnect(250) // Wait up to 250ms for clean disconnect
 			return
-		case <-ticker.C:
-			data, err := gnss.GetData()
-			if err != nil {
-				log.Printf("Failed to get GNSS data: %v", err)
+		case <-hupChan:
+			if reloadConfig() {
+				ticker.Reset(currentPollInterval())
+				updateHealthMaxAge()
+			}
+			if grace != nil && !grace.Done() {
+				log.Println("Received SIGHUP, birth message is still waiting for the startup grace period")
 				continue
 			}
-			if data != nil {
-				payload, err := json.Marshal(data)
-				if err != nil {
-					log.Printf("Failed to marshal GNSS data: %v", err)
-					continue
+			log.Println("Received SIGHUP, republishing birth message")
+			publishBirth()
+		case <-intervalChanged:
+			ticker.Reset(currentPollInterval())
+			updateHealthMaxAge()
+		case <-healthTick:
+			status := NewHealthStatus(health.Report(), client.IsConnectionOpen(), clock.Now())
+			if odometer != nil {
+				meters := odometer.Meters()
+				status.OdometerM = &meters
+			}
+			if err := publishJSON(client, healthTopic, status); err != nil {
+				log.Printf("Failed to publish health status: %v", err)
+			}
+		case <-connected:
+			drainQueue(MQTTSink)
+		case fullData, ok := <-replayCh:
+			if !ok {
+				log.Println("Replay complete")
+				cancel()
+				replayCh = nil
+				continue
+			}
+			fixCtx, span := tracer.Start(ctx, "gnss.replay")
+			handleFix(fixCtx, fullData)
+			span.End()
+		case fullData, ok := <-signalCh:
+			if !ok {
+				log.Println("GNSS signals stopped, falling back to polling")
+				signalCh = nil
+				continue
+			}
+			lastSignal = clock.Now()
+			health.RecordRead(nil)
+			recordFix(fullData)
+			fixCtx, span := tracer.Start(ctx, "gnss.signal")
+			handleFix(fixCtx, fullData)
+			span.End()
+		case <-ticker.C:
+			if grace != nil && grace.Complete(clock.Now()) {
+				log.Println("Startup grace period complete")
+				if err := publishRetained(client, statusTopic, []byte(StatusOnline)); err != nil {
+					log.Printf("Failed to publish online status: %v", err)
 				}
-				token := client.Publish(fmt.Sprintf("%s/gnss", mqttTopic), 0, false, payload)
-				token.Wait()
-				if token.Error() != nil {
-					log.Printf("Failed to publish GNSS data: %v", token.Error())
-				} else {
-					log.Printf("Published full GNSS data to MQTT %s", time.Now().UTC())
+				publishBirth()
+			}
+			if remoteWriter != nil {
+				remoteWriter.PushAsync(ctx, func(err error) {
+					log.Printf("Failed to push metrics: %v", err)
+					health.RecordError(err)
+				})
+			}
+			if debouncer != nil {
+				for _, ev := range debouncer.Due(clock.Now()) {
+					publishEvent(ev)
 				}
 			}
+			if rollup != nil {
+				if summary, ok := rollup.Due(); ok {
+					if err := publishJSON(client, fmt.Sprintf("%s/rollup/daily", cfg.DeviceTopic), summary); err != nil {
+						log.Printf("Failed to publish daily rollup: %v", err)
+						health.RecordError(err)
+					} else {
+						log.Printf("Published daily rollup for %s to %s", summary.PeriodStart, summary.PeriodEnd)
+					}
+				}
+			}
+			if replayCh != nil {
+				continue
+			}
+			if signalCh != nil && clock.Now().Sub(lastSignal) < cfg.GnssSignalTimeout {
+				continue
+			}
+			poll(ctx)
 		}
 	}
 }
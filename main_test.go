@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePollInterval(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"10", 10 * time.Second, false},
+		{"1", time.Second, false},
+		{"0.5", 500 * time.Millisecond, false},
+		{"300", 5 * time.Minute, false},
+		{"", 0, true},
+		{"ten", 0, true},
+		{"10s", 0, true},
+		{"0", 0, true},
+		{"-5", 0, true},
+		{"NaN", 0, true},
+		{"1e300", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePollInterval(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParsePollInterval(%q) = %s, %v, want %s, error %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MedianFilter replaces each position with the median latitude and longitude of the last
+// window fixes, so a single-fix spike is rejected while sustained movement passes through
+// with a lag of about half the window
+type MedianFilter struct {
+	window   int
+	lat, lon []float64 // Most recent positions, oldest first
+}
+
+// NewMedianFilter creates a filter over an odd window of at least 3 fixes
+func NewMedianFilter(window int) (*MedianFilter, error) {
+	if window < 3 || window%2 == 0 {
+		return nil, fmt.Errorf("window must be an odd number of at least 3, got %d", window)
+	}
+	return &MedianFilter{window: window}, nil
+}
+
+// Apply adds a position to the window and returns the filtered position. Until the window
+// has filled, positions pass through unchanged.
+func (f *MedianFilter) Apply(lat, lon float64) (float64, float64) {
+	f.lat = append(f.lat, lat)
+	f.lon = append(f.lon, lon)
+	if len(f.lat) > f.window {
+		f.lat = f.lat[1:]
+		f.lon = f.lon[1:]
+	}
+	if len(f.lat) < f.window {
+		return lat, lon
+	}
+	return median(f.lat), median(f.lon)
+}
+
+// median returns the middle value of an odd-length slice without modifying it
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/2]
+}
@@ -0,0 +1,70 @@
+package main
+
+import "testing"
+
+func TestMedianFilterRemovesSpike(t *testing.T) {
+	filter, err := NewMedianFilter(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A straight track heading north east, with a glitch at fix 10
+	const spike = 10
+	for i := range 20 {
+		lat, lon := 51+float64(i)*0.0001, -1+float64(i)*0.0001
+		inLat, inLon := lat, lon
+		if i == spike {
+			inLat, inLon = lat+0.05, lon-0.05 // About 6 km off the track
+		}
+		gotLat, gotLon := filter.Apply(inLat, inLon)
+		if i < 4 {
+			if gotLat != inLat || gotLon != inLon {
+				t.Errorf("fix %d = %v, %v before the window filled, want it unchanged", i, gotLat, gotLon)
+			}
+			continue
+		}
+		// Once full, the output lags the track by half the window
+		wantLat, wantLon := 51+float64(i-2)*0.0001, -1+float64(i-2)*0.0001
+		d := Haversine(gotLat, gotLon, wantLat, wantLon)
+		if d > 20 {
+			t.Errorf("fix %d filtered to %v, %v, %.0f m from the track", i, gotLat, gotLon, d)
+		}
+	}
+}
+
+func TestMedianFilterPreservesMotion(t *testing.T) {
+	filter, err := NewMedianFilter(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lat float64
+	for i := range 10 {
+		lat, _ = filter.Apply(float64(i), 0)
+	}
+	if lat != 8 {
+		t.Errorf("filtered latitude after a steady climb to 9 = %v, want 8", lat)
+	}
+}
+
+func TestNewMedianFilter(t *testing.T) {
+	tests := []struct {
+		window  int
+		wantErr bool
+	}{
+		{3, false}, {5, false}, {9, false}, {1, true}, {2, true}, {4, true}, {0, true}, {-3, true},
+	}
+	for _, tt := range tests {
+		if _, err := NewMedianFilter(tt.window); (err != nil) != tt.wantErr {
+			t.Errorf("NewMedianFilter(%d) error = %v, want error %t", tt.window, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMedian(t *testing.T) {
+	values := []float64{3, 1, 2, 9, -4}
+	if got := median(values); got != 2 {
+		t.Errorf("median(%v) = %v, want 2", values, got)
+	}
+	if values[0] != 3 || values[4] != -4 {
+		t.Errorf("median() reordered its input to %v", values)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the Prometheus gauges and counters describing GNSS and publishing health
+type Metrics struct {
+	Registry         *prometheus.Registry
+	SatellitesInView prometheus.Gauge
+	Hdop             prometheus.Gauge
+	FixValid         prometheus.Gauge
+	AltitudeMeters   prometheus.Gauge
+	Speed            prometheus.Gauge
+	PublishSuccesses prometheus.Counter
+	PublishFailures  prometheus.Counter
+}
+
+// NewMetrics creates the GNSS metrics and registers them on a dedicated registry
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		SatellitesInView: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "gnss_satellites_in_view",
+			Help: "Number of satellites in view across GPS, BeiDou, GLONASS and Galileo.",
+		}),
+		Hdop: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "gnss_hdop",
+			Help: "Horizontal dilution of precision of the last fix.",
+		}),
+		FixValid: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "gnss_fix_valid",
+			Help: "1 if the last fix had a usable position, 0 otherwise.",
+		}),
+		AltitudeMeters: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "gnss_altitude_meters",
+			Help: "Altitude above sea level of the last fix.",
+		}),
+		Speed: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "gnss_speed",
+			Help: "Ground speed of the last fix in SPEED_UNIT (km/h by default).",
+		}),
+		PublishSuccesses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "gnss_publish_success_total",
+			Help: "Number of GNSS payloads published successfully.",
+		}),
+		PublishFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "gnss_publish_failure_total",
+			Help: "Number of GNSS payloads that failed to marshal or publish.",
+		}),
+	}
+	m.Registry.MustRegister(
+		m.SatellitesInView, m.Hdop, m.FixValid, m.AltitudeMeters, m.Speed,
+		m.PublishSuccesses, m.PublishFailures,
+	)
+	return m
+}
+
+// ObserveFix updates the gauges from a freshly read fix; valid is the same check that
+// decides whether the fix is published, see GnssFullData.HasValidFix
+func (m *Metrics) ObserveFix(data *GnssData, valid bool) {
+	m.SatellitesInView.Set(float64(data.SatellitesInView()))
+	m.Hdop.Set(data.Hdop)
+	if valid {
+		m.FixValid.Set(1)
+	} else {
+		m.FixValid.Set(0)
+	}
+	m.AltitudeMeters.Set(data.Altitude)
+	m.Speed.Set(data.Speed)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})
+}
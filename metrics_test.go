@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// scrape serves /metrics from m and returns the exposition text
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d %s", rec.Code, rec.Body)
+	}
+	return rec.Body.String()
+}
+
+func TestMetricsScrape(t *testing.T) {
+	m := NewMetrics()
+	data := GnssData{Svnum: 9, BeidouSvnum: 4, GlonassSvnum: 3, Hdop: 0.9, Altitude: 35.2, Speed: 12.5}
+	m.ObserveFix(&data, true)
+	m.PublishSuccesses.Inc()
+	m.PublishSuccesses.Inc()
+	m.PublishFailures.Inc()
+
+	body := scrape(t, m)
+	for _, line := range []string{
+		"gnss_satellites_in_view 16",
+		"gnss_hdop 0.9",
+		"gnss_fix_valid 1",
+		"gnss_altitude_meters 35.2",
+		"gnss_speed 12.5",
+		"gnss_publish_success_total 2",
+		"gnss_publish_failure_total 1",
+		"# TYPE gnss_fix_valid gauge",
+		"# TYPE gnss_publish_success_total counter",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("scrape is missing %q:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "go_goroutines") {
+		t.Error("scrape includes the Go runtime collectors, want only the GNSS metrics")
+	}
+
+	m.ObserveFix(&data, false)
+	if body := scrape(t, m); !strings.Contains(body, "gnss_fix_valid 0\n") {
+		t.Errorf("gnss_fix_valid after an invalid fix isn't 0:\n%s", body)
+	}
+}
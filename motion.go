@@ -0,0 +1,65 @@
+package main
+
+import "time"
+
+const (
+	// DefaultStationaryPeriod is how long every fix must be below the moving threshold before
+	// adaptive polling slows down, when STATIONARY_SECONDS is unset
+	DefaultStationaryPeriod = 2 * time.Minute
+	// DefaultMovingFixes is how many consecutive fixes must be at or above the moving threshold
+	// before adaptive polling speeds back up, when MOVING_FIXES is unset
+	DefaultMovingFixes = 2
+)
+
+// MotionState tracks whether the device is moving for adaptive polling. Both directions have
+// hysteresis, so a single noisy fix can't flip the state back and forth: it only switches to
+// stationary once every fix for a full period has been slow, and only switches back to moving
+// after a run of consecutive fast fixes. The run is counted in fixes rather than time, since
+// fixes arrive at the slow idle rate while stationary, so a short run still reacts quickly.
+type MotionState struct {
+	period      time.Duration // How long the device must be still before it counts as stationary
+	movingFixes int           // Consecutive fast fixes needed before it counts as moving again
+	moving      bool
+	stillSince  time.Time // Time of the first slow fix in the current run, zero while moving
+	fastRun     int       // Consecutive fast fixes seen while stationary
+}
+
+// NewMotionState creates a tracker that starts out moving, so polling starts at the active rate.
+// movingFixes below 1 counts as 1, switching back on the first fast fix.
+func NewMotionState(period time.Duration, movingFixes int) *MotionState {
+	return &MotionState{period: period, movingFixes: max(movingFixes, 1), moving: true}
+}
+
+// Moving reports the current state
+func (m *MotionState) Moving() bool {
+	return m.moving
+}
+
+// Update feeds whether a valid fix taken at now was moving, and reports whether the state changed
+func (m *MotionState) Update(moving bool, now time.Time) bool {
+	if moving {
+		m.stillSince = time.Time{}
+		if m.moving {
+			return false
+		}
+		m.fastRun++
+		if m.fastRun < m.movingFixes {
+			return false
+		}
+		m.fastRun = 0
+		m.moving = true
+		return true
+	}
+	m.fastRun = 0
+	if !m.moving {
+		return false
+	}
+	if m.stillSince.IsZero() {
+		m.stillSince = now
+	}
+	if now.Sub(m.stillSince) < m.period {
+		return false
+	}
+	m.moving = false
+	return true
+}
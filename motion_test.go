@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMotionStateTransitions(t *testing.T) {
+	type step struct {
+		seconds     int // Since the first fix
+		moving      bool
+		wantChanged bool
+		wantMoving  bool
+	}
+	tests := []struct {
+		name        string
+		movingFixes int
+		steps       []step
+	}{
+		{"stays moving while fast", 2, []step{{0, true, false, true}, {10, true, false, true}, {200, true, false, true}}},
+		{"slows after the full period", 2, []step{
+			{0, false, false, true}, {60, false, false, true}, {119, false, false, true}, {120, false, true, false}, {180, false, false, false},
+		}},
+		{"a fast fix restarts the period", 2, []step{
+			{0, false, false, true}, {100, true, false, true}, {110, false, false, true}, {220, false, false, true}, {230, false, true, false},
+		}},
+		{"one noisy fast fix stays stationary", 2, []step{
+			{0, false, false, true}, {120, false, true, false}, {180, true, false, false}, {240, false, false, false}, {300, true, false, false},
+		}},
+		{"a run of fast fixes resumes moving", 2, []step{
+			{0, false, false, true}, {120, false, true, false}, {180, true, false, false}, {181, true, true, true}, {182, true, false, true},
+		}},
+		{"moving fixes below 1 count as 1", 0, []step{
+			{0, false, false, true}, {120, false, true, false}, {180, true, true, true},
+		}},
+		{"longer run required", 3, []step{
+			{0, false, false, true}, {120, false, true, false}, {180, true, false, false}, {181, true, false, false}, {182, true, true, true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMotionState(DefaultStationaryPeriod, tt.movingFixes)
+			if !m.Moving() {
+				t.Fatal("new MotionState isn't moving, want polling to start at the active rate")
+			}
+			start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+			for i, s := range tt.steps {
+				changed := m.Update(s.moving, start.Add(time.Duration(s.seconds)*time.Second))
+				if changed != s.wantChanged || m.Moving() != s.wantMoving {
+					t.Errorf("step %d at %ds: Update(%t) = %t with Moving() %t, want %t with %t",
+						i, s.seconds, s.moving, changed, m.Moving(), s.wantChanged, s.wantMoving)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+)
+
+// Supported values for MQTT_PROTOCOL
+const (
+	MQTTProtocol311 = "3.1.1"
+	MQTTProtocol5   = "5"
+)
+
+// mqtt5ClientIDSuffix keeps the MQTT 5 connection from taking over the 3.1.1 connection's session
+const mqtt5ClientIDSuffix = "-v5"
+
+// ParseUserProperties parses comma-separated key=value pairs, e.g. "serial=ABC123,firmware=1.4.2"
+func ParseUserProperties(s string) (map[string]string, error) {
+	props := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		props[key] = value
+	}
+	return props, nil
+}
+
+// ConnectMQTT5 starts an MQTT 5 connection to the broker that reconnects in the background.
+// It returns once the first connection is up, or ctx is done.
+func ConnectMQTT5(ctx context.Context, cfg *Config, tlsConfig *tls.Config) (*autopaho.ConnectionManager, error) {
+	server, err := url.Parse(fmt.Sprintf("tls://%s:%s", cfg.MQTTBrokerURL, cfg.MQTTBrokerPort))
+	if err != nil {
+		return nil, err
+	}
+	conn, err := autopaho.NewConnection(ctx, autopaho.ClientConfig{
+		ServerUrls:                    []*url.URL{server},
+		TlsCfg:                        tlsConfig,
+		KeepAlive:                     30,
+		CleanStartOnInitialConnection: cfg.MQTTCleanSession,
+		ConnectUsername:               cfg.MQTTUsername,
+		ConnectPassword:               []byte(cfg.MQTTPassword),
+		OnConnectionUp: func(*autopaho.ConnectionManager, *paho.Connack) {
+			log.Println("Connected to MQTT 5 broker")
+		},
+		OnConnectError: func(err error) {
+			log.Printf("MQTT 5 connection error: %v", err)
+		},
+		ClientConfig: paho.ClientConfig{ClientID: cfg.MQTTClientID + mqtt5ClientIDSuffix},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.AwaitConnection(ctx); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// MQTT5Publisher publishes over an MQTT 5 connection, carrying the content type and message
+// properties as MQTT 5 publish properties instead of in the payload
+type MQTT5Publisher struct {
+	Conn           *autopaho.ConnectionManager
+	QoS            byte
+	Retain         bool
+	ContentType    string
+	UserProperties map[string]string // Added to every message, e.g. the device serial and firmware
+}
+
+// Publish sends payload to topic with the static user properties followed by properties,
+// each in key order, giving up after mqttPublishTimeout
+func (p *MQTT5Publisher) Publish(ctx context.Context, topic string, payload []byte, properties map[string]string) error {
+	props := &paho.PublishProperties{ContentType: p.ContentType}
+	for _, m := range []map[string]string{p.UserProperties, properties} {
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			props.User.Add(key, m[key])
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, mqttPublishTimeout)
+	defer cancel()
+	_, err := p.Conn.Publish(ctx, &paho.Publish{
+		QoS:        p.QoS,
+		Retain:     p.Retain,
+		Topic:      topic,
+		Payload:    payload,
+		Properties: props,
+	})
+	return err
+}
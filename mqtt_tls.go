@@ -0,0 +1,38 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+)
+
+// loadMQTTTLSConfig builds the TLS config for the broker connection from the system roots,
+// plus the MQTT_CA_CERT file caCert, and the MQTT_CLIENT_CERT/MQTT_CLIENT_KEY pair for mutual TLS
+func loadMQTTTLSConfig(caCert, clientCert, clientKey string) (*tls.Config, error) {
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load system cert pool: %w", err)
+	}
+	tlsConfig := &tls.Config{RootCAs: rootCAs}
+	if caCert != "" {
+		pem, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MQTT_CA_CERT: %w", err)
+		}
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("failed to load MQTT_CA_CERT: %s contains no PEM certificates", caCert)
+		}
+	}
+	if clientCert != "" {
+		// LoadX509KeyPair also checks that the private key matches the certificate
+		cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load MQTT client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		log.Printf("Using MQTT client certificate %s", clientCert)
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	// ModemManagerDest is the well-known bus name of ModemManager
+	ModemManagerDest = "org.freedesktop.ModemManager1"
+	// ModemManagerPath is the root object path exposing the ModemManager object manager
+	ModemManagerPath = "/org/freedesktop/ModemManager1"
+	// ModemManagerModemInterface is the interface carrying a modem's AccessTechnologies property
+	ModemManagerModemInterface = "org.freedesktop.ModemManager1.Modem"
+)
+
+// ModemManager MMModemAccessTechnology flags, grouped by generation from newest to oldest
+var accessTechnologyGenerations = []struct {
+	name string
+	mask uint32
+}{
+	{"5G", 1 << 15},                                    // 5GNR
+	{"LTE", 1<<14 | 1<<16 | 1<<17},                     // LTE, LTE Cat-M, LTE NB-IoT
+	{"3G", 1<<5 | 1<<6 | 1<<7 | 1<<8 | 1<<9 | 0xF<<10}, // UMTS, HSxPA, CDMA 1xRTT and EV-DO
+	{"2G", 1<<1 | 1<<2 | 1<<3 | 1<<4},                  // GSM, GSM Compact, GPRS, EDGE
+}
+
+// NetworkTypeFromProperties returns the newest radio access technology generation in a
+// ModemManager modem's properties, e.g. "LTE", or "" when AccessTechnologies is absent or unknown
+func NetworkTypeFromProperties(props map[string]dbus.Variant) string {
+	v, ok := props["AccessTechnologies"]
+	if !ok {
+		return ""
+	}
+	techs, ok := v.Value().(uint32)
+	if !ok {
+		return ""
+	}
+	for _, gen := range accessTechnologyGenerations {
+		if techs&gen.mask != 0 {
+			return gen.name
+		}
+	}
+	return ""
+}
+
+// NetworkTypeReader reads the active radio access technology of the cellular modem from ModemManager
+type NetworkTypeReader struct {
+	conn *dbus.Conn
+}
+
+// NewNetworkTypeReader creates a reader on the system D-Bus
+func NewNetworkTypeReader() (*NetworkTypeReader, error) {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return nil, err
+	}
+	return &NetworkTypeReader{conn: conn}, nil
+}
+
+// NetworkType returns the network type of the first modem ModemManager knows about, by object
+// path, or "" when there's no modem or it isn't registered on a network. It gives up when ctx
+// is done.
+func (r *NetworkTypeReader) NetworkType(ctx context.Context) (string, error) {
+	var objects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+	obj := r.conn.Object(ModemManagerDest, ModemManagerPath)
+	if err := obj.CallWithContext(ctx, "org.freedesktop.DBus.ObjectManager.GetManagedObjects", 0).Store(&objects); err != nil {
+		return "", fmt.Errorf("failed to list ModemManager modems: %w", err)
+	}
+	paths := make([]string, 0, len(objects))
+	for path := range objects {
+		paths = append(paths, string(path))
+	}
+	sort.Strings(paths) // Map order is random; always report the same modem
+	for _, path := range paths {
+		if props, ok := objects[dbus.ObjectPath(path)][ModemManagerModemInterface]; ok {
+			return NetworkTypeFromProperties(props), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestNetworkTypeFromProperties(t *testing.T) {
+	tests := []struct {
+		name  string
+		props map[string]dbus.Variant
+		want  string
+	}{
+		{"LTE", map[string]dbus.Variant{"AccessTechnologies": dbus.MakeVariant(uint32(1 << 14))}, "LTE"},
+		{"LTE Cat-M", map[string]dbus.Variant{"AccessTechnologies": dbus.MakeVariant(uint32(1 << 16))}, "LTE"},
+		{"5G NSA with LTE anchor", map[string]dbus.Variant{"AccessTechnologies": dbus.MakeVariant(uint32(1<<14 | 1<<15))}, "5G"},
+		{"HSPA", map[string]dbus.Variant{"AccessTechnologies": dbus.MakeVariant(uint32(1 << 8))}, "3G"},
+		{"UMTS", map[string]dbus.Variant{"AccessTechnologies": dbus.MakeVariant(uint32(1 << 5))}, "3G"},
+		{"EDGE", map[string]dbus.Variant{"AccessTechnologies": dbus.MakeVariant(uint32(1 << 4))}, "2G"},
+		{
+			name: "alongside other modem properties",
+			props: map[string]dbus.Variant{
+				"Manufacturer":       dbus.MakeVariant("Quectel"),
+				"SignalQuality":      dbus.MakeVariant([]any{uint32(70), true}),
+				"AccessTechnologies": dbus.MakeVariant(uint32(1 << 14)),
+			},
+			want: "LTE",
+		},
+		{"unknown", map[string]dbus.Variant{"AccessTechnologies": dbus.MakeVariant(uint32(0))}, ""},
+		{"POTS only", map[string]dbus.Variant{"AccessTechnologies": dbus.MakeVariant(uint32(1))}, ""},
+		{"absent", map[string]dbus.Variant{"Manufacturer": dbus.MakeVariant("Quectel")}, ""},
+		{"wrong type", map[string]dbus.Variant{"AccessTechnologies": dbus.MakeVariant("LTE")}, ""},
+		{"no properties", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NetworkTypeFromProperties(tt.props); got != tt.want {
+				t.Errorf("NetworkTypeFromProperties() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
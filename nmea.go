@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// NMEA talker IDs
+const (
+	TalkerGPS          = "GP"
+	TalkerBeidou       = "GB" // NMEA 0183 v4.1 BeiDou talker
+	TalkerBeidouLegacy = "BD" // Pre-v4.1 BeiDou talker still used by many receivers
+	TalkerGlonass      = "GL"
+	TalkerGalileo      = "GA"
+	TalkerCombined     = "GN"
+)
+
+// nmeaChecksum XORs every character of the sentence body between '$' and '*'
+func nmeaChecksum(body string) byte {
+	var sum byte
+	for i := 0; i < len(body); i++ {
+		sum ^= body[i]
+	}
+	return sum
+}
+
+// formatNMEASentence frames a sentence body with the leading '$' and trailing checksum
+func formatNMEASentence(body string) string {
+	return fmt.Sprintf("$%s*%02X", body, nmeaChecksum(body))
+}
+
+// formatNMEACoordinate formats an absolute coordinate as NMEA degrees and decimal minutes,
+// ddmm.mmmm for latitude (degDigits 2) or dddmm.mmmm for longitude (degDigits 3)
+func formatNMEACoordinate(value float64, degDigits int) string {
+	value = math.Abs(value)
+	deg := math.Floor(value)
+	minutes := (value - deg) * 60
+	if math.Round(minutes*10000) >= 600000 { // Rounding would print 60.0000 minutes
+		deg++
+		minutes = 0
+	}
+	return fmt.Sprintf("%0*d%07.4f", degDigits, int(deg), minutes)
+}
+
+// nmeaLatitude returns the NMEA latitude field and N/S indicator
+func nmeaLatitude(lat float64) (string, string) {
+	if lat < 0 {
+		return formatNMEACoordinate(lat, 2), "S"
+	}
+	return formatNMEACoordinate(lat, 2), "N"
+}
+
+// nmeaLongitude returns the NMEA longitude field and E/W indicator
+func nmeaLongitude(lon float64) (string, string) {
+	if lon < 0 {
+		return formatNMEACoordinate(lon, 3), "W"
+	}
+	return formatNMEACoordinate(lon, 3), "E"
+}
+
+// nmeaTime formats the UTC time of day as hhmmss.ss
+func (u NmeaUtcTime) nmeaTime() string {
+	return fmt.Sprintf("%02d%02d%02d.00", u.Hour, u.Min, u.Sec)
+}
+
+// nmeaFixQuality returns the GGA fix quality indicator, 0 for invalid fixes
+func (d *GnssData) nmeaFixQuality() uint8 {
+	if d.Valid == 0 {
+		return 0
+	}
+	if d.Gpssta == 0 {
+		return 1
+	}
+	return d.Gpssta
+}
+
+// GGA builds a GGA sentence for the fix with the given talker ID and satellite count
+func (d *GnssData) GGA(talker string, satellites int) string {
+	lat, ns := nmeaLatitude(d.Latitude)
+	lon, ew := nmeaLongitude(d.Longitude)
+	fields := []string{
+		talker + "GGA",
+		d.Utc.nmeaTime(),
+		lat, ns,
+		lon, ew,
+		fmt.Sprintf("%d", d.nmeaFixQuality()),
+		fmt.Sprintf("%02d", min(satellites, 99)),
+		fmt.Sprintf("%.1f", d.Hdop),
+		fmt.Sprintf("%.1f", d.Altitude), "M",
+		"", "M", // Geoid separation isn't reported by the modem
+		"", "", // No differential age or station
+	}
+	return formatNMEASentence(strings.Join(fields, ","))
+}
+
+// ConstellationGGA builds a GGA sentence per constellation (GPS, BeiDou, GLONASS and Galileo)
+// followed by a combined $GNGGA. Each constellation's sentence carries its satellites in view;
+// the combined sentence carries SatellitesUsed, or all in view if that's unknown.
+func (d *GnssData) ConstellationGGA(beidouTalker string) []string {
+	combined := d.SatellitesUsed
+	if combined == 0 {
+		combined = d.SatellitesInView()
+	}
+	return []string{
+		d.GGA(TalkerGPS, int(d.Svnum)),
+		d.GGA(beidouTalker, int(d.BeidouSvnum)),
+		d.GGA(TalkerGlonass, int(d.GlonassSvnum)),
+		d.GGA(TalkerGalileo, int(d.GalileoSvnum)),
+		d.GGA(TalkerCombined, combined),
+	}
+}
+
+// speedKnots returns the ground speed in knots, undoing any SPEED_UNIT conversion
+func (d *GnssData) speedKnots() float64 {
+	unit := d.SpeedUnit
+	if unit == "" {
+		unit = SpeedUnitRaw
+	}
+	factor, ok := speedFactors[unit]
+	if !ok {
+		return 0
+	}
+	return d.Speed / factor * speedFactors[SpeedUnitKnots]
+}
+
+// RMC builds an RMC sentence for the fix with the given talker ID. The course over ground
+// and magnetic variation aren't reported by the modem and are left empty, as is the date
+// until the modem reports a valid UTC time.
+func (d *GnssData) RMC(talker string) string {
+	lat, ns := nmeaLatitude(d.Latitude)
+	lon, ew := nmeaLongitude(d.Longitude)
+	status, mode := "V", "N"
+	if d.Valid != 0 {
+		status, mode = "A", "A"
+		if d.Gpssta == 2 {
+			mode = "D"
+		}
+	}
+	var date string
+	if t, err := d.Utc.Time(); err == nil {
+		date = t.Format("020106")
+	}
+	fields := []string{
+		talker + "RMC",
+		d.Utc.nmeaTime(),
+		status,
+		lat, ns,
+		lon, ew,
+		fmt.Sprintf("%.1f", d.speedKnots()),
+		"", // Course over ground
+		date,
+		"", "", // Magnetic variation
+		mode,
+	}
+	return formatNMEASentence(strings.Join(fields, ","))
+}
+
+// ToNMEA returns the fix as a $GPGGA sentence followed by a $GPRMC sentence
+func (d *GnssData) ToNMEA() []string {
+	return []string{d.GGA(TalkerGPS, int(d.Svnum)), d.RMC(TalkerGPS)}
+}
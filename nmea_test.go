@@ -0,0 +1,155 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// checkNMEASentence verifies the framing and checksum of a sentence and returns its fields
+func checkNMEASentence(t *testing.T, sentence string) []string {
+	t.Helper()
+	body, sum, ok := strings.Cut(strings.TrimPrefix(sentence, "$"), "*")
+	if !strings.HasPrefix(sentence, "$") || !ok || len(sum) != 2 {
+		t.Fatalf("%q isn't framed as $body*hh", sentence)
+	}
+	want, err := strconv.ParseUint(sum, 16, 8)
+	if err != nil {
+		t.Fatalf("%q has a malformed checksum: %v", sentence, err)
+	}
+	var got byte
+	for _, c := range []byte(body) {
+		got ^= c
+	}
+	if got != byte(want) || sum != strings.ToUpper(sum) {
+		t.Errorf("%q checksum %s, want %02X", sentence, sum, got)
+	}
+	return strings.Split(body, ",")
+}
+
+func TestNMEAChecksum(t *testing.T) {
+	tests := []struct {
+		body string
+		want byte
+	}{
+		{"GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,", 0x47},
+		{"GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W", 0x6A},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := nmeaChecksum(tt.body); got != tt.want {
+			t.Errorf("nmeaChecksum(%q) = %02X, want %02X", tt.body, got, tt.want)
+		}
+	}
+	if got, want := formatNMEASentence(tests[0].body), "$"+tests[0].body+"*47"; got != want {
+		t.Errorf("formatNMEASentence() = %q, want %q", got, want)
+	}
+}
+
+func TestConstellationGGA(t *testing.T) {
+	data := GnssData{
+		Valid: 1, Latitude: 48.1173, Longitude: 11.516667, Altitude: 545.4, Hdop: 0.9,
+		Svnum: 8, BeidouSvnum: 5, GlonassSvnum: 4, GalileoSvnum: 3, SatellitesUsed: 14,
+		Utc: NmeaUtcTime{Year: 2024, Month: 3, Date: 23, Hour: 12, Min: 35, Sec: 19},
+	}
+	tests := []struct {
+		beidouTalker string
+		want         []string // Talker, sentence type and satellite count of each sentence
+	}{
+		{TalkerBeidou, []string{"GPGGA 08", "GBGGA 05", "GLGGA 04", "GAGGA 03", "GNGGA 14"}},
+		{TalkerBeidouLegacy, []string{"GPGGA 08", "BDGGA 05", "GLGGA 04", "GAGGA 03", "GNGGA 14"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.beidouTalker, func(t *testing.T) {
+			sentences := data.ConstellationGGA(tt.beidouTalker)
+			if len(sentences) != len(tt.want) {
+				t.Fatalf("ConstellationGGA() returned %d sentences, want %d", len(sentences), len(tt.want))
+			}
+			for i, s := range sentences {
+				fields := checkNMEASentence(t, s)
+				if got := fields[0] + " " + fields[7]; got != tt.want[i] {
+					t.Errorf("sentence %d = %q, want talker, type and satellites %q", i, s, tt.want[i])
+				}
+				// Everything but the talker and satellite count is shared
+				if want := "123519.00,4807.0380,N,01131.0000,E,1"; strings.Join(fields[1:7], ",") != want {
+					t.Errorf("sentence %d fields %v, want %s", i, fields[1:7], want)
+				}
+			}
+		})
+	}
+}
+
+func TestConstellationGGACombinedFallsBackToInView(t *testing.T) {
+	data := GnssData{Valid: 1, Svnum: 7, BeidouSvnum: 3, GlonassSvnum: 2}
+	sentences := data.ConstellationGGA(TalkerBeidou)
+	fields := checkNMEASentence(t, sentences[len(sentences)-1])
+	if fields[0] != "GNGGA" || fields[7] != "12" {
+		t.Errorf("combined sentence %q, want $GNGGA with the 12 satellites in view", sentences[len(sentences)-1])
+	}
+}
+
+func TestToNMEAKnownSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		data GnssData
+		want []string
+	}{
+		{
+			name: "valid fix",
+			data: GnssData{
+				Valid: 1, Latitude: 48.1173, Longitude: 11.516667, Altitude: 545.4, Hdop: 0.9, Svnum: 8,
+				Speed: 22.4 * 1.852, SpeedUnit: SpeedUnitKmh,
+				Utc: NmeaUtcTime{Year: 1994, Month: 3, Date: 23, Hour: 12, Min: 35, Sec: 19},
+			},
+			want: []string{
+				"$GPGGA,123519.00,4807.0380,N,01131.0000,E,1,08,0.9,545.4,M,,M,,*7C",
+				"$GPRMC,123519.00,A,4807.0380,N,01131.0000,E,22.4,,230394,,,A*44",
+			},
+		},
+		{
+			name: "invalid fix in the southern and western hemispheres without a date",
+			data: GnssData{
+				Latitude: -33.8563, Longitude: -151.2096, Altitude: -12,
+				Utc: NmeaUtcTime{Hour: 23, Min: 59, Sec: 59},
+			},
+			want: []string{
+				"$GPGGA,235959.00,3351.3780,S,15112.5760,W,0,00,0.0,-12.0,M,,M,,*69",
+				"$GPRMC,235959.00,V,3351.3780,S,15112.5760,W,0.0,,,,,N*6C",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.data.ToNMEA()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToNMEA() = %q, want %q", got, tt.want)
+			}
+			for _, s := range got {
+				checkNMEASentence(t, s)
+			}
+		})
+	}
+}
+
+func TestFormatNMEACoordinate(t *testing.T) {
+	tests := []struct {
+		value     float64
+		degDigits int
+		want      string
+	}{
+		{48.1173, 2, "4807.0380"},
+		{-33.8563, 2, "3351.3780"},
+		{0, 2, "0000.0000"},
+		{5.5, 2, "0530.0000"},
+		{11.516667, 3, "01131.0000"},
+		{-0.1246, 3, "00007.4760"},
+		{179.99999999, 3, "18000.0000"}, // Rounds up to a whole degree rather than 60 minutes
+		{51.999999999, 2, "5200.0000"},
+	}
+	for _, tt := range tests {
+		if got := formatNMEACoordinate(tt.value, tt.degDigits); got != tt.want {
+			t.Errorf("formatNMEACoordinate(%v, %d) = %q, want %q", tt.value, tt.degDigits, got, tt.want)
+		}
+	}
+}
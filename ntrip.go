@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http/httputil"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+const (
+	// ntripMinBackoff is the initial delay before reconnecting to the caster
+	ntripMinBackoff = time.Second
+	// ntripMaxBackoff caps the delay between caster reconnection attempts
+	ntripMaxBackoff = time.Minute
+	// rtcmPreamble marks the start of an RTCM 3 frame
+	rtcmPreamble = 0xD3
+)
+
+// RTCMSink receives RTCM 3 correction frames, giving up when ctx is done
+type RTCMSink interface {
+	InjectRTCM(ctx context.Context, frame []byte) error
+}
+
+// RTCMSinkFunc adapts a function to the RTCMSink interface
+type RTCMSinkFunc func(ctx context.Context, frame []byte) error
+
+// InjectRTCM calls f(ctx, frame)
+func (f RTCMSinkFunc) InjectRTCM(ctx context.Context, frame []byte) error {
+	return f(ctx, frame)
+}
+
+// NTRIPClient streams RTCM corrections from an NTRIP caster mountpoint into a sink,
+// reconnecting with exponential backoff when the caster disconnects
+type NTRIPClient struct {
+	Address    string // Caster host:port
+	Mountpoint string // Mountpoint name, without leading slash
+	Username   string // Optional caster username
+	Password   string // Optional caster password
+	Sink       RTCMSink
+	Dial       func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// Run streams corrections until ctx is cancelled
+func (c *NTRIPClient) Run(ctx context.Context) {
+	backoff := ntripMinBackoff
+	for ctx.Err() == nil {
+		frames, err := c.stream(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if frames > 0 {
+			backoff = ntripMinBackoff
+		}
+		log.Printf("NTRIP stream from %s/%s ended after %d frames: %v, reconnecting in %s", c.Address, c.Mountpoint, frames, err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, ntripMaxBackoff)
+	}
+}
+
+// stream runs a single caster session, returning the number of frames forwarded
+func (c *NTRIPClient) stream(ctx context.Context) (int, error) {
+	conn, err := c.Dial(ctx, "tcp", c.Address)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	// Unblock the read on shutdown; stop releases the callback when the session ends first
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	body, err := c.handshake(conn)
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("Connected to NTRIP caster %s/%s", c.Address, c.Mountpoint)
+	r := bufio.NewReader(body)
+	frames := 0
+	for {
+		frame, err := ReadRTCMFrame(r)
+		if err != nil {
+			return frames, err
+		}
+		if err := c.Sink.InjectRTCM(ctx, frame); err != nil {
+			return frames, fmt.Errorf("failed to inject RTCM frame: %w", err)
+		}
+		frames++
+	}
+}
+
+// handshake requests the mountpoint and returns a reader positioned at the RTCM stream.
+// Both NTRIP v1 ("ICY 200 OK") and v2 (HTTP/1.1, optionally chunked) responses are accepted.
+func (c *NTRIPClient) handshake(conn net.Conn) (io.Reader, error) {
+	var req strings.Builder
+	fmt.Fprintf(&req, "GET /%s HTTP/1.1\r\n", c.Mountpoint)
+	fmt.Fprintf(&req, "Host: %s\r\n", c.Address)
+	req.WriteString("Ntrip-Version: Ntrip/2.0\r\n")
+	req.WriteString("User-Agent: NTRIP particle-tachyon-gps-dbus\r\n")
+	if c.Username != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password))
+		fmt.Fprintf(&req, "Authorization: Basic %s\r\n", auth)
+	}
+	req.WriteString("Connection: close\r\n\r\n")
+	if _, err := io.WriteString(conn, req.String()); err != nil {
+		return nil, fmt.Errorf("failed to send NTRIP request: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	tp := textproto.NewReader(br)
+	status, err := tp.ReadLine()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read NTRIP response: %w", err)
+	}
+	switch {
+	case strings.HasPrefix(status, "ICY 200"):
+		return br, nil
+	case strings.HasPrefix(status, "HTTP/1.") && strings.Contains(status, " 200"):
+		header, err := tp.ReadMIMEHeader()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read NTRIP response headers: %w", err)
+		}
+		if strings.EqualFold(header.Get("Transfer-Encoding"), "chunked") {
+			return httputil.NewChunkedReader(br), nil
+		}
+		return br, nil
+	case strings.HasPrefix(status, "SOURCETABLE"):
+		return nil, fmt.Errorf("mountpoint %q not found on caster", c.Mountpoint)
+	default:
+		return nil, fmt.Errorf("caster rejected request: %s", status)
+	}
+}
+
+// ReadRTCMFrame reads the next CRC-valid RTCM 3 frame from r, skipping any bytes
+// that don't begin a valid frame. The returned frame includes header and CRC.
+func ReadRTCMFrame(r *bufio.Reader) ([]byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b != rtcmPreamble {
+			continue
+		}
+		header, err := r.Peek(2)
+		if err != nil {
+			return nil, err
+		}
+		if header[0]&0xFC != 0 { // Reserved bits must be zero
+			continue
+		}
+		length := int(header[0]&0x03)<<8 | int(header[1])
+		rest, err := r.Peek(2 + length + 3)
+		if err != nil {
+			return nil, err
+		}
+		frame := make([]byte, 0, 1+len(rest))
+		frame = append(frame, rtcmPreamble)
+		frame = append(frame, rest...)
+		body := frame[:3+length]
+		crc := uint32(frame[3+length])<<16 | uint32(frame[4+length])<<8 | uint32(frame[5+length])
+		if crc24q(body) != crc {
+			continue
+		}
+		if _, err := r.Discard(len(rest)); err != nil {
+			return nil, err
+		}
+		return frame, nil
+	}
+}
+
+// crc24q computes the Qualcomm CRC-24Q checksum used by RTCM 3
+func crc24q(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for range 8 {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= 0x1864CFB
+			}
+		}
+	}
+	return crc & 0xFFFFFF
+}
+
+// FixTypeFromQuality maps an NMEA GGA fix quality indicator to a fix type name
+func FixTypeFromQuality(quality uint8) string {
+	switch quality {
+	case 0:
+		return "invalid"
+	case 1:
+		return "gps"
+	case 2:
+		return "dgps"
+	case 3:
+		return "pps"
+	case 4:
+		return "rtk_fixed"
+	case 5:
+		return "rtk_float"
+	case 6:
+		return "estimated"
+	case 7:
+		return "manual"
+	case 8:
+		return "simulation"
+	default:
+		return "unknown"
+	}
+}
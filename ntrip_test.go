@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// rtcm1005 is the example type 1005 station message from the RTCM 3 standard
+var rtcm1005 = []byte{
+	0xD3, 0x00, 0x13, 0x3E, 0xD7, 0xD3, 0x02, 0x02, 0x98, 0x0E, 0xDE, 0xEF, 0x34, 0xB4,
+	0xBD, 0x62, 0xAC, 0x09, 0x41, 0x98, 0x6F, 0x33, 0x36, 0x0B, 0x98,
+}
+
+// rtcmFrame wraps payload in an RTCM 3 header and CRC
+func rtcmFrame(payload ...byte) []byte {
+	frame := append([]byte{rtcmPreamble, byte(len(payload) >> 8), byte(len(payload))}, payload...)
+	crc := crc24q(frame)
+	return append(frame, byte(crc>>16), byte(crc>>8), byte(crc))
+}
+
+func TestCRC24Q(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want uint32
+	}{
+		{"empty", nil, 0},
+		{"check value", []byte("123456789"), 0xCDE703},
+		{"RTCM 1005 example", rtcm1005[:len(rtcm1005)-3], 0x360B98},
+	}
+	for _, tt := range tests {
+		if got := crc24q(tt.in); got != tt.want {
+			t.Errorf("crc24q(%s) = %#06x, want %#06x", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadRTCMFrame(t *testing.T) {
+	short := rtcmFrame(0x01, 0x02)
+	corrupt := bytes.Clone(short)
+	corrupt[len(corrupt)-1] ^= 0xFF
+	tests := []struct {
+		name   string
+		stream []byte
+		want   [][]byte
+	}{
+		{"single frame", rtcm1005, [][]byte{rtcm1005}},
+		{"leading garbage", append([]byte("$GPGGA\r\n\xD3\xFF"), rtcm1005...), [][]byte{rtcm1005}},
+		{"back to back", append(bytes.Clone(short), rtcm1005...), [][]byte{short, rtcm1005}},
+		{"bad CRC skipped", append(bytes.Clone(corrupt), short...), [][]byte{short}},
+		{"empty payload", rtcmFrame(), [][]byte{rtcmFrame()}},
+		{"truncated", rtcm1005[:10], nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReader(bytes.NewReader(tt.stream))
+			var got [][]byte
+			for {
+				frame, err := ReadRTCMFrame(r)
+				if err != nil {
+					if !errors.Is(err, io.EOF) {
+						t.Fatalf("ReadRTCMFrame() error = %v, want EOF", err)
+					}
+					break
+				}
+				got = append(got, frame)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("read %d frames, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if !bytes.Equal(got[i], tt.want[i]) {
+					t.Errorf("frame %d = % X, want % X", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// mockCaster answers a single NTRIP request over an in-memory connection
+type mockCaster struct {
+	response string // Status line and headers, sent before the body
+	body     []byte
+	chunked  bool
+
+	mu      sync.Mutex
+	request *http.Request
+}
+
+func (m *mockCaster) Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		req, err := http.ReadRequest(bufio.NewReader(server))
+		if err != nil {
+			return
+		}
+		m.mu.Lock()
+		m.request = req
+		m.mu.Unlock()
+		io.WriteString(server, m.response)
+		if m.chunked {
+			w := httputil.NewChunkedWriter(server)
+			w.Write(m.body)
+			w.Close()
+			return
+		}
+		server.Write(m.body)
+	}()
+	return client, nil
+}
+
+func TestNTRIPClientStream(t *testing.T) {
+	second := rtcmFrame(0xAA, 0xBB, 0xCC)
+	stream := append(bytes.Clone(rtcm1005), second...)
+	tests := []struct {
+		name    string
+		caster  *mockCaster
+		want    int
+		wantErr string // Substring of the session error
+	}{
+		{"NTRIP v1", &mockCaster{response: "ICY 200 OK\r\n", body: stream}, 2, "EOF"},
+		{"NTRIP v2", &mockCaster{response: "HTTP/1.1 200 OK\r\nContent-Type: gnss/data\r\n\r\n", body: stream}, 2, "EOF"},
+		{"NTRIP v2 chunked", &mockCaster{response: "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n", body: stream, chunked: true}, 2, "EOF"},
+		{"unknown mountpoint", &mockCaster{response: "SOURCETABLE 200 OK\r\n\r\n"}, 0, `mountpoint "MOUNT" not found`},
+		{"unauthorized", &mockCaster{response: "HTTP/1.1 401 Unauthorized\r\n\r\n"}, 0, "caster rejected request: HTTP/1.1 401 Unauthorized"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var frames [][]byte
+			c := &NTRIPClient{
+				Address:    "caster.example:2101",
+				Mountpoint: "MOUNT",
+				Username:   "user",
+				Password:   "pass",
+				Dial:       tt.caster.Dial,
+				Sink: RTCMSinkFunc(func(ctx context.Context, frame []byte) error {
+					frames = append(frames, frame)
+					return nil
+				}),
+			}
+			n, err := c.stream(context.Background())
+			if n != tt.want || len(frames) != tt.want {
+				t.Errorf("stream() forwarded %d frames, sink got %d, want %d", n, len(frames), tt.want)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("stream() error = %v, want %q", err, tt.wantErr)
+			}
+			if tt.want > 0 && (!bytes.Equal(frames[0], rtcm1005) || !bytes.Equal(frames[1], second)) {
+				t.Errorf("sink got % X, want the caster's frames", frames)
+			}
+
+			tt.caster.mu.Lock()
+			req := tt.caster.request
+			tt.caster.mu.Unlock()
+			if req == nil {
+				t.Fatal("caster received no request")
+			}
+			user, pass, _ := req.BasicAuth()
+			if req.URL.Path != "/MOUNT" || req.Host != c.Address || req.Header.Get("Ntrip-Version") != "Ntrip/2.0" || user != "user" || pass != "pass" {
+				t.Errorf("request %s %s host %q version %q auth %q:%q, want /MOUNT on %s with credentials",
+					req.Method, req.URL, req.Host, req.Header.Get("Ntrip-Version"), user, pass, c.Address)
+			}
+		})
+	}
+}
+
+func TestNTRIPClientSinkError(t *testing.T) {
+	caster := &mockCaster{response: "ICY 200 OK\r\n", body: append(bytes.Clone(rtcm1005), rtcm1005...)}
+	c := &NTRIPClient{
+		Address:    "caster.example:2101",
+		Mountpoint: "MOUNT",
+		Dial:       caster.Dial,
+		Sink: RTCMSinkFunc(func(ctx context.Context, frame []byte) error {
+			return fmt.Errorf("no such method")
+		}),
+	}
+	n, err := c.stream(context.Background())
+	if n != 0 || err == nil || !strings.Contains(err.Error(), "failed to inject RTCM frame: no such method") {
+		t.Errorf("stream() = %d, %v, want 0 frames and the sink error", n, err)
+	}
+}
+
+func TestFixTypeFromQuality(t *testing.T) {
+	tests := []struct {
+		quality uint8
+		want    string
+	}{
+		{0, "invalid"}, {1, "gps"}, {2, "dgps"}, {4, "rtk_fixed"}, {5, "rtk_float"}, {6, "estimated"}, {9, "unknown"},
+	}
+	for _, tt := range tests {
+		if got := FixTypeFromQuality(tt.quality); got != tt.want {
+			t.Errorf("FixTypeFromQuality(%d) = %q, want %q", tt.quality, got, tt.want)
+		}
+	}
+}
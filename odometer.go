@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultOdometerMaxSpeed is the speed in m/s above which a hop between fixes is treated as
+	// a bad fix rather than travel, about 250 km/h
+	DefaultOdometerMaxSpeed = 70.0
+	// OdometerSaveInterval limits how often the odometer is written to disk
+	OdometerSaveInterval = time.Minute
+)
+
+// Odometer accumulates the distance traveled between consecutive valid fixes and persists the
+// total so it survives restarts
+type Odometer struct {
+	path     string
+	maxSpeed float64 // Meters per second
+
+	mu        sync.Mutex
+	meters    float64
+	lat, lon  float64 // Previous accepted fix
+	at        time.Time
+	havePrev  bool
+	dirty     bool // Meters changed since the last save
+	lastSaved time.Time
+}
+
+// LoadOdometer reads the total from path, starting from zero if the file doesn't exist yet
+func LoadOdometer(path string, maxSpeed float64) (*Odometer, error) {
+	o := &Odometer{path: path, maxSpeed: maxSpeed}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read odometer: %w", err)
+	}
+	meters, err := strconv.ParseFloat(strings.TrimSpace(string(raw)), 64)
+	if err != nil || !(meters >= 0) {
+		return nil, fmt.Errorf("invalid odometer file %s: %q", path, strings.TrimSpace(string(raw)))
+	}
+	o.meters = meters
+	return o, nil
+}
+
+// Add feeds a valid fix taken at the given time. The distance from the previous fix is added
+// unless covering it would have needed more than the maximum speed, in which case the fix is
+// ignored and the next one is measured from the previous fix instead. It reports whether the
+// fix was ignored.
+func (o *Odometer) Add(lat, lon float64, at time.Time) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.havePrev {
+		o.lat, o.lon, o.at, o.havePrev = lat, lon, at, true
+		return false
+	}
+	distance := Haversine(o.lat, o.lon, lat, lon)
+	if elapsed := at.Sub(o.at).Seconds(); distance > 0 && (elapsed <= 0 || distance > o.maxSpeed*elapsed) {
+		return true
+	}
+	o.lat, o.lon, o.at = lat, lon, at
+	if distance > 0 {
+		o.meters += distance
+		o.dirty = true
+	}
+	return false
+}
+
+// Meters returns the total distance traveled
+func (o *Odometer) Meters() float64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.meters
+}
+
+// Save writes the total to disk if it changed since the last save
+func (o *Odometer) Save(now time.Time) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.dirty {
+		return nil
+	}
+	if err := writeFileAtomic(o.path, []byte(strconv.FormatFloat(o.meters, 'f', 3, 64)+"\n"), 0o644); err != nil {
+		return err
+	}
+	o.dirty = false
+	o.lastSaved = now
+	return nil
+}
+
+// SaveIfDue saves the total at most once per OdometerSaveInterval, sparing the device's flash
+func (o *Odometer) SaveIfDue(now time.Time) error {
+	o.mu.Lock()
+	due := now.Sub(o.lastSaved) >= OdometerSaveInterval
+	o.mu.Unlock()
+	if !due {
+		return nil
+	}
+	return o.Save(now)
+}
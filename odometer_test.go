@@ -0,0 +1,151 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOdometerAccumulates(t *testing.T) {
+	o, err := LoadOdometer(filepath.Join(t.TempDir(), "odometer"), DefaultOdometerMaxSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	lat, lon := 51.5, -0.12
+	// 10 m north every second for a minute, with a stationary second in the middle
+	for i := range 61 {
+		if i != 30 {
+			lat, lon = OffsetPosition(lat, lon, 10, 0)
+		}
+		if ignored := o.Add(lat, lon, start.Add(time.Duration(i)*time.Second)); ignored {
+			t.Fatalf("fix %d ignored", i)
+		}
+	}
+	if got := o.Meters(); math.Abs(got-590) > 0.01 {
+		t.Errorf("Meters() = %v, want 590", got)
+	}
+}
+
+func TestOdometerIgnoresTeleports(t *testing.T) {
+	o, err := LoadOdometer(filepath.Join(t.TempDir(), "odometer"), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	lat, lon := 51.5, -0.12
+	o.Add(lat, lon, start)
+	steps := []struct {
+		north       float64 // Meters north of the first fix
+		seconds     int
+		wantIgnored bool
+		wantMeters  float64
+	}{
+		{40, 1, false, 40},
+		{5040, 2, true, 40},   // 5 km in a second is a bad fix
+		{80, 3, false, 80},    // Measured from the last accepted fix, not the teleport
+		{80, 3, false, 80},    // Same place at the same time
+		{100, 3, true, 80},    // Moved without time passing
+		{179, 5, false, 179},  // 99 m in 2 s is within 50 m/s
+		{300, 7, true, 179},   // 121 m in 2 s isn't
+		{279, 10, false, 279}, // Covered by the time passed since the last accepted fix
+	}
+	for i, s := range steps {
+		fixLat, fixLon := OffsetPosition(lat, lon, s.north, 0)
+		ignored := o.Add(fixLat, fixLon, start.Add(time.Duration(s.seconds)*time.Second))
+		if ignored != s.wantIgnored || math.Abs(o.Meters()-s.wantMeters) > 0.01 {
+			t.Errorf("step %d: ignored %t with %.2f m, want %t with %v m", i, ignored, o.Meters(), s.wantIgnored, s.wantMeters)
+		}
+	}
+}
+
+func TestOdometerSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "odometer")
+	o, err := LoadOdometer(path, DefaultOdometerMaxSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := o.Save(start); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Save() with nothing traveled wrote the file: %v", err)
+	}
+
+	lat, lon := 51.5, -0.12
+	o.Add(lat, lon, start)
+	lat, lon = OffsetPosition(lat, lon, 1234.5678, 0)
+	o.Add(lat, lon, start.Add(time.Minute))
+	if err := o.Save(start.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadOdometer(path, DefaultOdometerMaxSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.Meters(); math.Abs(got-1234.568) > 1e-9 {
+		t.Errorf("reloaded Meters() = %v, want 1234.568", got)
+	}
+	// Travel after a restart adds to the persisted total
+	reloaded.Add(lat, lon, start.Add(2*time.Minute))
+	lat, lon = OffsetPosition(lat, lon, 100, 0)
+	reloaded.Add(lat, lon, start.Add(3*time.Minute))
+	if got := reloaded.Meters(); math.Abs(got-1334.568) > 0.001 {
+		t.Errorf("Meters() after more travel = %v, want 1334.568", got)
+	}
+}
+
+func TestOdometerSaveIfDue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "odometer")
+	o, err := LoadOdometer(path, DefaultOdometerMaxSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	lat, lon := 51.5, -0.12
+	move := func(at time.Time) {
+		lat, lon = OffsetPosition(lat, lon, 10, 0)
+		o.Add(lat, lon, at)
+	}
+	saved := func() string {
+		raw, _ := os.ReadFile(path)
+		return string(raw)
+	}
+	o.Add(lat, lon, start)
+	move(start.Add(time.Second))
+	if err := o.SaveIfDue(start.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if got := saved(); got != "10.000\n" {
+		t.Errorf("first save wrote %q, want 10.000", got)
+	}
+	move(start.Add(2 * time.Second))
+	if err := o.SaveIfDue(start.Add(30 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if got := saved(); got != "10.000\n" {
+		t.Errorf("save within the interval wrote %q, want it skipped", got)
+	}
+	if err := o.SaveIfDue(start.Add(time.Second + OdometerSaveInterval)); err != nil {
+		t.Fatal(err)
+	}
+	if got := saved(); got != "20.000\n" {
+		t.Errorf("save after the interval wrote %q, want 20.000", got)
+	}
+}
+
+func TestLoadOdometerRejectsCorruptFile(t *testing.T) {
+	for _, contents := range []string{"", "far", "-5", "NaN"} {
+		path := filepath.Join(t.TempDir(), "odometer")
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadOdometer(path, DefaultOdometerMaxSpeed); err == nil {
+			t.Errorf("LoadOdometer() of %q succeeded, want an error", contents)
+		}
+	}
+}
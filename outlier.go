@@ -0,0 +1,47 @@
+package main
+
+import "time"
+
+// MaxConsecutiveOutliers is how many fixes in a row the OutlierFilter rejects before accepting
+// the new position anyway. A real relocation, or a bad first fix the filter anchored to, would
+// otherwise have every later fix rejected.
+const MaxConsecutiveOutliers = 3
+
+// OutlierFilter rejects "teleport" fixes whose implied speed from the previous accepted fix
+// exceeds a maximum. The speed is measured over the real time between the fixes, so a long gap
+// in fixes allows a correspondingly long hop.
+type OutlierFilter struct {
+	maxSpeed float64 // Meters per second
+	lat, lon float64 // Previous accepted fix
+	at       time.Time
+	havePrev bool
+	rejected int // Consecutive fixes rejected since the last accepted one
+}
+
+// NewOutlierFilter creates a filter rejecting fixes implying more than maxSpeed m/s
+func NewOutlierFilter(maxSpeed float64) *OutlierFilter {
+	return &OutlierFilter{maxSpeed: maxSpeed}
+}
+
+// Accept reports whether a fix taken at the given time is plausible, along with its implied
+// speed in m/s from the previous accepted fix. Accepted fixes become the new reference. Fixes
+// less than a second apart are measured as one second apart, since the modem updates its
+// position at most once a second.
+func (f *OutlierFilter) Accept(lat, lon float64, at time.Time) (bool, float64) {
+	if !f.havePrev {
+		f.lat, f.lon, f.at, f.havePrev = lat, lon, at, true
+		return true, 0
+	}
+	elapsed := at.Sub(f.at).Seconds()
+	if elapsed < 1 {
+		elapsed = 1
+	}
+	speed := Haversine(f.lat, f.lon, lat, lon) / elapsed
+	if speed > f.maxSpeed && f.rejected < MaxConsecutiveOutliers {
+		f.rejected++
+		return false, speed
+	}
+	f.lat, f.lon, f.at = lat, lon, at
+	f.rejected = 0
+	return true, speed
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestOutlierFilter(t *testing.T) {
+	type fix struct {
+		north   float64 // Meters north of the start
+		seconds float64 // Since the first fix
+		want    bool
+	}
+	tests := []struct {
+		name     string
+		maxSpeed float64
+		fixes    []fix
+	}{
+		{"normal walking sequence", 30, []fix{{0, 0, true}, {1.5, 1, true}, {3, 2, true}, {4.5, 3, true}}},
+		{"single spike", 30, []fix{{0, 0, true}, {10, 1, true}, {2010, 2, false}, {30, 3, true}, {40, 4, true}}},
+		{"fast but plausible", 70, []fix{{0, 0, true}, {65, 1, true}, {130, 2, true}, {195, 3, true}}},
+		{"long gap allows a long hop", 30, []fix{{0, 0, true}, {20, 1, true}, {5020, 601, true}}},
+		{"fixes within a second measured as a second", 30, []fix{{0, 0, true}, {25, 0.2, true}, {80, 0.4, false}}},
+		{"sustained relocation accepted after the limit", 30, []fix{
+			{0, 0, true}, {5000, 1, false}, {5000, 2, false}, {5000, 3, false}, {5000, 4, true}, {5010, 5, true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewOutlierFilter(tt.maxSpeed)
+			start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+			for i, f := range tt.fixes {
+				lat, lon := OffsetPosition(51.5, -0.12, f.north, 0)
+				at := start.Add(time.Duration(f.seconds * float64(time.Second)))
+				if got, speed := filter.Accept(lat, lon, at); got != f.want {
+					t.Errorf("fix %d at %vm, %vs: Accept() = %t at %.1f m/s, want %t", i, f.north, f.seconds, got, speed, f.want)
+				}
+			}
+		})
+	}
+}
+
+func TestOutlierFilterSpeed(t *testing.T) {
+	filter := NewOutlierFilter(30)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if ok, speed := filter.Accept(51.5, -0.12, start); !ok || speed != 0 {
+		t.Errorf("first Accept() = %t, %v, want true, 0", ok, speed)
+	}
+	lat, lon := OffsetPosition(51.5, -0.12, 100, 0)
+	if ok, speed := filter.Accept(lat, lon, start.Add(10*time.Second)); !ok || math.Abs(speed-10) > 0.01 {
+		t.Errorf("Accept() after 100 m in 10 s = %t, %v, want true at 10 m/s", ok, speed)
+	}
+}
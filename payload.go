@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Supported values for PAYLOAD_FORMAT
+const (
+	PayloadFormatJSON        = "json"
+	PayloadFormatCloudEvents = "cloudevents"
+	PayloadFormatNMEA        = "nmea"
+	PayloadFormatMsgpack     = "msgpack"
+	PayloadFormatGeoJSON     = "geojson"
+	PayloadFormatCayenne     = "cayenne"
+	PayloadFormatInflux      = "influx"
+)
+
+// PayloadEncoder marshals GnssData into the configured wire format
+type PayloadEncoder struct {
+	Format string // One of the PayloadFormat constants
+	Source string // CloudEvents source attribute, used by the cloudevents format
+	CRC    bool   // Append a "crc" member holding the CRC-32 of the payload
+	EncKey []byte // AES-256-GCM key; when set the payload is published as base64(nonce+ciphertext)
+
+	Compression string // PayloadCompressionGzip to gzip the payload, before any encryption
+
+	NMEASplit    bool   // Emit per-constellation GGA sentences plus a combined $GNGGA
+	BeidouTalker string // Talker ID for BeiDou sentences, TalkerBeidou or TalkerBeidouLegacy
+
+	Rounding map[string]int // Decimal places per field, see ParseRoundingRules
+
+	InfluxMeasurement string            // Measurement name for the influx format
+	InfluxTags        map[string]string // Tag set for the influx format
+}
+
+// ValidatePayloadFormat checks that format is one of the supported payload formats
+func ValidatePayloadFormat(format string) error {
+	switch format {
+	case PayloadFormatJSON, PayloadFormatCloudEvents, PayloadFormatNMEA, PayloadFormatMsgpack, PayloadFormatGeoJSON,
+		PayloadFormatCayenne, PayloadFormatInflux:
+		return nil
+	default:
+		return fmt.Errorf("unsupported payload format: %q", format)
+	}
+}
+
+// NewPayloadEncoder validates the payload format and returns an encoder for it
+func NewPayloadEncoder(format, source string) (*PayloadEncoder, error) {
+	if err := ValidatePayloadFormat(format); err != nil {
+		return nil, err
+	}
+	return &PayloadEncoder{Format: format, Source: source, BeidouTalker: TalkerBeidou, InfluxMeasurement: DefaultInfluxMeasurement}, nil
+}
+
+// IsJSON reports whether the encoder produces a JSON object payload
+func (e *PayloadEncoder) IsJSON() bool {
+	return isJSONFormat(e.Format)
+}
+
+// isJSONFormat reports whether format produces a JSON object payload
+func isJSONFormat(format string) bool {
+	return format == PayloadFormatJSON || format == PayloadFormatCloudEvents || format == PayloadFormatGeoJSON
+}
+
+// ContentType returns the MIME type of the encoded payloads
+func (e *PayloadEncoder) ContentType() string {
+	switch {
+	case e.EncKey != nil || e.Format == PayloadFormatNMEA || e.Format == PayloadFormatInflux:
+		return "text/plain"
+	case e.Format == PayloadFormatMsgpack:
+		return "application/msgpack"
+	case e.Format == PayloadFormatGeoJSON:
+		return "application/geo+json"
+	case e.Format == PayloadFormatCayenne:
+		return "application/octet-stream"
+	default:
+		return "application/json"
+	}
+}
+
+// Gzipped reports whether the encoded payloads are gzip streams, i.e. compressed and not then
+// encrypted, which wraps them in base64 text
+func (e *PayloadEncoder) Gzipped() bool {
+	return e.Compression == PayloadCompressionGzip && e.EncKey == nil
+}
+
+// Charset returns the character encoding of the encoded payloads, "utf-8" for the text
+// formats and encrypted payloads, or "" when they're binary
+func (e *PayloadEncoder) Charset() string {
+	switch {
+	case e.EncKey != nil:
+		return "utf-8" // base64
+	case e.Gzipped(), e.Format == PayloadFormatMsgpack, e.Format == PayloadFormatCayenne:
+		return ""
+	default:
+		return "utf-8"
+	}
+}
+
+// Encode marshals data according to the encoder's format
+func (e *PayloadEncoder) Encode(data *GnssData) ([]byte, error) {
+	payload, err := e.marshal(e.round(data))
+	if err != nil {
+		return nil, err
+	}
+	return e.seal(payload)
+}
+
+// EncodePosition marshals the lightweight position-only payload as JSON, see GnssPosition
+func (e *PayloadEncoder) EncodePosition(data *GnssData) ([]byte, error) {
+	payload, err := json.Marshal(NewGnssPosition(e.round(data)))
+	if err != nil {
+		return nil, err
+	}
+	return e.seal(payload)
+}
+
+// EncodeSatellites marshals the satellite detail payload as JSON, see GnssSatellites
+func (e *PayloadEncoder) EncodeSatellites(data *GnssData) ([]byte, error) {
+	payload, err := json.Marshal(NewGnssSatellites(data))
+	if err != nil {
+		return nil, err
+	}
+	return e.seal(payload)
+}
+
+// round returns data with the rounding rules applied, leaving the original untouched
+func (e *PayloadEncoder) round(data *GnssData) *GnssData {
+	if len(e.Rounding) == 0 {
+		return data
+	}
+	rounded := *data
+	rounded.ApplyRounding(e.Rounding)
+	return &rounded
+}
+
+// seal appends the CRC member, compresses and encrypts the payload, as configured
+func (e *PayloadEncoder) seal(payload []byte) ([]byte, error) {
+	var err error
+	if e.CRC {
+		if payload, err = AppendCRCField(payload); err != nil {
+			return nil, err
+		}
+	}
+	if e.Compression == PayloadCompressionGzip {
+		if payload, err = compressPayload(payload); err != nil {
+			return nil, fmt.Errorf("failed to compress payload: %w", err)
+		}
+	}
+	if e.EncKey != nil {
+		sealed, err := encryptPayload(e.EncKey, payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt payload: %w", err)
+		}
+		payload = []byte(base64.StdEncoding.EncodeToString(sealed))
+	}
+	return payload, nil
+}
+
+// marshalMsgpack encodes data as a MessagePack map with the same keys as the JSON payload
+func marshalMsgpack(data *GnssData) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	if err := enc.Encode(data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// marshal encodes data in the configured format without any trailing integrity fields
+func (e *PayloadEncoder) marshal(data *GnssData) ([]byte, error) {
+	switch e.Format {
+	case PayloadFormatCloudEvents:
+		event, err := NewCloudEvent(CloudEventTypeFix, e.Source, data)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(event)
+	case PayloadFormatNMEA:
+		var sentences []string
+		if e.NMEASplit {
+			sentences = append(data.ConstellationGGA(e.BeidouTalker), data.RMC(TalkerCombined))
+		} else {
+			sentences = data.ToNMEA()
+		}
+		return []byte(strings.Join(sentences, "\r\n") + "\r\n"), nil
+	case PayloadFormatMsgpack:
+		return marshalMsgpack(data)
+	case PayloadFormatGeoJSON:
+		return data.MarshalGeoJSON()
+	case PayloadFormatCayenne:
+		return EncodeCayenneGPS(CayenneGPSChannel, data.Latitude, data.Longitude, data.Altitude), nil
+	case PayloadFormatInflux:
+		return []byte(data.ToLineProtocol(e.InfluxMeasurement, e.InfluxTags)), nil
+	default:
+		return json.Marshal(data)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	enc, err := NewPayloadEncoder(PayloadFormatMsgpack, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sampleFix().ToGnssData(false)
+	payload, err := enc.Encode(&want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got GnssData
+	dec := msgpack.NewDecoder(bytes.NewReader(payload))
+	dec.SetCustomStructTag("json")
+	if err := dec.Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+
+	jsonPayload, err := json.Marshal(&want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payload) >= len(jsonPayload) {
+		t.Errorf("msgpack payload is %d bytes, want it smaller than the %d byte JSON payload", len(payload), len(jsonPayload))
+	}
+}
+
+func TestMsgpackUsesJSONKeys(t *testing.T) {
+	payload, err := marshalMsgpack(&GnssData{Latitude: 51.5, Geohash: "gcpvj"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := msgpack.Unmarshal(payload, &got); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]any{"Latitude": 51.5, "Geohash": "gcpvj"} {
+		if got[key] != want {
+			t.Errorf("%s = %v, want %v", key, got[key], want)
+		}
+	}
+	if _, ok := got["Address"]; ok {
+		t.Error("empty Address was encoded despite omitempty")
+	}
+}
+
+func TestPayloadEncoderContentType(t *testing.T) {
+	tests := []struct {
+		format string
+		enc    bool
+		want   string
+	}{
+		{PayloadFormatJSON, false, "application/json"},
+		{PayloadFormatMsgpack, false, "application/msgpack"},
+		{PayloadFormatNMEA, false, "text/plain"},
+		{PayloadFormatMsgpack, true, "text/plain"},
+	}
+	for _, tt := range tests {
+		enc, err := NewPayloadEncoder(tt.format, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.enc {
+			enc.EncKey = make([]byte, PayloadKeySize)
+		}
+		if got := enc.ContentType(); got != tt.want {
+			t.Errorf("%s (encrypted %t) ContentType() = %q, want %q", tt.format, tt.enc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// postgresTimeout bounds a single batch insert
+const postgresTimeout = 10 * time.Second
+
+// pgTableName matches plain or schema-qualified unquoted table names, e.g. public.gnss_fixes
+var pgTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// pgRow is a fix waiting to be inserted
+type pgRow struct {
+	time       time.Time
+	data       GnssData
+	satellites int
+}
+
+// PostgresSink batches valid fixes and inserts them into a Postgres or TimescaleDB table.
+// database/sql reconnects lost connections and re-prepares the statement as needed; a batch
+// that fails to insert is kept and retried on the next flush, up to maxPending rows. Run does
+// all the flushing, so a slow or unreachable database never holds up the caller of Add.
+type PostgresSink struct {
+	db         *sql.DB
+	query      string
+	deviceID   string
+	batchSize  int
+	maxPending int
+	flushNow   chan struct{} // Signalled by Add when a full batch is waiting; holds at most one signal
+
+	flushMu sync.Mutex // Serializes flushes
+	insert  *sql.Stmt  // Prepared on the first flush so startup doesn't need the database
+
+	mu      sync.Mutex
+	pending []pgRow
+}
+
+// OpenPostgresSink creates a sink inserting into table, which must have the columns listed in
+// the README. No connection is made until the first flush.
+func OpenPostgresSink(dsn, table, deviceID string, batchSize int) (*PostgresSink, error) {
+	if !pgTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	if batchSize < 1 {
+		return nil, fmt.Errorf("batch size must be at least 1")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &PostgresSink{
+		db: db,
+		query: fmt.Sprintf("INSERT INTO %s (time, device_id, latitude, longitude, altitude, speed, hdop, satellites, data) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)", table),
+		deviceID:   deviceID,
+		batchSize:  batchSize,
+		maxPending: batchSize * 100,
+		flushNow:   make(chan struct{}, 1),
+	}, nil
+}
+
+// Add queues a fix for insertion. Once a full batch is waiting, Run is asked to flush early;
+// while a flush is already pending or in progress, further requests are merged into it.
+func (s *PostgresSink) Add(data *GnssData, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) >= s.maxPending {
+		s.pending = s.pending[1:]
+		log.Println("Postgres backlog full, dropped oldest pending fix")
+	}
+	s.pending = append(s.pending, pgRow{
+		time:       now,
+		data:       *data,
+		satellites: data.SatellitesInView(),
+	})
+	if len(s.pending) >= s.batchSize {
+		select {
+		case s.flushNow <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush inserts the pending fixes in a single transaction. On failure they're put back at the
+// front of the queue to be retried. Add isn't blocked while the insert runs.
+func (s *PostgresSink) Flush(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	if err := s.insertBatch(ctx, batch); err != nil {
+		s.mu.Lock()
+		s.pending = append(batch, s.pending...)
+		if over := len(s.pending) - s.maxPending; over > 0 {
+			s.pending = s.pending[over:]
+			log.Printf("Postgres backlog full, dropped %d oldest pending fixes", over)
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// insertBatch inserts rows in one transaction, preparing the statement if needed
+func (s *PostgresSink) insertBatch(ctx context.Context, rows []pgRow) error {
+	ctx, cancel := context.WithTimeout(ctx, postgresTimeout)
+	defer cancel()
+	if s.insert == nil {
+		insert, err := s.db.PrepareContext(ctx, s.query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare insert: %w", err)
+		}
+		s.insert = insert
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin insert: %w", err)
+	}
+	stmt := tx.StmtContext(ctx, s.insert)
+	for _, row := range rows {
+		raw, err := json.Marshal(row.data)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		d := row.data
+		if _, err := stmt.ExecContext(ctx, row.time, s.deviceID, d.Latitude, d.Longitude, d.Altitude,
+			d.Speed, d.Hdop, row.satellites, string(raw)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to insert fix: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit inserts: %w", err)
+	}
+	return nil
+}
+
+// Run flushes every interval, and whenever Add has a full batch, until ctx is cancelled
+func (s *PostgresSink) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.flushNow:
+		case <-ctx.Done():
+			return
+		}
+		if err := s.Flush(ctx); err != nil {
+			log.Printf("Failed to flush fixes to Postgres: %v", err)
+		}
+	}
+}
+
+// Close flushes the remaining fixes and closes the database
+func (s *PostgresSink) Close() error {
+	err := s.Flush(context.Background())
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	if s.insert != nil {
+		s.insert.Close()
+	}
+	if closeErr := s.db.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDB is a database/sql driver recording the statements prepared and executed on it
+type fakeDB struct {
+	mu        sync.Mutex
+	prepared  []string
+	execs     [][]driver.Value
+	commits   int
+	rollbacks int
+	failExec  error // Returned by every Exec while set
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.prepared = append(c.db.prepared, query)
+	return &fakeStmt{db: c.db}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return &fakeTx{db: c.db}, nil }
+
+type fakeTx struct{ db *fakeDB }
+
+func (tx *fakeTx) Commit() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	tx.db.commits++
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	tx.db.rollbacks++
+	return nil
+}
+
+type fakeStmt struct{ db *fakeDB }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if s.db.failExec != nil {
+		return nil, s.db.failExec
+	}
+	s.db.execs = append(s.db.execs, args)
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+// newFakePostgresSink opens a sink whose database is db rather than a real Postgres server
+func newFakePostgresSink(t *testing.T, db *fakeDB, table string, batchSize int) *PostgresSink {
+	t.Helper()
+	sink, err := OpenPostgresSink("postgres://localhost/gnss", table, "tachyon-1", batchSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.db.Close()
+	sink.db = sql.OpenDB(db)
+	return sink
+}
+
+func TestPostgresSinkInsertsBatch(t *testing.T) {
+	db := &fakeDB{}
+	sink := newFakePostgresSink(t, db, "public.gnss_fixes", 10)
+	at := time.Date(2024, 6, 1, 12, 30, 15, 0, time.UTC)
+	fixes := []GnssData{
+		{Latitude: 51.5007, Longitude: -0.1246, Altitude: 35.2, Speed: 4.5, Hdop: 0.9, Svnum: 9, BeidouSvnum: 3},
+		{Latitude: 51.5008, Longitude: -0.1247, Altitude: 35.4, Speed: 4.7, Hdop: 1.1, Svnum: 8},
+	}
+	for i := range fixes {
+		sink.Add(&fixes[i], at.Add(time.Duration(i)*time.Second))
+	}
+	if err := sink.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() = %v", err)
+	}
+
+	wantQuery := "INSERT INTO public.gnss_fixes (time, device_id, latitude, longitude, altitude, speed, hdop, satellites, data) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	if len(db.prepared) == 0 || db.prepared[0] != wantQuery {
+		t.Errorf("prepared %q, want %q", db.prepared, wantQuery)
+	}
+	if db.commits != 1 || db.rollbacks != 0 {
+		t.Errorf("%d commits and %d rollbacks, want the batch in one transaction", db.commits, db.rollbacks)
+	}
+	if len(db.execs) != len(fixes) {
+		t.Fatalf("%d inserts, want %d", len(db.execs), len(fixes))
+	}
+	for i, args := range db.execs {
+		d := fixes[i]
+		raw, err := json.Marshal(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []driver.Value{
+			at.Add(time.Duration(i) * time.Second), "tachyon-1", d.Latitude, d.Longitude, d.Altitude,
+			d.Speed, d.Hdop, int64(d.SatellitesInView()), string(raw),
+		}
+		if !reflect.DeepEqual(args, want) {
+			t.Errorf("insert %d parameters = %v, want %v", i, args, want)
+		}
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+}
+
+func TestPostgresSinkRetriesFailedBatch(t *testing.T) {
+	db := &fakeDB{failExec: errors.New("connection reset by peer")}
+	sink := newFakePostgresSink(t, db, "gnss_fixes", 10)
+	at := time.Date(2024, 6, 1, 12, 30, 15, 0, time.UTC)
+	sink.Add(&GnssData{Latitude: 51.5, Longitude: -0.12}, at)
+
+	if err := sink.Flush(context.Background()); err == nil {
+		t.Fatal("Flush() with a failing insert succeeded")
+	}
+	if db.rollbacks != 1 || db.commits != 0 {
+		t.Errorf("%d rollbacks and %d commits after a failed insert, want 1 and 0", db.rollbacks, db.commits)
+	}
+
+	db.mu.Lock()
+	db.failExec = nil
+	db.mu.Unlock()
+	sink.Add(&GnssData{Latitude: 51.6, Longitude: -0.13}, at.Add(time.Second))
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	if len(db.execs) != 2 {
+		t.Fatalf("%d inserts after recovering, want the failed fix retried plus the new one", len(db.execs))
+	}
+	if db.execs[0][2] != 51.5 || db.execs[1][2] != 51.6 {
+		t.Errorf("inserted latitudes %v and %v, want the retried fix first", db.execs[0][2], db.execs[1][2])
+	}
+}
+
+func TestOpenPostgresSinkRejectsBadConfig(t *testing.T) {
+	for _, table := range []string{"", "fixes; DROP TABLE fixes", "a.b.c", "1fixes", `"fixes"`} {
+		if _, err := OpenPostgresSink("postgres://localhost/gnss", table, "tachyon-1", 10); err == nil {
+			t.Errorf("OpenPostgresSink() with table %q succeeded, want an error", table)
+		}
+	}
+	if _, err := OpenPostgresSink("postgres://localhost/gnss", "gnss_fixes", "tachyon-1", 0); err == nil {
+		t.Error("OpenPostgresSink() with a batch size of 0 succeeded, want an error")
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeWindow is a daily local time-of-day range in minutes since midnight.
+// Windows whose end is before their start wrap past midnight.
+type TimeWindow struct {
+	Start int // Inclusive start, minutes since midnight
+	End   int // Exclusive end, minutes since midnight
+}
+
+// ParseTimeWindows parses a comma-separated list of "HH:MM-HH:MM" windows
+func ParseTimeWindows(s string) ([]TimeWindow, error) {
+	var windows []TimeWindow
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		start, end, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid time window %q, expected HH:MM-HH:MM", part)
+		}
+		sh, sm, err := ParseTimeOfDay(strings.TrimSpace(start))
+		if err != nil {
+			return nil, err
+		}
+		eh, em, err := ParseTimeOfDay(strings.TrimSpace(end))
+		if err != nil {
+			return nil, err
+		}
+		w := TimeWindow{Start: sh*60 + sm, End: eh*60 + em}
+		if w.Start == w.End {
+			return nil, fmt.Errorf("invalid time window %q: start and end are equal", part)
+		}
+		windows = append(windows, w)
+	}
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("no time windows in %q", s)
+	}
+	return windows, nil
+}
+
+// Contains reports whether t's local time of day falls inside the window
+func (w TimeWindow) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.Start < w.End {
+		return m >= w.Start && m < w.End
+	}
+	return m >= w.Start || m < w.End
+}
+
+// PublishGate decides whether a fix may be published. The time-of-day windows are checked
+// first, then the moving constraint; a fix is published only when every enabled constraint passes.
+type PublishGate struct {
+	Windows         []TimeWindow  // Allowed local time windows, nil to allow any time
+	RequireMoving   bool          // Only publish while the device is moving
+	MovingThreshold float64       // Minimum speed counted as moving
+	MinMoveMeters   float64       // Minimum distance from the last published fix, 0 to publish every fix
+	Heartbeat       time.Duration // Publish at least this often while MinMoveMeters holds fixes back
+
+	lastLat, lastLon float64 // Position of the last allowed valid fix
+	lastAt           time.Time
+	haveLast         bool
+}
+
+// IsMoving reports whether the fix is valid and its speed reaches the threshold
+func (g *PublishGate) IsMoving(data *GnssData) bool {
+	return data.Valid != 0 && data.Speed >= g.MovingThreshold
+}
+
+// Allow reports whether data may be published at now, with the reason when it may not.
+// Allowed valid fixes become the reference for the MinMoveMeters check.
+func (g *PublishGate) Allow(data *GnssData, now time.Time) (bool, string) {
+	if len(g.Windows) > 0 {
+		inWindow := false
+		for _, w := range g.Windows {
+			if w.Contains(now) {
+				inWindow = true
+				break
+			}
+		}
+		if !inWindow {
+			return false, fmt.Sprintf("outside publish windows at %s", now.Format("15:04"))
+		}
+	}
+	if g.RequireMoving && !g.IsMoving(data) {
+		return false, fmt.Sprintf("stationary (speed %.2f below %.2f)", data.Speed, g.MovingThreshold)
+	}
+	if g.MinMoveMeters > 0 && data.Valid != 0 {
+		if g.haveLast && now.Sub(g.lastAt) < g.Heartbeat {
+			if moved := Haversine(g.lastLat, g.lastLon, data.Latitude, data.Longitude); moved < g.MinMoveMeters {
+				return false, fmt.Sprintf("moved %.1f m, below %.1f m", moved, g.MinMoveMeters)
+			}
+		}
+		g.lastLat, g.lastLon, g.lastAt, g.haveLast = data.Latitude, data.Longitude, now, true
+	}
+	return true, ""
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPublishGateCombinations(t *testing.T) {
+	workHours := []TimeWindow{{Start: 9 * 60, End: 17 * 60}}
+	inWindow := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+	outOfWindow := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	moving := &GnssData{Valid: 1, Speed: 12}
+	stationary := &GnssData{Valid: 1, Speed: 0.2}
+	tests := []struct {
+		name       string
+		now        time.Time
+		data       *GnssData
+		want       bool
+		wantReason string
+	}{
+		{"in window, moving", inWindow, moving, true, ""},
+		{"in window, stationary", inWindow, stationary, false, "stationary (speed 0.20 below 1.00)"},
+		{"out of window, moving", outOfWindow, moving, false, "outside publish windows at 20:00"},
+		{"out of window, stationary", outOfWindow, stationary, false, "outside publish windows at 20:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &PublishGate{Windows: workHours, RequireMoving: true, MovingThreshold: 1}
+			got, reason := g.Allow(tt.data, tt.now)
+			if got != tt.want || reason != tt.wantReason {
+				t.Errorf("Allow() = %t, %q, want %t, %q", got, reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestPublishGateMinMove(t *testing.T) {
+	g := &PublishGate{MinMoveMeters: 50, Heartbeat: time.Minute}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	steps := []struct {
+		name  string
+		after time.Duration
+		lat   float64
+		want  bool
+	}{
+		{"first fix", 0, 51, true},
+		{"moved 11 m", 10 * time.Second, 51.0001, false},
+		{"moved 111 m", 20 * time.Second, 51.001, true},
+		{"stationary until heartbeat", 80 * time.Second, 51.001, true},
+	}
+	for _, step := range steps {
+		got, _ := g.Allow(&GnssData{Valid: 1, Latitude: step.lat, Longitude: -1}, start.Add(step.after))
+		if got != step.want {
+			t.Errorf("%s: Allow() = %t, want %t", step.name, got, step.want)
+		}
+	}
+}
+
+func TestTimeWindowContains(t *testing.T) {
+	tests := []struct {
+		window string
+		at     string
+		want   bool
+	}{
+		{"09:00-17:00", "09:00", true},
+		{"09:00-17:00", "16:59", true},
+		{"09:00-17:00", "17:00", false},
+		{"09:00-17:00", "08:59", false},
+		{"22:00-06:00", "23:30", true},
+		{"22:00-06:00", "05:59", true},
+		{"22:00-06:00", "12:00", false},
+	}
+	for _, tt := range tests {
+		windows, err := ParseTimeWindows(tt.window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		at, err := time.Parse("15:04", tt.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := windows[0].Contains(at); got != tt.want {
+			t.Errorf("%s contains %s = %t, want %t", tt.window, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimeWindows(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []TimeWindow
+		wantErr bool
+	}{
+		{"09:00-17:00", []TimeWindow{{540, 1020}}, false},
+		{"07:00-09:30, 16:00-18:00", []TimeWindow{{420, 570}, {960, 1080}}, false},
+		{"09:00", nil, true},
+		{"09:00-09:00", nil, true},
+		{"25:00-26:00", nil, true},
+		{"", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeWindows(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTimeWindows(%q) error = %v, want error %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseTimeWindows(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ParseTimeWindows(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		}
+	}
+}
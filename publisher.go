@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// mqttPublishTimeout bounds how long a fix publish waits on the broker, over MQTT 3.1.1 or 5,
+// so a stalled connection can't hold up the poll loop
+const mqttPublishTimeout = 10 * time.Second
+
+// Publisher delivers a payload to a destination. Properties carry message metadata for
+// transports that support it and are ignored by those that don't.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, payload []byte, properties map[string]string) error
+}
+
+// MQTTPublisher publishes fix payloads to the MQTT broker
+type MQTTPublisher struct {
+	Client mqtt.Client
+	QoS    byte // MQTT_QOS
+	Retain bool // MQTT_RETAIN, so new subscribers get the last known position immediately
+}
+
+// Publish sends payload to topic; MQTT 3.1.1 has no message properties so they're dropped
+func (p *MQTTPublisher) Publish(_ context.Context, topic string, payload []byte, _ map[string]string) error {
+	return publishQoS(p.Client, topic, p.QoS, p.Retain, payload)
+}
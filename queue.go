@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// queueEntrySuffix is the file extension of persisted queue entries
+const queueEntrySuffix = ".msg"
+
+// QueuedMessage is a payload waiting to be published
+type QueuedMessage struct {
+	Topic   string `json:"topic"`
+	Payload []byte `json:"payload"`
+	Sink    string `json:"sink,omitempty"` // WebhookSink, or MQTTSink
+}
+
+// MQTTSink is the QueuedMessage.Sink of messages that failed to reach the MQTT broker
+const MQTTSink = ""
+
+// queueEntry describes a persisted message file
+type queueEntry struct {
+	seq  uint64
+	size int64
+	sink string // The message's Sink, so Drain can pick out a sink's entries without reading the rest
+}
+
+// PersistentQueue is a bounded FIFO of unpublished messages stored one file per entry in a
+// directory, so it survives restarts. When full, the oldest entries are evicted.
+type PersistentQueue struct {
+	dir        string
+	maxBytes   int64
+	maxEntries int // 0 for no limit on the number of entries
+
+	mu      sync.Mutex
+	entries []queueEntry // Oldest first
+	size    int64        // Total bytes of all entries
+	nextSeq uint64
+
+	draining map[string]bool // Sinks with a Drain in progress
+}
+
+// OpenPersistentQueue opens (creating if needed) a queue in dir holding at most maxBytes and,
+// unless maxEntries is 0, at most maxEntries messages
+func OpenPersistentQueue(dir string, maxBytes int64, maxEntries int) (*PersistentQueue, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("queue size limit must be positive")
+	}
+	if maxEntries < 0 {
+		return nil, fmt.Errorf("queue entry limit must not be negative")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue directory: %w", err)
+	}
+	q := &PersistentQueue{dir: dir, maxBytes: maxBytes, maxEntries: maxEntries, nextSeq: 1, draining: map[string]bool{}}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, queueEntrySuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, queueEntrySuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			return nil, err
+		}
+		entry := queueEntry{seq: seq, size: info.Size()}
+		// An unreadable entry is left to Drain to drop with the MQTT sink's entries
+		var msg QueuedMessage
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err == nil && json.Unmarshal(data, &msg) == nil {
+			entry.sink = msg.Sink
+		}
+		q.entries = append(q.entries, entry)
+		q.size += info.Size()
+		q.nextSeq = max(q.nextSeq, seq+1)
+	}
+	sort.Slice(q.entries, func(i, j int) bool { return q.entries[i].seq < q.entries[j].seq })
+	return q, nil
+}
+
+// Len returns the number of queued messages
+func (q *PersistentQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// Enqueue persists msg at the tail, evicting the oldest entries if the size or entry limit would be exceeded
+func (q *PersistentQueue) Enqueue(msg QueuedMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	size := int64(len(data))
+	if size > q.maxBytes {
+		return fmt.Errorf("message of %d bytes exceeds queue limit of %d bytes", size, q.maxBytes)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.entries) > 0 && (q.size+size > q.maxBytes || (q.maxEntries > 0 && len(q.entries) >= q.maxEntries)) {
+		oldest := q.entries[0]
+		if err := os.Remove(q.path(oldest.seq)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to evict queue entry: %w", err)
+		}
+		q.entries = q.entries[1:]
+		q.size -= oldest.size
+		log.Printf("Publish queue full, dropped oldest queued message %d", oldest.seq)
+	}
+	seq := q.nextSeq
+	if err := writeFileAtomic(q.path(seq), data, 0o644); err != nil {
+		return fmt.Errorf("failed to persist queue entry: %w", err)
+	}
+	q.nextSeq++
+	q.entries = append(q.entries, queueEntry{seq: seq, size: size, sink: msg.Sink})
+	q.size += size
+	return nil
+}
+
+// Drain sends the messages queued for sink oldest first, removing each once send succeeds, and
+// leaves other sinks' messages queued. It stops at the first failure, leaving that message and
+// the rest queued, and returns how many were sent. The queue isn't locked while send runs, so
+// messages can be enqueued meanwhile. A Drain of a sink that's already being drained returns
+// straight away.
+func (q *PersistentQueue) Drain(sink string, send func(QueuedMessage) error) (int, error) {
+	q.mu.Lock()
+	if q.draining[sink] {
+		q.mu.Unlock()
+		return 0, nil
+	}
+	q.draining[sink] = true
+	q.mu.Unlock()
+	defer func() {
+		q.mu.Lock()
+		delete(q.draining, sink)
+		q.mu.Unlock()
+	}()
+
+	sent := 0
+	var last uint64
+	for {
+		head, ok := q.next(sink, last)
+		if !ok {
+			return sent, nil
+		}
+		last = head.seq
+		data, err := os.ReadFile(q.path(head.seq))
+		var msg QueuedMessage
+		if err == nil {
+			err = json.Unmarshal(data, &msg)
+		}
+		if os.IsNotExist(err) {
+			// Evicted by Enqueue since next returned it
+		} else if err != nil {
+			// An unreadable entry can never be delivered, so drop it rather than block the queue
+			log.Printf("Dropping unreadable queue entry %d: %v", head.seq, err)
+		} else if err := send(msg); err != nil {
+			return sent, err
+		} else {
+			sent++
+		}
+		if err := q.remove(head.seq); err != nil {
+			return sent, err
+		}
+	}
+}
+
+// next returns the oldest entry for sink after the sequence number last
+func (q *PersistentQueue) next(sink string, last uint64) (queueEntry, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, entry := range q.entries {
+		if entry.seq > last && entry.sink == sink {
+			return entry, true
+		}
+	}
+	return queueEntry{}, false
+}
+
+// remove deletes the entry with the given sequence number, if it hasn't been evicted already
+func (q *PersistentQueue) remove(seq uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, entry := range q.entries {
+		if entry.seq != seq {
+			continue
+		}
+		if err := os.Remove(q.path(seq)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove queue entry: %w", err)
+		}
+		q.entries = append(q.entries[:i], q.entries[i+1:]...)
+		q.size -= entry.size
+		return nil
+	}
+	return nil
+}
+
+// path returns the file path of the entry with the given sequence number
+func (q *PersistentQueue) path(seq uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", seq, queueEntrySuffix))
+}
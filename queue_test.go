@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// queuedSize is the number of bytes msg takes up in the queue
+func queuedSize(t *testing.T, msg QueuedMessage) int64 {
+	t.Helper()
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int64(len(data))
+}
+
+// drainAll drains the MQTT messages in q and returns the payloads sent, oldest first
+func drainAll(t *testing.T, q *PersistentQueue) []string {
+	t.Helper()
+	var got []string
+	if _, err := q.Drain(MQTTSink, func(msg QueuedMessage) error {
+		got = append(got, string(msg.Payload))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+// testMessage returns the i'th of a series of equally sized messages
+func testMessage(i int) QueuedMessage {
+	return QueuedMessage{Topic: "tachyon/gnss", Payload: []byte(fmt.Sprintf("fix-%02d", i))}
+}
+
+func TestPersistentQueueFIFO(t *testing.T) {
+	dir := t.TempDir()
+	q, err := OpenPersistentQueue(dir, 1<<20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 5 {
+		if err := q.Enqueue(testMessage(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A restart picks up the persisted entries in the same order
+	q, err = OpenPersistentQueue(dir, 1<<20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(testMessage(5)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"fix-00", "fix-01", "fix-02", "fix-03", "fix-04", "fix-05"}
+	if got := drainAll(t, q); !reflect.DeepEqual(got, want) {
+		t.Errorf("drained %v, want %v", got, want)
+	}
+	if files, _ := os.ReadDir(dir); q.Len() != 0 || len(files) != 0 {
+		t.Errorf("queue holds %d entries and %d files after draining, want none", q.Len(), len(files))
+	}
+}
+
+func TestPersistentQueueEvictsOldest(t *testing.T) {
+	size := queuedSize(t, testMessage(0))
+	tests := []struct {
+		name     string
+		maxBytes int64
+		enqueue  int
+		want     []string
+	}{
+		{"under the limit", 3 * size, 3, []string{"fix-00", "fix-01", "fix-02"}},
+		{"one over the limit", 3 * size, 4, []string{"fix-01", "fix-02", "fix-03"}},
+		{"limit between entries", 3*size - 1, 4, []string{"fix-02", "fix-03"}},
+		{"room for one", size, 3, []string{"fix-02"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := OpenPersistentQueue(t.TempDir(), tt.maxBytes, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range tt.enqueue {
+				if err := q.Enqueue(testMessage(i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := drainAll(t, q); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("drained %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPersistentQueueRejectsOversized(t *testing.T) {
+	q, err := OpenPersistentQueue(t.TempDir(), 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(testMessage(0)); err == nil {
+		t.Error("Enqueue() of a message larger than the queue succeeded")
+	}
+	if q.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", q.Len())
+	}
+}
+
+func TestPersistentQueueDrainStopsAtFailure(t *testing.T) {
+	q, err := OpenPersistentQueue(t.TempDir(), 1<<20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		if err := q.Enqueue(testMessage(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	errOffline := errors.New("offline")
+	sent, err := q.Drain(MQTTSink, func(msg QueuedMessage) error {
+		if string(msg.Payload) == "fix-01" {
+			return errOffline
+		}
+		return nil
+	})
+	if sent != 1 || !errors.Is(err, errOffline) {
+		t.Fatalf("Drain() = %d, %v, want 1 sent and the send error", sent, err)
+	}
+	if got, want := drainAll(t, q), []string{"fix-01", "fix-02"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("remaining %v, want %v", got, want)
+	}
+}
+
+func TestOpenPersistentQueueLimits(t *testing.T) {
+	if _, err := OpenPersistentQueue(t.TempDir(), 0, 0); err == nil {
+		t.Error("OpenPersistentQueue() with no size limit succeeded")
+	}
+	if _, err := OpenPersistentQueue(t.TempDir(), 1024, -1); err == nil {
+		t.Error("OpenPersistentQueue() with a negative entry limit succeeded")
+	}
+}
+
+func TestPersistentQueueEntryLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxEntries int
+		enqueue    int
+		want       []string
+	}{
+		{"unlimited", 0, 4, []string{"fix-00", "fix-01", "fix-02", "fix-03"}},
+		{"at the limit", 3, 3, []string{"fix-00", "fix-01", "fix-02"}},
+		{"over the limit", 3, 5, []string{"fix-02", "fix-03", "fix-04"}},
+		{"single entry", 1, 3, []string{"fix-02"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := OpenPersistentQueue(t.TempDir(), 1<<20, tt.maxEntries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range tt.enqueue {
+				if err := q.Enqueue(testMessage(i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := drainAll(t, q); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("drained %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPersistentQueueDrainOnReconnect(t *testing.T) {
+	q, err := OpenPersistentQueue(t.TempDir(), 1<<20, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	connected := false
+	var delivered []QueuedMessage
+	publish := func(msg QueuedMessage) error {
+		if !connected {
+			return errors.New("not connected")
+		}
+		delivered = append(delivered, msg)
+		return nil
+	}
+
+	// Payloads failing to publish while the broker is down are queued
+	outage := []QueuedMessage{testMessage(0), {Topic: "hooks", Payload: []byte("fix-01"), Sink: WebhookSink}, testMessage(2)}
+	for _, msg := range outage {
+		if err := publish(msg); err != nil {
+			if err := q.Enqueue(msg); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if sent, err := q.Drain(MQTTSink, publish); sent != 0 || err == nil || q.Len() != 3 {
+		t.Fatalf("Drain() while disconnected = %d, %v with %d queued, want nothing sent", sent, err, q.Len())
+	}
+
+	connected = true
+	if sent, err := q.Drain(MQTTSink, publish); sent != 2 || err != nil {
+		t.Fatalf("Drain() after reconnecting = %d, %v, want the 2 MQTT messages sent", sent, err)
+	}
+	if sent, err := q.Drain(WebhookSink, publish); sent != 1 || err != nil {
+		t.Fatalf("Drain() of the webhook = %d, %v, want 1 sent", sent, err)
+	}
+	if want := []QueuedMessage{outage[0], outage[2], outage[1]}; !reflect.DeepEqual(delivered, want) {
+		t.Errorf("delivered %+v, want %+v", delivered, want)
+	}
+}
+
+func TestPersistentQueueDrainSkipsOtherSinks(t *testing.T) {
+	dir := t.TempDir()
+	q, err := OpenPersistentQueue(dir, 1<<20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook := func(i int) QueuedMessage {
+		return QueuedMessage{Topic: "hooks", Payload: []byte(fmt.Sprintf("hook-%02d", i)), Sink: WebhookSink}
+	}
+	for _, msg := range []QueuedMessage{testMessage(0), hook(1), testMessage(2), hook(3)} {
+		if err := q.Enqueue(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A failing broker leaves its backlog queued without holding up the webhook's, which is
+	// found again after a restart
+	if _, err := q.Drain(MQTTSink, func(QueuedMessage) error { return errors.New("offline") }); err == nil {
+		t.Fatal("Drain() of an offline broker succeeded")
+	}
+	if q, err = OpenPersistentQueue(dir, 1<<20, 0); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	sent, err := q.Drain(WebhookSink, func(msg QueuedMessage) error {
+		got = append(got, string(msg.Payload))
+		// Messages arriving mid-drain are sent too, rather than deadlocking
+		if len(got) == 1 {
+			return q.Enqueue(hook(4))
+		}
+		return nil
+	})
+	if want := []string{"hook-01", "hook-03", "hook-04"}; sent != 3 || err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Drain() = %d, %v sending %v, want %v", sent, err, got, want)
+	}
+	if got, want := drainAll(t, q), []string{"fix-00", "fix-02"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MQTT backlog %v, want %v", got, want)
+	}
+}
+
+func TestPersistentQueueDrainOnce(t *testing.T) {
+	q, err := OpenPersistentQueue(t.TempDir(), 1<<20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 2 {
+		if err := q.Enqueue(testMessage(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var nested int
+	sent, err := q.Drain(MQTTSink, func(QueuedMessage) error {
+		// A second drain of the same sink would send the message being sent again
+		var err error
+		nested, err = q.Drain(MQTTSink, func(QueuedMessage) error { return nil })
+		return err
+	})
+	if sent != 2 || err != nil || nested != 0 {
+		t.Errorf("Drain() = %d, %v with %d sent by the nested drain, want 2 and none", sent, err, nested)
+	}
+}
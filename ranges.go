@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Supported values for RANGE_CHECK
+const (
+	RangeCheckOff    = "off"    // Decode values as reported
+	RangeCheckWarn   = "warn"   // Log out-of-range values but leave the fix alone
+	RangeCheckStrict = "strict" // Log out-of-range values and clear the fix's valid flag
+)
+
+// Plausible bounds for decoded values. Altitudes span the Dead Sea shore to well above
+// airliner cruise; 99.99 is the largest DOP the NMEA fields can carry.
+const (
+	MinPlausibleAltitude = -1000.0
+	MaxPlausibleAltitude = 50000.0
+	MaxPlausibleDop      = 99.99
+)
+
+// RangeProblems describes every decoded value outside its valid or plausible range: latitude
+// outside [-90, 90], longitude outside [-180, 180], altitude outside MinPlausibleAltitude to
+// MaxPlausibleAltitude, and DOPs that are negative or above MaxPlausibleDop. NaN is always out
+// of range.
+func (d *GnssFullData) RangeProblems() []string {
+	var problems []string
+	check := func(name string, v, lo, hi float64) {
+		if !(v >= lo && v <= hi) {
+			problems = append(problems, fmt.Sprintf("%s %g outside [%g, %g]", name, v, lo, hi))
+		}
+	}
+	check("latitude", d.Latitude, -90, 90)
+	check("longitude", d.Longitude, -180, 180)
+	check("altitude", d.Altitude, MinPlausibleAltitude, MaxPlausibleAltitude)
+	check("pdop", d.Pdop, 0, MaxPlausibleDop)
+	check("hdop", d.Hdop, 0, MaxPlausibleDop)
+	check("vdop", d.Vdop, 0, MaxPlausibleDop)
+	return problems
+}
+
+// applyRangeCheck logs out-of-range values in a fix flagged valid and, under RangeCheckStrict,
+// clears the flag so the fix is handled like any other without a usable position. Fixes the
+// modem already flags invalid are left alone, since their values are meaningless anyway.
+func (d *GnssFullData) applyRangeCheck(mode string) {
+	if mode == RangeCheckOff || d.Valid == 0 {
+		return
+	}
+	problems := d.RangeProblems()
+	if len(problems) == 0 {
+		return
+	}
+	if mode == RangeCheckStrict {
+		d.Valid = 0
+		log.Printf("Marked GNSS data invalid: %s", strings.Join(problems, ", "))
+		return
+	}
+	log.Printf("GNSS data out of range: %s", strings.Join(problems, ", "))
+}
+
+// ValidateRangeCheck checks a RANGE_CHECK value
+func ValidateRangeCheck(mode string) error {
+	switch mode {
+	case RangeCheckOff, RangeCheckWarn, RangeCheckStrict:
+		return nil
+	}
+	return fmt.Errorf("must be %q, %q or %q, got %q", RangeCheckOff, RangeCheckWarn, RangeCheckStrict, mode)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// validFixVariants returns the variant map of a valid 3D fix with plausible values
+func validFixVariants() map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"valid":     dbus.MakeVariant(int32(1)),
+		"fixmode":   dbus.MakeVariant(uint8(3)),
+		"latitude":  dbus.MakeVariant(51.5007),
+		"longitude": dbus.MakeVariant(-0.1246),
+		"altitude":  dbus.MakeVariant(35.2),
+		"pdop":      dbus.MakeVariant(1.8),
+		"hdop":      dbus.MakeVariant(0.9),
+		"vdop":      dbus.MakeVariant(1.5),
+	}
+}
+
+func TestDecodeGnssRangeCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value any
+		ok    bool // Whether the value is in range
+	}{
+		{"plausible fix", "", nil, true},
+		{"latitude above 90", "latitude", 90.5, false},
+		{"latitude below -90", "latitude", "-91", false},
+		{"latitude at the pole", "latitude", 90.0, true},
+		{"longitude above 180", "longitude", 181.0, false},
+		{"longitude below -180", "longitude", -180.01, false},
+		{"longitude at the antimeridian", "longitude", -180.0, true},
+		{"NaN latitude", "latitude", math.NaN(), false},
+		{"altitude below the Dead Sea", "altitude", -1500.0, false},
+		{"altitude above cruise", "altitude", 99999.0, false},
+		{"altitude at sea level", "altitude", 0.0, true},
+		{"negative HDOP", "hdop", -0.5, false},
+		{"absurd PDOP", "pdop", 150.0, false},
+		{"largest VDOP", "vdop", 99.99, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, mode := range []string{RangeCheckOff, RangeCheckWarn, RangeCheckStrict} {
+				result := validFixVariants()
+				if tt.key != "" {
+					result[tt.key] = dbus.MakeVariant(tt.value)
+				}
+				data, err := decodeGnss(result, mode)
+				if err != nil {
+					t.Fatalf("decodeGnss() = %v", err)
+				}
+				if problems := data.RangeProblems(); (len(problems) == 0) != tt.ok {
+					t.Errorf("RangeProblems() = %q, want in range %t", problems, tt.ok)
+				}
+				wantValid := tt.ok || mode != RangeCheckStrict
+				if (data.Valid == 1) != wantValid {
+					t.Errorf("with RANGE_CHECK=%s, Valid = %d, want valid %t", mode, data.Valid, wantValid)
+				}
+			}
+		})
+	}
+}
+
+func TestRangeCheckLeavesInvalidFixAlone(t *testing.T) {
+	data := GnssFullData{Valid: 0, Latitude: 200}
+	data.applyRangeCheck(RangeCheckStrict)
+	if data.Valid != 0 || data.Latitude != 200 {
+		t.Errorf("applyRangeCheck() changed a fix already flagged invalid to %+v", data)
+	}
+}
+
+func TestValidateRangeCheck(t *testing.T) {
+	for _, mode := range []string{RangeCheckOff, RangeCheckWarn, RangeCheckStrict} {
+		if err := ValidateRangeCheck(mode); err != nil {
+			t.Errorf("ValidateRangeCheck(%q) = %v", mode, err)
+		}
+	}
+	for _, mode := range []string{"", "STRICT", "on"} {
+		if err := ValidateRangeCheck(mode); err == nil {
+			t.Errorf("ValidateRangeCheck(%q) succeeded, want an error", mode)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+)
+
+// HotReloadKeys are the settings a SIGHUP reload applies without a restart. Every other setting
+// is only read at startup.
+var HotReloadKeys = []string{
+	"POLL_INTERVAL_SECONDS", "PUBLISH_INVALID_FIXES", "MOVING_SPEED_THRESHOLD",
+	"MIN_MOVE_METERS", "HEARTBEAT_SECONDS", "EVENT_MIN_INTERVAL_SECONDS",
+}
+
+// ReloadableSettings are the settings a SIGHUP reload can change, one per HotReloadKeys entry
+type ReloadableSettings struct {
+	PollInterval     time.Duration
+	PublishInvalid   bool
+	MovingThreshold  float64       // Minimum speed counted as moving, in SPEED_UNIT
+	MinMoveMeters    float64       // Minimum distance from the last published fix, 0 to publish every fix
+	Heartbeat        time.Duration // Publish at least this often while MinMoveMeters holds fixes back
+	EventMinInterval time.Duration // Minimum time between events of the same kind, 0 to publish every change
+}
+
+// Reloadable returns the hot-reloadable subset of cfg
+func (cfg *Config) Reloadable() ReloadableSettings {
+	return ReloadableSettings{
+		PollInterval:     cfg.PollInterval,
+		PublishInvalid:   cfg.PublishInvalid,
+		MovingThreshold:  cfg.MovingThreshold,
+		MinMoveMeters:    cfg.MinMoveMeters,
+		Heartbeat:        cfg.Heartbeat,
+		EventMinInterval: cfg.EventMinInterval,
+	}
+}
+
+// ReloadConfig re-reads CONFIG_FILE for a SIGHUP reload and returns the hot-reloadable settings
+// it now gives, along with the HotReloadKeys whose file value changed since the last load.
+// Only those should be applied, so that settings changed at runtime, e.g. through the command
+// topic, survive a reload that doesn't touch them. The environment of a running process can't
+// change, so only settings taken from the file can, and variables set in the environment still
+// override it. File settings outside HotReloadKeys whose value changed are returned in restart
+// and otherwise ignored. The settings are read and validated by readConfig, as at startup, and
+// nothing is applied unless the file parses and readConfig succeeds.
+func ReloadConfig() (settings ReloadableSettings, changed, restart []string, err error) {
+	var file map[string]string
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return ReloadableSettings{}, nil, nil, fmt.Errorf("CONFIG_FILE: %w", err)
+		}
+		if file, err = parseConfigFile(raw); err != nil {
+			return ReloadableSettings{}, nil, nil, fmt.Errorf("CONFIG_FILE %s: %w", path, err)
+		}
+	}
+
+	// The settings the file provided at the last load or provides now, unless the environment
+	// overrides them
+	var keys []string
+	for key := range configFileValues {
+		keys = append(keys, key)
+	}
+	for key := range file {
+		if _, loaded := configFileValues[key]; !loaded && os.Getenv(key) == "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	previous := make(map[string]string)
+	for _, key := range keys {
+		if file[key] == configFileValues[key] {
+			continue
+		}
+		if !slices.Contains(HotReloadKeys, key) {
+			restart = append(restart, key)
+			continue
+		}
+		previous[key] = os.Getenv(key)
+		os.Setenv(key, file[key]) // Empty counts as unset, restoring the default
+		changed = append(changed, key)
+	}
+	cfg, err := readConfig()
+	if err != nil {
+		for key, val := range previous {
+			os.Setenv(key, val)
+		}
+		return ReloadableSettings{}, nil, restart, err
+	}
+	for key := range previous {
+		if val, ok := file[key]; ok {
+			configFileValues[key] = val
+		} else {
+			delete(configFileValues, key)
+		}
+	}
+	return cfg.Reloadable(), changed, restart, nil
+}
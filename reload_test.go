@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// rewriteConfigFile replaces the contents of the CONFIG_FILE loaded by loadTestConfigFile
+func rewriteConfigFile(t *testing.T, contents string) {
+	t.Helper()
+	if err := os.WriteFile(os.Getenv("CONFIG_FILE"), []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadUpdatesTickerInterval(t *testing.T) {
+	setConfigEnv(t, map[string]string{"DRY_RUN": "true"})
+	if err := loadTestConfigFile(t, "poll_interval_seconds: 3600\nmqtt_topic: gnss\n"); err != nil {
+		t.Fatalf("loadConfigFile() = %v", err)
+	}
+	settings := NewRuntimeSettings(time.Hour, false)
+	ticker := time.NewTicker(settings.PollInterval())
+	defer ticker.Stop()
+
+	rewriteConfigFile(t, "poll_interval_seconds: 0.05\nmqtt_topic: gnss-moved\n")
+	next, changed, restart, err := ReloadConfig()
+	if err != nil {
+		t.Fatalf("ReloadConfig() = %v", err)
+	}
+	if !reflect.DeepEqual(changed, []string{"POLL_INTERVAL_SECONDS"}) || !reflect.DeepEqual(restart, []string{"MQTT_TOPIC"}) {
+		t.Errorf("ReloadConfig() changed %q and restart %q, want POLL_INTERVAL_SECONDS and MQTT_TOPIC", changed, restart)
+	}
+	if next.PollInterval != 50*time.Millisecond {
+		t.Fatalf("reloaded poll interval = %v, want 50ms", next.PollInterval)
+	}
+
+	// Applied as the poll loop does on SIGHUP
+	if !settings.Set(next.PollInterval, next.PublishInvalid) {
+		t.Fatal("Set() = false, want the interval reported as changed")
+	}
+	ticker.Reset(settings.PollInterval())
+	select {
+	case <-ticker.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ticker didn't fire at the reloaded interval")
+	}
+
+	// Reloading the same file again changes nothing
+	if _, changed, restart, err := ReloadConfig(); err != nil || len(changed) != 0 || len(restart) != 1 {
+		t.Errorf("second ReloadConfig() = changed %q, restart %q, %v, want only MQTT_TOPIC to restart", changed, restart, err)
+	}
+}
+
+func TestReloadInvalidValueKeepsSettings(t *testing.T) {
+	setConfigEnv(t, map[string]string{"DRY_RUN": "true", "PUBLISH_INVALID_FIXES": ""})
+	if err := loadTestConfigFile(t, "poll_interval_seconds: 10\n"); err != nil {
+		t.Fatalf("loadConfigFile() = %v", err)
+	}
+	rewriteConfigFile(t, "poll_interval_seconds: -1\npublish_invalid_fixes: true\n")
+	if _, changed, _, err := ReloadConfig(); err == nil {
+		t.Fatalf("ReloadConfig() changed %q, want an error for the negative interval", changed)
+	}
+	if got := os.Getenv("POLL_INTERVAL_SECONDS"); got != "10" {
+		t.Errorf("POLL_INTERVAL_SECONDS = %q after a failed reload, want 10", got)
+	}
+	if got := os.Getenv("PUBLISH_INVALID_FIXES"); got != "" {
+		t.Errorf("PUBLISH_INVALID_FIXES = %q after a failed reload, want it unset", got)
+	}
+	if configFileValues["POLL_INTERVAL_SECONDS"] != "10" {
+		t.Errorf("file values = %v after a failed reload, want the previous load's", configFileValues)
+	}
+}
+
+func TestReloadEnvironmentOverridesFile(t *testing.T) {
+	setConfigEnv(t, map[string]string{"DRY_RUN": "true", "POLL_INTERVAL_SECONDS": "7"})
+	if err := loadTestConfigFile(t, "poll_interval_seconds: 10\n"); err != nil {
+		t.Fatalf("loadConfigFile() = %v", err)
+	}
+	rewriteConfigFile(t, "poll_interval_seconds: 2\n")
+	next, changed, _, err := ReloadConfig()
+	if err != nil || len(changed) != 0 || next.PollInterval != 7*time.Second {
+		t.Errorf("ReloadConfig() = %v, changed %q, %v, want the environment's 7s unchanged", next.PollInterval, changed, err)
+	}
+}
+
+func TestReloadPublishAndEventThresholds(t *testing.T) {
+	// The variables only the reloaded file sets are registered too, so they're unset afterwards
+	setConfigEnv(t, map[string]string{"DRY_RUN": "true", "HEARTBEAT_SECONDS": "", "EVENT_MIN_INTERVAL_SECONDS": ""})
+	if err := loadTestConfigFile(t, "min_move_meters: 10\n"); err != nil {
+		t.Fatalf("loadConfigFile() = %v", err)
+	}
+	rewriteConfigFile(t, "min_move_meters: 25\nheartbeat_seconds: 60\nevent_min_interval_seconds: 30\n")
+	next, changed, restart, err := ReloadConfig()
+	if err != nil {
+		t.Fatalf("ReloadConfig() = %v", err)
+	}
+	want := []string{"EVENT_MIN_INTERVAL_SECONDS", "HEARTBEAT_SECONDS", "MIN_MOVE_METERS"}
+	if !reflect.DeepEqual(changed, want) || len(restart) != 0 {
+		t.Errorf("ReloadConfig() changed %q and restart %q, want %q and nothing to restart", changed, restart, want)
+	}
+	if next.MinMoveMeters != 25 || next.Heartbeat != time.Minute || next.EventMinInterval != 30*time.Second {
+		t.Errorf("reloaded settings = %+v, want 25 m, a 1m heartbeat and a 30s event interval", next)
+	}
+	// Defaults come from readConfig, so settings the file leaves out keep theirs
+	if next.PollInterval != DefaultPollInterval || next.MovingThreshold != 1 {
+		t.Errorf("reloaded poll interval %v and moving threshold %g, want the defaults", next.PollInterval, next.MovingThreshold)
+	}
+}
+
+func TestReloadValidatesLikeStartup(t *testing.T) {
+	setConfigEnv(t, map[string]string{"DRY_RUN": "true"})
+	if err := loadTestConfigFile(t, "heartbeat_seconds: 60\n"); err != nil {
+		t.Fatalf("loadConfigFile() = %v", err)
+	}
+	// Parses as a number, but validateConfig rejects it
+	rewriteConfigFile(t, "heartbeat_seconds: 0\n")
+	if _, changed, _, err := ReloadConfig(); err == nil {
+		t.Fatalf("ReloadConfig() changed %q, want an error for the zero heartbeat", changed)
+	}
+	if got := os.Getenv("HEARTBEAT_SECONDS"); got != "60" {
+		t.Errorf("HEARTBEAT_SECONDS = %q after a failed reload, want 60", got)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// remoteWriteTimeout bounds a single remote-write push
+const remoteWriteTimeout = 10 * time.Second
+
+// RemoteWriter pushes gathered metrics to a Prometheus remote-write endpoint
+type RemoteWriter struct {
+	URL      string              // Remote-write endpoint
+	Client   *http.Client        // HTTP client used for pushes
+	Gatherer prometheus.Gatherer // Source of the metric values
+	Labels   map[string]string   // Extra labels added to every series, e.g. instance
+	Clock    Clock
+
+	inFlight atomic.Bool
+}
+
+// PushAsync pushes in the background, skipping the push if the previous one hasn't finished
+func (w *RemoteWriter) PushAsync(ctx context.Context, onError func(error)) {
+	if !w.inFlight.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer w.inFlight.Store(false)
+		if err := w.Push(ctx); err != nil {
+			onError(err)
+		}
+	}()
+}
+
+// Push gathers the current metric values and sends them as a snappy-compressed WriteRequest
+func (w *RemoteWriter) Push(ctx context.Context) error {
+	families, err := w.Gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	body := snappy.Encode(nil, BuildWriteRequest(families, w.Labels, w.Clock.Now()))
+
+	ctx, cancel := context.WithTimeout(ctx, remoteWriteTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("remote write failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote write returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// BuildWriteRequest encodes gauge, counter and untyped metrics as a Prometheus
+// remote-write WriteRequest protobuf message with a single sample per series at ts
+func BuildWriteRequest(families []*dto.MetricFamily, extraLabels map[string]string, ts time.Time) []byte {
+	var out []byte
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			var value float64
+			switch mf.GetType() {
+			case dto.MetricType_GAUGE:
+				value = m.GetGauge().GetValue()
+			case dto.MetricType_COUNTER:
+				value = m.GetCounter().GetValue()
+			case dto.MetricType_UNTYPED:
+				value = m.GetUntyped().GetValue()
+			default:
+				continue
+			}
+			labels := map[string]string{"__name__": mf.GetName()}
+			for k, v := range extraLabels {
+				labels[k] = v
+			}
+			for _, lp := range m.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			series := appendTimeSeries(nil, labels, value, ts.UnixMilli())
+			out = protowire.AppendTag(out, 1, protowire.BytesType) // WriteRequest.timeseries
+			out = protowire.AppendBytes(out, series)
+		}
+	}
+	return out
+}
+
+// appendTimeSeries encodes a TimeSeries message with labels sorted by name and one sample
+func appendTimeSeries(b []byte, labels map[string]string, value float64, tsMillis int64) []byte {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var label []byte
+		label = protowire.AppendTag(label, 1, protowire.BytesType) // Label.name
+		label = protowire.AppendString(label, name)
+		label = protowire.AppendTag(label, 2, protowire.BytesType) // Label.value
+		label = protowire.AppendString(label, labels[name])
+		b = protowire.AppendTag(b, 1, protowire.BytesType) // TimeSeries.labels
+		b = protowire.AppendBytes(b, label)
+	}
+	var sample []byte
+	sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type) // Sample.value
+	sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+	sample = protowire.AppendTag(sample, 2, protowire.VarintType) // Sample.timestamp
+	sample = protowire.AppendVarint(sample, uint64(tsMillis))
+	b = protowire.AppendTag(b, 2, protowire.BytesType) // TimeSeries.samples
+	return protowire.AppendBytes(b, sample)
+}
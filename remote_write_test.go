@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodedSeries is a TimeSeries read back from a WriteRequest
+type decodedSeries struct {
+	Labels map[string]string
+	Value  float64
+	TS     int64
+}
+
+// decodeWriteRequest parses the subset of the remote-write protobuf that BuildWriteRequest produces
+func decodeWriteRequest(t *testing.T, b []byte) []decodedSeries {
+	t.Helper()
+	var out []decodedSeries
+	forEachField(t, b, func(num protowire.Number, _ protowire.Type, v []byte) {
+		if num != 1 {
+			t.Fatalf("unexpected WriteRequest field %d", num)
+		}
+		s := decodedSeries{Labels: map[string]string{}}
+		forEachField(t, v, func(num protowire.Number, _ protowire.Type, v []byte) {
+			switch num {
+			case 1: // Label
+				var name, value string
+				forEachField(t, v, func(num protowire.Number, _ protowire.Type, v []byte) {
+					if num == 1 {
+						name = string(v)
+					} else {
+						value = string(v)
+					}
+				})
+				s.Labels[name] = value
+			case 2: // Sample
+				forEachField(t, v, func(num protowire.Number, _ protowire.Type, v []byte) {
+					if num == 1 {
+						bits, _ := protowire.ConsumeFixed64(v)
+						s.Value = math.Float64frombits(bits)
+					} else {
+						ts, _ := protowire.ConsumeVarint(v)
+						s.TS = int64(ts)
+					}
+				})
+			}
+		})
+		out = append(out, s)
+	})
+	return out
+}
+
+// forEachField calls fn for each field in a protobuf message with the field's raw value:
+// the payload for bytes fields and the encoded value otherwise
+func forEachField(t *testing.T, b []byte, fn func(protowire.Number, protowire.Type, []byte)) {
+	t.Helper()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("bad tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if m < 0 {
+			t.Fatalf("bad field %d: %v", num, protowire.ParseError(m))
+		}
+		v := b[:m]
+		if typ == protowire.BytesType {
+			v, _ = protowire.ConsumeBytes(v)
+		}
+		fn(num, typ, v)
+		b = b[m:]
+	}
+}
+
+func TestRemoteWriterPush(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	sats := prometheus.NewGauge(prometheus.GaugeOpts{Name: "gnss_satellites", Help: "test"})
+	reads := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "gnss_reads_total", Help: "test"}, []string{"result"})
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "gnss_read_seconds", Help: "test"})
+	reg.MustRegister(sats, reads, latency)
+	sats.Set(9)
+	reads.WithLabelValues("ok").Add(3)
+	latency.Observe(0.2)
+
+	var headers http.Header
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		compressed, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if body, err = snappy.Decode(nil, compressed); err != nil {
+			t.Errorf("body isn't snappy encoded: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := &RemoteWriter{
+		URL:      srv.URL,
+		Client:   srv.Client(),
+		Gatherer: reg,
+		Labels:   map[string]string{"instance": "tachyon-1"},
+		Clock:    newFakeClock(now),
+	}
+	if err := w.Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for header, want := range map[string]string{
+		"Content-Type":                      "application/x-protobuf",
+		"Content-Encoding":                  "snappy",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+	} {
+		if got := headers.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	got := decodeWriteRequest(t, body)
+	sort.Slice(got, func(i, j int) bool { return got[i].Labels["__name__"] < got[j].Labels["__name__"] })
+	want := []decodedSeries{
+		{Labels: map[string]string{"__name__": "gnss_reads_total", "instance": "tachyon-1", "result": "ok"}, Value: 3, TS: now.UnixMilli()},
+		{Labels: map[string]string{"__name__": "gnss_satellites", "instance": "tachyon-1"}, Value: 9, TS: now.UnixMilli()},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pushed series %+v, want %+v", got, want)
+	}
+}
+
+func TestRemoteWriterPushError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	w := &RemoteWriter{URL: srv.URL, Client: srv.Client(), Gatherer: prometheus.NewRegistry(), Clock: systemClock{}}
+	err := w.Push(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "400 Bad Request: out of order sample") {
+		t.Errorf("Push() error = %v, want the endpoint's status and message", err)
+	}
+}
+
+func TestAppendTimeSeriesSortsLabels(t *testing.T) {
+	b := appendTimeSeries(nil, map[string]string{"z": "1", "__name__": "m", "a": "2"}, 1, 0)
+	var names []string
+	forEachField(t, b, func(num protowire.Number, _ protowire.Type, v []byte) {
+		if num != 1 {
+			return
+		}
+		forEachField(t, v, func(num protowire.Number, _ protowire.Type, v []byte) {
+			if num == 1 {
+				names = append(names, string(v))
+			}
+		})
+	})
+	if want := []string{"__name__", "a", "z"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("label order %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// MaxReplaySpeed is the largest accepted REPLAY_SPEED multiplier
+const MaxReplaySpeed = 1000.0
+
+// RecordedFix is one line of a recording: the time a fix was read and the fix itself
+type RecordedFix struct {
+	Time time.Time     `json:"time"`
+	Data *GnssFullData `json:"data"`
+}
+
+// Recorder appends fixes to a JSON lines recording file
+type Recorder struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+// NewRecorder opens path for appending, creating it if needed
+func NewRecorder(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	return &Recorder{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Record appends a fix read at the given time
+func (r *Recorder) Record(at time.Time, data *GnssFullData) error {
+	return r.enc.Encode(RecordedFix{Time: at.UTC(), Data: data})
+}
+
+// Close closes the recording file
+func (r *Recorder) Close() error {
+	return r.f.Close()
+}
+
+// ValidateReplaySpeed checks that a replay speed multiplier is positive and not absurdly large
+func ValidateReplaySpeed(speed float64) error {
+	if !(speed > 0) || speed > MaxReplaySpeed {
+		return fmt.Errorf("replay speed must be greater than 0 and at most %g, got %g", MaxReplaySpeed, speed)
+	}
+	return nil
+}
+
+// ReplayDelay returns the wait between two recorded fixes scaled by the speed multiplier.
+// Out-of-order timestamps replay immediately.
+func ReplayDelay(prev, next time.Time, speed float64) time.Duration {
+	delay := next.Sub(prev)
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(float64(delay) / speed)
+}
+
+// Replay sends the fixes in a recording to out, preserving their relative timing scaled by speed.
+// It returns when the recording is exhausted or ctx is cancelled.
+func Replay(ctx context.Context, path string, speed float64, out chan<- *GnssFullData) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var prev time.Time
+	for line := 1; scanner.Scan(); line++ {
+		var rec RecordedFix
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("recording line %d: %w", line, err)
+		}
+		if rec.Data == nil {
+			continue
+		}
+		if !prev.IsZero() {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(ReplayDelay(prev, rec.Time, speed)):
+			}
+		}
+		prev = rec.Time
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case out <- rec.Data:
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReplayDelay(t *testing.T) {
+	prev := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		gap   time.Duration
+		speed float64
+		want  time.Duration
+	}{
+		{"real time", 5 * time.Second, 1, 5 * time.Second},
+		{"ten times faster", 5 * time.Second, 10, 500 * time.Millisecond},
+		{"half speed", time.Second, 0.5, 2 * time.Second},
+		{"maximum speed", 10 * time.Second, MaxReplaySpeed, 10 * time.Millisecond},
+		{"same timestamp", 0, 10, 0},
+		{"out of order", -time.Second, 10, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReplayDelay(prev, prev.Add(tt.gap), tt.speed); got != tt.want {
+				t.Errorf("ReplayDelay(%s apart, %gx) = %s, want %s", tt.gap, tt.speed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReplaySpeed(t *testing.T) {
+	tests := []struct {
+		speed   float64
+		wantErr bool
+	}{
+		{1, false},
+		{0.25, false},
+		{MaxReplaySpeed, false},
+		{0, true},
+		{-2, true},
+		{MaxReplaySpeed + 1, true},
+	}
+	for _, tt := range tests {
+		if err := ValidateReplaySpeed(tt.speed); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateReplaySpeed(%g) error = %v, want error %t", tt.speed, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drive.jsonl")
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lats := []float64{51.0, 51.1, 51.2}
+	for i, lat := range lats {
+		if err := rec.Record(start.Add(time.Duration(i)*time.Second), &GnssFullData{Latitude: lat, Valid: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := make(chan *GnssFullData, len(lats))
+	if err := Replay(context.Background(), path, MaxReplaySpeed, out); err != nil {
+		t.Fatal(err)
+	}
+	close(out)
+	var got []float64
+	for data := range out {
+		got = append(got, data.Latitude)
+	}
+	if len(got) != len(lats) || got[0] != lats[0] || got[2] != lats[2] {
+		t.Errorf("replayed latitudes %v, want %v", got, lats)
+	}
+}
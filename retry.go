@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ErrInvalidGnssData wraps errors decoding a GetGnss reply. The modem answered, so reading
+// again straight away would most likely get the same reply.
+var ErrInvalidGnssData = errors.New("invalid GNSS data")
+
+// ErrGnssServiceUnavailable wraps reads failing because no process owns the GNSS service's bus
+// name, typically at boot before the service has started. Retrying within the same tick won't
+// help, so RetryingGnssReader returns it at once.
+var ErrGnssServiceUnavailable = errors.New("GNSS service is not registered on D-Bus")
+
+// dbusErrServiceUnknown is the error name the bus replies with when a call's destination is
+// neither owned nor activatable
+const dbusErrServiceUnknown = "org.freedesktop.DBus.Error.ServiceUnknown"
+
+// isServiceUnknown reports whether err is a D-Bus ServiceUnknown error reply
+func isServiceUnknown(err error) bool {
+	var dbusErr dbus.Error
+	if errors.As(err, &dbusErr) {
+		return dbusErr.Name == dbusErrServiceUnknown
+	}
+	var dbusErrPtr *dbus.Error
+	return errors.As(err, &dbusErrPtr) && dbusErrPtr.Name == dbusErrServiceUnknown
+}
+
+// Defaults for DBUS_RETRY_ATTEMPTS and DBUS_RETRY_DELAY_SECONDS
+const (
+	DefaultDbusRetryAttempts = 3
+	DefaultDbusRetryDelay    = 200 * time.Millisecond
+)
+
+// RetryingGnssReader retries transient read failures, such as a D-Bus transport error or a
+// timed out call, with exponential backoff before giving up for the tick. Having no fix yet
+// isn't an error, so those reads return at once.
+type RetryingGnssReader struct {
+	Reader    GnssReader
+	Attempts  int           // Total reads per call, including the first
+	BaseDelay time.Duration // Delay before the first retry, doubling for each further retry
+}
+
+// ReadGnss reads from the wrapped reader, retrying transient failures
+func (r *RetryingGnssReader) ReadGnss(ctx context.Context) (*GnssFullData, error) {
+	delay := r.BaseDelay
+	for attempt := 1; ; attempt++ {
+		data, err := r.Reader.ReadGnss(ctx)
+		if err == nil || errors.Is(err, ErrInvalidGnssData) || errors.Is(err, ErrGnssServiceUnavailable) ||
+			ctx.Err() != nil || attempt >= r.Attempts {
+			return data, err
+		}
+		log.Printf("GNSS read failed (attempt %d of %d), retrying in %s: %v", attempt, r.Attempts, delay, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// mockGnssReader fails its first failures reads with err, then returns data
+type mockGnssReader struct {
+	failures int
+	err      error
+	data     *GnssFullData
+	calls    int
+}
+
+func (m *mockGnssReader) ReadGnss(ctx context.Context) (*GnssFullData, error) {
+	m.calls++
+	if m.calls <= m.failures {
+		return nil, m.err
+	}
+	return m.data, nil
+}
+
+func TestRetryingGnssReader(t *testing.T) {
+	fix := &GnssFullData{Valid: 1, Fixmode: 3, Latitude: 51.5, Longitude: -0.12}
+	noFix := &GnssFullData{Fixmode: 1}
+	errTransport := errors.New("dbus: connection reset by peer")
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		data      *GnssFullData
+		wantCalls int
+		wantErr   error
+	}{
+		{"first read succeeds", 0, nil, fix, 1, nil},
+		{"fails twice then succeeds", 2, errTransport, fix, 3, nil},
+		{"gives up after every attempt", 5, errTransport, fix, 3, errTransport},
+		{"no fix yet isn't retried", 0, nil, noFix, 1, nil},
+		{"undecodable reply isn't retried", 5, fmt.Errorf("%w: utc has 5 fields", ErrInvalidGnssData), fix, 1, ErrInvalidGnssData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockGnssReader{failures: tt.failures, err: tt.err, data: tt.data}
+			reader := &RetryingGnssReader{Reader: mock, Attempts: 3, BaseDelay: time.Millisecond}
+			data, err := reader.ReadGnss(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReadGnss() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && data != tt.data {
+				t.Errorf("ReadGnss() = %+v, want %+v", data, tt.data)
+			}
+			if mock.calls != tt.wantCalls {
+				t.Errorf("%d reads, want %d", mock.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryingGnssReaderBackoff(t *testing.T) {
+	mock := &mockGnssReader{failures: 3, err: errors.New("timeout"), data: &GnssFullData{}}
+	reader := &RetryingGnssReader{Reader: mock, Attempts: 4, BaseDelay: 20 * time.Millisecond}
+	start := time.Now()
+	if _, err := reader.ReadGnss(context.Background()); err != nil {
+		t.Fatalf("ReadGnss() = %v", err)
+	}
+	// 20 + 40 + 80 ms between the four reads
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("three retries took %v, want at least 140ms of doubling backoff", elapsed)
+	}
+}
+
+func TestRetryingGnssReaderCancelled(t *testing.T) {
+	mock := &mockGnssReader{failures: 10, err: errors.New("timeout")}
+	reader := &RetryingGnssReader{Reader: mock, Attempts: 10, BaseDelay: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := reader.ReadGnss(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ReadGnss() = %v, want the context's error while backing off", err)
+	}
+	if mock.calls != 1 {
+		t.Errorf("%d reads, want 1 before the context ended", mock.calls)
+	}
+}
+
+func TestIsServiceUnknown(t *testing.T) {
+	unknown := dbus.Error{Name: dbusErrServiceUnknown, Body: []any{"The name io.particle.tachyon.GNSS was not provided by any .service files"}}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"value", unknown, true},
+		{"pointer", &unknown, true},
+		{"wrapped", fmt.Errorf("GetGnss: %w", unknown), true},
+		{"other D-Bus error", dbus.Error{Name: "org.freedesktop.DBus.Error.NoReply"}, false},
+		{"other error", errors.New("dbus: connection closed by user"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isServiceUnknown(tt.err); got != tt.want {
+				t.Errorf("isServiceUnknown(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryingGnssReaderServiceUnknown(t *testing.T) {
+	// As dbusGnssReader reports a ServiceUnknown reply
+	unknown := dbus.Error{Name: dbusErrServiceUnknown, Body: []any{"The name is not activatable"}}
+	err := fmt.Errorf("%w: %s: %v", ErrGnssServiceUnavailable, GnssDbusDest, unknown)
+	mock := &mockGnssReader{failures: 5, err: err, data: &GnssFullData{}}
+	reader := &RetryingGnssReader{Reader: mock, Attempts: 3, BaseDelay: time.Hour}
+	if _, err := reader.ReadGnss(context.Background()); !errors.Is(err, ErrGnssServiceUnavailable) {
+		t.Errorf("ReadGnss() = %v, want ErrGnssServiceUnavailable", err)
+	}
+	if mock.calls != 1 {
+		t.Errorf("%d reads, want 1 without retrying while the service is missing", mock.calls)
+	}
+}
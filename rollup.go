@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// rollupMinMoveMeters is the distance between consecutive fixes counted as activity
+	rollupMinMoveMeters = 10.0
+	// rollupMaxGap caps the time credited to a single moving interval, so outages aren't counted as activity
+	rollupMaxGap = 10 * time.Minute
+)
+
+// DailySummary is the rollup published once per day
+type DailySummary struct {
+	PeriodStart string       `json:"period_start"`           // RFC3339 start of the accumulation period
+	PeriodEnd   string       `json:"period_end"`             // RFC3339 end of the accumulation period
+	Fixes       int          `json:"fixes"`                  // Number of valid fixes accumulated
+	DistanceM   float64      `json:"distance_m"`             // Total great-circle distance traveled
+	ActiveHours float64      `json:"active_hours"`           // Time spent moving between fixes
+	MaxSpeed    float64      `json:"max_speed"`              // Highest reported ground speed
+	BoundingBox *BoundingBox `json:"bounding_box,omitempty"` // Extent of positions, nil when no valid fix was seen
+}
+
+// DailyRollup accumulates valid fixes and produces a DailySummary at a fixed local time each day
+type DailyRollup struct {
+	clock        Clock
+	hour, minute int
+	next         time.Time // Next emission time
+	start        time.Time // Start of the current period
+	summary      DailySummary
+	prev         *GnssData
+	prevAt       time.Time
+}
+
+// NewDailyRollup creates a rollup emitting at hour:minute in the clock's local time zone
+func NewDailyRollup(clock Clock, hour, minute int) *DailyRollup {
+	now := clock.Now()
+	return &DailyRollup{
+		clock:  clock,
+		hour:   hour,
+		minute: minute,
+		next:   nextTimeOfDay(now, hour, minute),
+		start:  now,
+	}
+}
+
+// Next returns the time the next summary is due
+func (r *DailyRollup) Next() time.Time {
+	return r.next
+}
+
+// Add accumulates a valid fix into the current period
+func (r *DailyRollup) Add(data *GnssData) {
+	now := r.clock.Now()
+	r.summary.Fixes++
+	r.summary.MaxSpeed = math.Max(r.summary.MaxSpeed, data.Speed)
+	if bb := r.summary.BoundingBox; bb == nil {
+		r.summary.BoundingBox = &BoundingBox{
+			MinLatitude: data.Latitude, MinLongitude: data.Longitude,
+			MaxLatitude: data.Latitude, MaxLongitude: data.Longitude,
+		}
+	} else {
+		bb.MinLatitude = math.Min(bb.MinLatitude, data.Latitude)
+		bb.MinLongitude = math.Min(bb.MinLongitude, data.Longitude)
+		bb.MaxLatitude = math.Max(bb.MaxLatitude, data.Latitude)
+		bb.MaxLongitude = math.Max(bb.MaxLongitude, data.Longitude)
+	}
+	if r.prev != nil {
+		dist := Haversine(r.prev.Latitude, r.prev.Longitude, data.Latitude, data.Longitude)
+		r.summary.DistanceM += dist
+		if gap := now.Sub(r.prevAt); dist >= rollupMinMoveMeters && gap > 0 {
+			r.summary.ActiveHours += min(gap, rollupMaxGap).Hours()
+		}
+	}
+	fix := *data
+	r.prev = &fix
+	r.prevAt = now
+}
+
+// Due returns the summary for the elapsed period once the daily emission time has passed,
+// and resets the accumulators for the next period
+func (r *DailyRollup) Due() (*DailySummary, bool) {
+	now := r.clock.Now()
+	if now.Before(r.next) {
+		return nil, false
+	}
+	summary := r.summary
+	summary.PeriodStart = r.start.Format(time.RFC3339)
+	summary.PeriodEnd = now.Format(time.RFC3339)
+	r.summary = DailySummary{}
+	r.start = now
+	r.next = nextTimeOfDay(now, r.hour, r.minute)
+	// r.prev is kept so distance spanning the boundary counts towards the next period
+	return &summary, true
+}
+
+// nextTimeOfDay returns the first hour:minute strictly after now, in now's location
+func nextTimeOfDay(now time.Time, hour, minute int) time.Time {
+	t := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !t.After(now) {
+		t = time.Date(now.Year(), now.Month(), now.Day()+1, hour, minute, 0, 0, now.Location())
+	}
+	return t
+}
+
+// ParseTimeOfDay parses a 24-hour "HH:MM" string
+func ParseTimeOfDay(s string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNextTimeOfDay(t *testing.T) {
+	day := func(d, h, m int) time.Time { return time.Date(2024, 3, d, h, m, 0, 0, time.UTC) }
+	tests := []struct {
+		name         string
+		now          time.Time
+		hour, minute int
+		want         time.Time
+	}{
+		{"later today", day(10, 8, 0), 23, 30, day(10, 23, 30)},
+		{"earlier today", day(10, 8, 0), 6, 0, day(11, 6, 0)},
+		{"exactly now", day(10, 23, 30), 23, 30, day(11, 23, 30)},
+		{"midnight", day(10, 23, 59), 0, 0, day(11, 0, 0)},
+		{"month end", time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC), 0, 0, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextTimeOfDay(tt.now, tt.hour, tt.minute); !got.Equal(tt.want) {
+				t.Errorf("nextTimeOfDay(%s, %d, %d) = %s, want %s", tt.now, tt.hour, tt.minute, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	tests := []struct {
+		in           string
+		hour, minute int
+		wantErr      bool
+	}{
+		{"00:00", 0, 0, false},
+		{"23:59", 23, 59, false},
+		{"07:05", 7, 5, false},
+		{"24:00", 0, 0, true},
+		{"12:60", 0, 0, true},
+		{"noon", 0, 0, true},
+		{"", 0, 0, true},
+	}
+	for _, tt := range tests {
+		hour, minute, err := ParseTimeOfDay(tt.in)
+		if (err != nil) != tt.wantErr || hour != tt.hour || minute != tt.minute {
+			t.Errorf("ParseTimeOfDay(%q) = %d, %d, %v, want %d, %d, error %t", tt.in, hour, minute, err, tt.hour, tt.minute, tt.wantErr)
+		}
+	}
+}
+
+func TestDailyRollupBoundaryAndReset(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 3, 10, 22, 0, 0, 0, time.UTC))
+	rollup := NewDailyRollup(clock, 23, 0)
+	if want := time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC); !rollup.Next().Equal(want) {
+		t.Fatalf("Next() = %s, want %s", rollup.Next(), want)
+	}
+
+	// Three fixes a minute apart moving north about 111 m each, then one not moving
+	fixes := []GnssData{
+		{Latitude: 51.000, Longitude: -1, Speed: 20},
+		{Latitude: 51.001, Longitude: -1, Speed: 35},
+		{Latitude: 51.002, Longitude: -1, Speed: 25},
+		{Latitude: 51.002, Longitude: -1, Speed: 0},
+	}
+	for i := range fixes {
+		rollup.Add(&fixes[i])
+		if _, ok := rollup.Due(); ok {
+			t.Fatalf("summary due at %s, before the boundary", clock.Now())
+		}
+		clock.Advance(time.Minute)
+	}
+
+	clock.Advance(time.Hour)
+	summary, ok := rollup.Due()
+	if !ok {
+		t.Fatalf("no summary at %s, after the boundary", clock.Now())
+	}
+	if summary.Fixes != 4 || summary.MaxSpeed != 35 {
+		t.Errorf("fixes %d, max speed %v, want 4 and 35", summary.Fixes, summary.MaxSpeed)
+	}
+	if want := Haversine(51, -1, 51.002, -1); math.Abs(summary.DistanceM-want) > 0.01 {
+		t.Errorf("distance %.2f m, want %.2f m", summary.DistanceM, want)
+	}
+	if want := (2 * time.Minute).Hours(); math.Abs(summary.ActiveHours-want) > 1e-9 {
+		t.Errorf("active hours %v, want %v", summary.ActiveHours, want)
+	}
+	bb := summary.BoundingBox
+	if bb == nil || bb.MinLatitude != 51 || bb.MaxLatitude != 51.002 || bb.MinLongitude != -1 || bb.MaxLongitude != -1 {
+		t.Errorf("bounding box %+v, want 51,-1 to 51.002,-1", bb)
+	}
+	if summary.PeriodStart != "2024-03-10T22:00:00Z" || summary.PeriodEnd != "2024-03-10T23:04:00Z" {
+		t.Errorf("period %s to %s, want 2024-03-10T22:00:00Z to 2024-03-10T23:04:00Z", summary.PeriodStart, summary.PeriodEnd)
+	}
+
+	// The next period starts empty and ends at the same time the following day
+	if want := time.Date(2024, 3, 11, 23, 0, 0, 0, time.UTC); !rollup.Next().Equal(want) {
+		t.Errorf("Next() after the boundary = %s, want %s", rollup.Next(), want)
+	}
+	if _, ok := rollup.Due(); ok {
+		t.Error("summary due again straight after the boundary")
+	}
+	clock.Advance(24 * time.Hour)
+	summary, ok = rollup.Due()
+	if !ok || summary.Fixes != 0 || summary.DistanceM != 0 || summary.BoundingBox != nil {
+		t.Errorf("second summary = %+v, %t, want an empty summary", summary, ok)
+	}
+}
+
+func TestDailyRollupCapsActiveGap(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC))
+	rollup := NewDailyRollup(clock, 0, 0)
+	rollup.Add(&GnssData{Latitude: 51, Longitude: -1})
+	clock.Advance(3 * time.Hour) // An outage between two fixes far apart
+	rollup.Add(&GnssData{Latitude: 51.1, Longitude: -1})
+	clock.Advance(24 * time.Hour)
+	summary, _ := rollup.Due()
+	if want := rollupMaxGap.Hours(); summary.ActiveHours != want {
+		t.Errorf("active hours %v, want the %v cap", summary.ActiveHours, want)
+	}
+}
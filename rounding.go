@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxRoundingDecimals is the largest number of decimal places accepted in ROUNDING
+const MaxRoundingDecimals = 15
+
+// roundableFields maps lowercase ROUNDING field names to the GnssData float fields they round
+var roundableFields = map[string]func(*GnssData) *float64{
+	"latitude":  func(d *GnssData) *float64 { return &d.Latitude },
+	"longitude": func(d *GnssData) *float64 { return &d.Longitude },
+	"speed":     func(d *GnssData) *float64 { return &d.Speed },
+	"altitude":  func(d *GnssData) *float64 { return &d.Altitude },
+	"pdop":      func(d *GnssData) *float64 { return &d.Pdop },
+	"hdop":      func(d *GnssData) *float64 { return &d.Hdop },
+	"vdop":      func(d *GnssData) *float64 { return &d.Vdop },
+}
+
+// ParseRoundingRules parses a comma separated list of field=decimals pairs, e.g.
+// "latitude=6,longitude=6,altitude=1", into a map of lowercase field name to decimal places
+func ParseRoundingRules(s string) (map[string]int, error) {
+	rules := make(map[string]int)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule %q, expected field=decimals", part)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := roundableFields[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q, expected one of %s", name, strings.Join(roundableFieldNames(), ", "))
+		}
+		decimals, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || decimals < 0 || decimals > MaxRoundingDecimals {
+			return nil, fmt.Errorf("invalid decimals for %s: %q must be between 0 and %d", name, value, MaxRoundingDecimals)
+		}
+		rules[name] = decimals
+	}
+	return rules, nil
+}
+
+// roundableFieldNames returns the sorted field names accepted in rounding rules
+func roundableFieldNames() []string {
+	names := make([]string, 0, len(roundableFields))
+	for name := range roundableFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyRounding rounds each field listed in rules to its number of decimal places;
+// unlisted fields keep full precision
+func (d *GnssData) ApplyRounding(rules map[string]int) {
+	for name, decimals := range rules {
+		if field, ok := roundableFields[name]; ok {
+			v := field(d)
+			*v = roundTo(*v, decimals)
+		}
+	}
+}
+
+// roundTo rounds v half away from zero to the given number of decimal places
+func roundTo(v float64, decimals int) float64 {
+	scale := math.Pow10(decimals)
+	return math.Round(v*scale) / scale
+}
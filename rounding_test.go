@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseRoundingRules(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]int
+		wantErr bool
+	}{
+		{"latitude=6,longitude=6,altitude=1,speed=2", map[string]int{"latitude": 6, "longitude": 6, "altitude": 1, "speed": 2}, false},
+		{" Latitude = 4 , HDOP=0,", map[string]int{"latitude": 4, "hdop": 0}, false},
+		{"", map[string]int{}, false},
+		{"latitude", nil, true},
+		{"heading=2", nil, true},
+		{"latitude=-1", nil, true},
+		{"latitude=16", nil, true},
+		{"latitude=six", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRoundingRules(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRoundingRules(%q) error = %v, want error %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseRoundingRules(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestApplyRounding(t *testing.T) {
+	rules, err := ParseRoundingRules("latitude=6,longitude=3,altitude=1,speed=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := GnssData{Latitude: 51.50072919, Longitude: -0.12462455, Altitude: 35.25, Speed: 12.5, Hdop: 0.876543}
+	data.ApplyRounding(rules)
+	want := GnssData{Latitude: 51.500729, Longitude: -0.125, Altitude: 35.3, Speed: 13, Hdop: 0.876543}
+	if data.Latitude != want.Latitude || data.Longitude != want.Longitude || data.Altitude != want.Altitude ||
+		data.Speed != want.Speed || data.Hdop != want.Hdop {
+		t.Errorf("ApplyRounding() = %+v, want %+v", data, want)
+	}
+}
+
+func TestPayloadEncoderRounding(t *testing.T) {
+	enc, err := NewPayloadEncoder(PayloadFormatJSON, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc.Rounding, err = ParseRoundingRules("latitude=2,longitude=2"); err != nil {
+		t.Fatal(err)
+	}
+	data := &GnssData{Latitude: 51.50072, Longitude: -0.12462, Altitude: 35.123}
+	payload, err := enc.Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["Latitude"] != 51.5 || got["Longitude"] != -0.12 || got["Altitude"] != 35.123 {
+		t.Errorf("payload %s, want rounded coordinates and a full precision altitude", payload)
+	}
+	if data.Latitude != 51.50072 {
+		t.Error("Encode() rounded the caller's data")
+	}
+}
+
+func TestRoundTo(t *testing.T) {
+	tests := []struct {
+		v        float64
+		decimals int
+		want     float64
+	}{
+		{2.5, 0, 3},
+		{-2.5, 0, -3},
+		{1.23456, 2, 1.23},
+		{1.235, 1, 1.2},
+		{-0.12462, 3, -0.125},
+	}
+	for _, tt := range tests {
+		if got := roundTo(tt.v, tt.decimals); got != tt.want {
+			t.Errorf("roundTo(%v, %d) = %v, want %v", tt.v, tt.decimals, got, tt.want)
+		}
+	}
+}
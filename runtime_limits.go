@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// byteSizeUnits maps GOMEMLIMIT-style suffixes to their size in bytes
+var byteSizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+	{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12},
+	{"B", 1},
+}
+
+// ParseByteSize parses a GOMEMLIMIT-style size such as "64MiB", "500MB" or "1048576"
+func ParseByteSize(s string) (int64, error) {
+	num := strings.TrimSpace(s)
+	mult := int64(1)
+	for _, u := range byteSizeUnits {
+		if strings.HasSuffix(num, u.suffix) {
+			num, mult = strings.TrimSpace(strings.TrimSuffix(num, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || n <= 0 || n*float64(mult) > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q, expected a positive size such as 64MiB", s)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+// ApplyRuntimeLimits sets the soft memory limit and GOMAXPROCS when they're non-zero
+func ApplyRuntimeLimits(memLimit int64, maxProcs int) {
+	if memLimit > 0 {
+		prev := debug.SetMemoryLimit(memLimit)
+		log.Printf("Soft memory limit set to %d bytes (was %d)", memLimit, prev)
+	}
+	if maxProcs > 0 {
+		prev := runtime.GOMAXPROCS(maxProcs)
+		log.Printf("GOMAXPROCS set to %d (was %d)", maxProcs, prev)
+	}
+}
+
+// StopAfter calls stop once d has elapsed on clock, unless ctx is done first
+func StopAfter(ctx context.Context, clock Clock, d time.Duration, stop func()) {
+	select {
+	case <-clock.After(d):
+		log.Printf("Maximum runtime of %s reached, shutting down...", d)
+		stop()
+	case <-ctx.Done():
+	}
+}
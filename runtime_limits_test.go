@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"time"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"1048576", 1 << 20, false},
+		{"64MiB", 64 << 20, false},
+		{"1.5GiB", 3 << 29, false},
+		{"500MB", 500e6, false},
+		{"2 KB", 2000, false},
+		{"512B", 512, false},
+		{"1TiB", 1 << 40, false},
+		{"", 0, true},
+		{"0", 0, true},
+		{"-1MiB", 0, true},
+		{"64 potatoes", 0, true},
+		{"10000000TB", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, %v, want %d, error %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestApplyRuntimeLimits(t *testing.T) {
+	prevLimit := debug.SetMemoryLimit(-1)
+	prevProcs := runtime.GOMAXPROCS(0)
+	t.Cleanup(func() {
+		debug.SetMemoryLimit(prevLimit)
+		runtime.GOMAXPROCS(prevProcs)
+	})
+
+	ApplyRuntimeLimits(64<<20, 1)
+	if got := debug.SetMemoryLimit(-1); got != 64<<20 {
+		t.Errorf("memory limit = %d, want %d", got, 64<<20)
+	}
+	if got := runtime.GOMAXPROCS(0); got != 1 {
+		t.Errorf("GOMAXPROCS = %d, want 1", got)
+	}
+
+	// Zero leaves both settings alone
+	ApplyRuntimeLimits(0, 0)
+	if got := debug.SetMemoryLimit(-1); got != 64<<20 {
+		t.Errorf("memory limit after zero = %d, want it unchanged", got)
+	}
+	if got := runtime.GOMAXPROCS(0); got != 1 {
+		t.Errorf("GOMAXPROCS after zero = %d, want it unchanged", got)
+	}
+}
+
+// waitForWaiters waits until n After calls are pending on the clock
+func waitForWaiters(t *testing.T, c *fakeClock, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		got := len(c.waiters)
+		c.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("clock didn't reach %d pending waiters", n)
+}
+
+func TestStopAfter(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	stopped := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		StopAfter(context.Background(), clock, time.Hour, func() { close(stopped) })
+	}()
+	waitForWaiters(t, clock, 1)
+
+	clock.Advance(time.Hour - time.Second)
+	select {
+	case <-stopped:
+		t.Fatal("shutdown started before the maximum runtime")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("shutdown not started once the maximum runtime elapsed")
+	}
+	<-done
+}
+
+func TestStopAfterCancelled(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		StopAfter(ctx, clock, time.Hour, func() { t.Error("stop called after the context was cancelled") })
+	}()
+	waitForWaiters(t, clock, 1)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("StopAfter didn't return when the context was cancelled")
+	}
+	clock.Advance(2 * time.Hour)
+}
@@ -0,0 +1,161 @@
+package main
+
+// Constellation names used in SatelliteInfo
+const (
+	ConstellationGPS     = "gps"
+	ConstellationBeidou  = "beidou"
+	ConstellationGlonass = "glonass"
+	ConstellationGalileo = "galileo"
+)
+
+// SatelliteInfo is a constellation-agnostic view of one tracked satellite
+type SatelliteInfo struct {
+	Constellation string // One of the Constellation constants
+	Num           int    // Satellite number (PRN)
+	Elevation     int    // Elevation in degrees
+	Azimuth       int    // Azimuth in degrees
+	SNR           int    // Signal-to-noise ratio in dB-Hz, 0 when not tracked
+}
+
+// SatellitesInView returns the number of satellites in view across all constellations
+func (d *GnssData) SatellitesInView() int {
+	return int(d.Svnum) + int(d.BeidouSvnum) + int(d.GlonassSvnum) + int(d.GalileoSvnum)
+}
+
+// UsedSatellitePRNs decodes Possl, the satellite IDs of the NMEA GSA sentence, into the PRNs
+// of the satellites used in the position solution, dropping the zeros that pad the array
+func UsedSatellitePRNs(possl []uint8) []int {
+	var prns []int
+	for _, prn := range possl {
+		if prn != 0 {
+			prns = append(prns, int(prn))
+		}
+	}
+	return prns
+}
+
+// SatellitesUsed returns the number of satellites used in the position solution. It's Posslnum,
+// the GSA count, unless Possl lists more, as the count isn't filled in by every firmware.
+// Possl holds at most 12 PRNs, so Posslnum is the only source beyond that.
+func (d *GnssFullData) SatellitesUsed() int {
+	return max(int(d.Posslnum), len(UsedSatellitePRNs(d.Possl)))
+}
+
+// isSatellitePadding reports whether a satellite array entry is one of the all-zero entries the
+// modem pads the array with, rather than a satellite
+func isSatellitePadding(num, eledeg, snr int8) bool {
+	return num == 0 && eledeg == 0 && snr == 0
+}
+
+// TrimSatellitePadding returns the entries of a satellite array that are real satellites. A
+// non-nil array stays non-nil, so it still serializes as a list when every entry is padding.
+func TrimSatellitePadding(msgs []NmeaSatelliteMsg) []NmeaSatelliteMsg {
+	if msgs == nil {
+		return nil
+	}
+	sats := make([]NmeaSatelliteMsg, 0, len(msgs))
+	for _, s := range msgs {
+		if !isSatellitePadding(s.Num, s.Eledeg, s.SN) {
+			sats = append(sats, s)
+		}
+	}
+	return sats
+}
+
+// TrimBeidouSatellitePadding is TrimSatellitePadding for the BeiDou array
+func TrimBeidouSatellitePadding(msgs []BeidouNmeaSatelliteMsg) []BeidouNmeaSatelliteMsg {
+	if msgs == nil {
+		return nil
+	}
+	sats := make([]BeidouNmeaSatelliteMsg, 0, len(msgs))
+	for _, s := range msgs {
+		if !isSatellitePadding(s.BeidouNum, s.BeidouEledeg, s.BeidouSN) {
+			sats = append(sats, s)
+		}
+	}
+	return sats
+}
+
+// appendSatellites appends the non-padding entries of a satellite array
+func appendSatellites(sats []SatelliteInfo, constellation string, msgs []NmeaSatelliteMsg) []SatelliteInfo {
+	for _, s := range msgs {
+		if isSatellitePadding(s.Num, s.Eledeg, s.SN) {
+			continue
+		}
+		sats = append(sats, SatelliteInfo{
+			Constellation: constellation,
+			Num:           int(s.Num),
+			Elevation:     int(s.Eledeg),
+			Azimuth:       int(s.Azideg),
+			SNR:           int(s.SN),
+		})
+	}
+	return sats
+}
+
+// Satellites returns the satellites reported across all constellations, skipping the
+// all-zero entries that pad the fixed-size arrays
+func (d *GnssData) Satellites() []SatelliteInfo {
+	sats := appendSatellites(nil, ConstellationGPS, d.Slmsg)
+	for _, s := range d.BeidouSlmsg {
+		if isSatellitePadding(s.BeidouNum, s.BeidouEledeg, s.BeidouSN) {
+			continue
+		}
+		sats = append(sats, SatelliteInfo{
+			Constellation: ConstellationBeidou,
+			Num:           int(s.BeidouNum),
+			Elevation:     int(s.BeidouEledeg),
+			Azimuth:       int(s.BeidouAzideg),
+			SNR:           int(s.BeidouSN),
+		})
+	}
+	sats = appendSatellites(sats, ConstellationGlonass, d.GlonassSlmsg)
+	return appendSatellites(sats, ConstellationGalileo, d.GalileoSlmsg)
+}
+
+// dropWeak returns the entries of a satellite array with an SNR of at least minSNR
+func dropWeak(msgs []NmeaSatelliteMsg, minSNR int) []NmeaSatelliteMsg {
+	if msgs == nil {
+		return nil
+	}
+	sats := make([]NmeaSatelliteMsg, 0, len(msgs))
+	for _, s := range msgs {
+		if !isSatellitePadding(s.Num, s.Eledeg, s.SN) && int(s.SN) >= minSNR {
+			sats = append(sats, s)
+		}
+	}
+	return sats
+}
+
+// DropWeakSatellites removes the satellites with an SNR below minSNR, and any padding, from the
+// satellite arrays and recounts SatellitesListed. The in-view counts are left as reported.
+func (d *GnssData) DropWeakSatellites(minSNR int) {
+	d.Slmsg = dropWeak(d.Slmsg, minSNR)
+	d.GlonassSlmsg = dropWeak(d.GlonassSlmsg, minSNR)
+	d.GalileoSlmsg = dropWeak(d.GalileoSlmsg, minSNR)
+	if d.BeidouSlmsg != nil {
+		beidou := make([]BeidouNmeaSatelliteMsg, 0, len(d.BeidouSlmsg))
+		for _, s := range d.BeidouSlmsg {
+			if !isSatellitePadding(s.BeidouNum, s.BeidouEledeg, s.BeidouSN) && int(s.BeidouSN) >= minSNR {
+				beidou = append(beidou, s)
+			}
+		}
+		d.BeidouSlmsg = beidou
+	}
+	d.SatellitesListed = len(d.Slmsg) + len(d.BeidouSlmsg) + len(d.GlonassSlmsg) + len(d.GalileoSlmsg)
+}
+
+// AverageSNR returns the mean SNR of the satellites with a non-zero SNR, or 0 if there are none
+func AverageSNR(sats []SatelliteInfo) float64 {
+	total, n := 0, 0
+	for _, s := range sats {
+		if s.SNR > 0 {
+			total += s.SNR
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return float64(total) / float64(n)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUsedSatellitePRNs(t *testing.T) {
+	tests := []struct {
+		name  string
+		possl []uint8
+		want  []int
+	}{
+		{"sample GSA", []uint8{4, 5, 9, 12, 17, 23, 25, 0, 0, 0, 0, 0}, []int{4, 5, 9, 12, 17, 23, 25}},
+		{"full", []uint8{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}},
+		{"padding between PRNs", []uint8{7, 0, 201, 0}, []int{7, 201}},
+		{"all padding", make([]uint8, 12), nil},
+		{"missing", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UsedSatellitePRNs(tt.possl); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UsedSatellitePRNs(%v) = %v, want %v", tt.possl, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSatellitesUsed(t *testing.T) {
+	sample := []uint8{4, 5, 9, 12, 17, 23, 25, 0, 0, 0, 0, 0}
+	tests := []struct {
+		name     string
+		posslnum uint8
+		possl    []uint8
+		want     int
+	}{
+		{"count matches the list", 7, sample, 7},
+		{"count not filled in", 0, sample, 7},
+		{"more used than the list holds", 16, []uint8{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, 16},
+		{"no fix", 0, make([]uint8, 12), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &GnssFullData{Posslnum: tt.posslnum, Possl: tt.possl}
+			if got := d.SatellitesUsed(); got != tt.want {
+				t.Errorf("SatellitesUsed() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToGnssDataUsedAndVisible(t *testing.T) {
+	full := &GnssFullData{
+		Svnum:       11,
+		BeidouSvnum: 6,
+		Posslnum:    0,
+		Possl:       []uint8{4, 5, 9, 12, 17, 23, 25, 0, 0, 0, 0, 0},
+	}
+	data := full.ToGnssData(false)
+	if data.SatellitesUsed != 7 || data.SatellitesVisible != 17 {
+		t.Errorf("satellites used %d and visible %d, want 7 and 17", data.SatellitesUsed, data.SatellitesVisible)
+	}
+	if want := []int{4, 5, 9, 12, 17, 23, 25}; !reflect.DeepEqual(data.UsedPRNs, want) {
+		t.Errorf("UsedPRNs = %v, want %v", data.UsedPRNs, want)
+	}
+}
+
+func TestTrimSatellitePadding(t *testing.T) {
+	tests := []struct {
+		name string
+		msgs []NmeaSatelliteMsg
+		want []NmeaSatelliteMsg
+	}{
+		{
+			name: "partially filled",
+			msgs: []NmeaSatelliteMsg{{Num: 5, Eledeg: 45, Azideg: 120, SN: 38}, {Num: 12, Eledeg: 10, Azideg: 300}, {}, {}, {}},
+			want: []NmeaSatelliteMsg{{Num: 5, Eledeg: 45, Azideg: 120, SN: 38}, {Num: 12, Eledeg: 10, Azideg: 300}},
+		},
+		{
+			name: "padding between satellites",
+			msgs: []NmeaSatelliteMsg{{}, {Num: 7, SN: 22}, {}, {Num: 9}},
+			want: []NmeaSatelliteMsg{{Num: 7, SN: 22}, {Num: 9}},
+		},
+		{
+			name: "unnumbered entry with a signal",
+			msgs: []NmeaSatelliteMsg{{SN: 20}, {Eledeg: 5}},
+			want: []NmeaSatelliteMsg{{SN: 20}, {Eledeg: 5}},
+		},
+		{
+			name: "azimuth alone is padding",
+			msgs: []NmeaSatelliteMsg{{Azideg: 90}},
+			want: []NmeaSatelliteMsg{},
+		},
+		{"all padding", make([]NmeaSatelliteMsg, 12), []NmeaSatelliteMsg{}},
+		{"missing", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TrimSatellitePadding(tt.msgs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TrimSatellitePadding() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrimBeidouSatellitePadding(t *testing.T) {
+	msgs := []BeidouNmeaSatelliteMsg{{BeidouNum: 21, BeidouEledeg: 60, BeidouSN: 41}, {}, {BeidouNum: 30}, {}}
+	want := []BeidouNmeaSatelliteMsg{{BeidouNum: 21, BeidouEledeg: 60, BeidouSN: 41}, {BeidouNum: 30}}
+	if got := TrimBeidouSatellitePadding(msgs); !reflect.DeepEqual(got, want) {
+		t.Errorf("TrimBeidouSatellitePadding() = %+v, want %+v", got, want)
+	}
+	if got := TrimBeidouSatellitePadding(make([]BeidouNmeaSatelliteMsg, 12)); got == nil || len(got) != 0 {
+		t.Errorf("TrimBeidouSatellitePadding() of padding = %#v, want an empty list", got)
+	}
+}
+
+func TestToGnssDataSatellitePadding(t *testing.T) {
+	gps := make([]NmeaSatelliteMsg, 12)
+	gps[0] = NmeaSatelliteMsg{Num: 5, Eledeg: 45, Azideg: 120, SN: 38}
+	gps[1] = NmeaSatelliteMsg{Num: 12, Eledeg: 10, Azideg: 300}
+	beidou := make([]BeidouNmeaSatelliteMsg, 12)
+	beidou[0] = BeidouNmeaSatelliteMsg{BeidouNum: 21, BeidouEledeg: 60, BeidouSN: 41}
+	full := &GnssFullData{
+		Svnum:        2,
+		BeidouSvnum:  1,
+		Slmsg:        gps,
+		BeidouSlmsg:  beidou,
+		GlonassSlmsg: make([]NmeaSatelliteMsg, 12),
+	}
+	tests := []struct {
+		keepPadding         bool
+		wantGPS, wantBeidou int
+		wantGlonass         int
+	}{
+		{false, 2, 1, 0},
+		{true, 12, 12, 12},
+	}
+	for _, tt := range tests {
+		data := full.ToGnssData(tt.keepPadding)
+		if len(data.Slmsg) != tt.wantGPS || len(data.BeidouSlmsg) != tt.wantBeidou || len(data.GlonassSlmsg) != tt.wantGlonass {
+			t.Errorf("ToGnssData(%t) has %d GPS, %d BeiDou and %d GLONASS entries, want %d, %d and %d", tt.keepPadding,
+				len(data.Slmsg), len(data.BeidouSlmsg), len(data.GlonassSlmsg), tt.wantGPS, tt.wantBeidou, tt.wantGlonass)
+		}
+		if data.SatellitesListed != 3 {
+			t.Errorf("ToGnssData(%t).SatellitesListed = %d, want the 3 real satellites", tt.keepPadding, data.SatellitesListed)
+		}
+		if !tt.keepPadding && !reflect.DeepEqual(data.Slmsg, gps[:2]) {
+			t.Errorf("ToGnssData(false).Slmsg = %+v, want %+v", data.Slmsg, gps[:2])
+		}
+	}
+
+	// An all-padding GPS array still serializes as a list
+	raw, err := json.Marshal((&GnssFullData{Slmsg: make([]NmeaSatelliteMsg, 12)}).ToGnssData(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), `"Slmsg":[]`) {
+		t.Errorf("payload %s, want an empty Slmsg list", raw)
+	}
+	if len(full.Slmsg) != 12 {
+		t.Errorf("ToGnssData() trimmed the modem's array to %d entries in place", len(full.Slmsg))
+	}
+}
+
+func TestDropWeakSatellites(t *testing.T) {
+	data := GnssData{
+		Svnum:       3,
+		BeidouSvnum: 2,
+		Slmsg:       []NmeaSatelliteMsg{{Num: 5, SN: 38}, {Num: 12, SN: 19}, {Num: 14}, {}},
+		BeidouSlmsg: []BeidouNmeaSatelliteMsg{{BeidouNum: 21, BeidouSN: 20}, {BeidouNum: 30, BeidouSN: 8}},
+	}
+	data.DropWeakSatellites(20)
+	if want := []NmeaSatelliteMsg{{Num: 5, SN: 38}}; !reflect.DeepEqual(data.Slmsg, want) {
+		t.Errorf("Slmsg = %+v, want %+v", data.Slmsg, want)
+	}
+	if want := []BeidouNmeaSatelliteMsg{{BeidouNum: 21, BeidouSN: 20}}; !reflect.DeepEqual(data.BeidouSlmsg, want) {
+		t.Errorf("BeidouSlmsg = %+v, want %+v", data.BeidouSlmsg, want)
+	}
+	if data.GlonassSlmsg != nil || data.SatellitesListed != 2 || data.SatellitesInView() != 5 {
+		t.Errorf("GLONASS %v, %d listed and %d in view, want nil, 2 and the reported 5",
+			data.GlonassSlmsg, data.SatellitesListed, data.SatellitesInView())
+	}
+}
+
+func TestSatellitesSkipOnlyPadding(t *testing.T) {
+	// An unnumbered entry with a signal isn't padding, matching TrimSatellitePadding
+	data := GnssData{
+		Slmsg:       []NmeaSatelliteMsg{{SN: 20}, {}},
+		BeidouSlmsg: []BeidouNmeaSatelliteMsg{{BeidouEledeg: 15}, {}},
+	}
+	want := []SatelliteInfo{
+		{Constellation: ConstellationGPS, SNR: 20},
+		{Constellation: ConstellationBeidou, Elevation: 15},
+	}
+	if got := data.Satellites(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Satellites() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// Supported values for VALIDATE_SCHEMA
+const (
+	SchemaModeLog  = "log"  // Log non-conforming payloads but still publish them
+	SchemaModeDrop = "drop" // Log and drop non-conforming payloads
+)
+
+//go:embed gnss_data.schema.json
+var gnssDataSchema []byte
+
+// PayloadValidator checks marshaled GnssData against the embedded JSON Schema
+type PayloadValidator struct {
+	schema *jsonschema.Schema
+}
+
+// NewPayloadValidator compiles the embedded GnssData schema
+func NewPayloadValidator() (*PayloadValidator, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(gnssDataSchema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse embedded schema: %w", err)
+	}
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("gnss_data.schema.json", doc); err != nil {
+		return nil, err
+	}
+	schema, err := c.Compile("gnss_data.schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile embedded schema: %w", err)
+	}
+	return &PayloadValidator{schema: schema}, nil
+}
+
+// Validate returns an error describing every violation when payload doesn't conform to the schema
+func (v *PayloadValidator) Validate(payload []byte) error {
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("payload is not valid JSON: %w", err)
+	}
+	return v.schema.Validate(inst)
+}
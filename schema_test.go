@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// sampleFix returns a realistic 3D fix in London with padded satellite arrays, as the modem reports them
+func sampleFix() *GnssFullData {
+	return &GnssFullData{
+		Valid:          1,
+		LastLockTimeMs: 1200,
+		Svnum:          9,
+		BeidouSvnum:    4,
+		NSHemi:         "N",
+		EWHemi:         "W",
+		Latitude:       51.5007,
+		Longitude:      -0.1246,
+		Gpssta:         1,
+		Posslnum:       6,
+		Fixmode:        3,
+		Pdop:           1.8,
+		Hdop:           0.9,
+		Vdop:           1.5,
+		Altitude:       35.2,
+		Speed:          12.5,
+		Utc:            NmeaUtcTime{Year: 2024, Month: 6, Date: 1, Hour: 12, Min: 30, Sec: 15},
+		Slmsg: []NmeaSatelliteMsg{
+			{Num: 3, Eledeg: 45, Azideg: 120, SN: 38},
+			{Num: 7, Eledeg: 30, Azideg: 250, SN: 31},
+			{Num: 12, Eledeg: 70, Azideg: 15, SN: 42},
+			{}, {},
+		},
+		BeidouSlmsg: []BeidouNmeaSatelliteMsg{
+			{BeidouNum: 21, BeidouEledeg: 40, BeidouAzideg: 100, BeidouSN: 35},
+			{},
+		},
+		Possl: []uint8{3, 7, 12, 21, 0, 0, 0, 0, 0, 0, 0, 0},
+	}
+}
+
+func TestPayloadValidator(t *testing.T) {
+	v, err := NewPayloadValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := sampleFix().ToGnssData(false)
+	data.SpeedUnit = SpeedUnitKmh // Set by the main loop before publishing
+	valid, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Validate(valid); err != nil {
+		t.Fatalf("published payload fails validation: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(m map[string]any)
+	}{
+		{"missing latitude", func(m map[string]any) { delete(m, "Latitude") }},
+		{"latitude out of range", func(m map[string]any) { m["Latitude"] = 123.4 }},
+		{"satellite count as string", func(m map[string]any) { m["Svnum"] = "9" }},
+		{"satellite count overflow", func(m map[string]any) { m["Svnum"] = 300 }},
+		{"unknown fix mode text", func(m map[string]any) { m["fix_mode_text"] = "4D" }},
+		{"device id with wildcard", func(m map[string]any) { m["device_id"] = "tachyon/#" }},
+		{"utc without year", func(m map[string]any) { delete(m["Utc"].(map[string]any), "Year") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m map[string]any
+			if err := json.Unmarshal(valid, &m); err != nil {
+				t.Fatal(err)
+			}
+			tt.mutate(m)
+			payload, err := json.Marshal(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := v.Validate(payload); err == nil {
+				t.Errorf("Validate(%s) passed, want a violation", payload)
+			}
+		})
+	}
+
+	if err := v.Validate([]byte(`{"Latitude":`)); err == nil {
+		t.Error("Validate() of truncated JSON passed, want an error")
+	}
+}
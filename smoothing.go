@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// smoothingSample is one fix in a SmoothingFilter window
+type smoothingSample struct {
+	lat, lon, alt float64
+	weight        float64
+}
+
+// SmoothingFilter replaces each position with the weighted average of the last window fixes,
+// taming the jitter of a stationary device. Fixes are weighted by 1/HDOP², so poor fixes
+// contribute less; fixes without an HDOP get the weight of an HDOP of 1.
+type SmoothingFilter struct {
+	window  int
+	samples []smoothingSample // Most recent fixes, oldest first
+}
+
+// NewSmoothingFilter creates a filter averaging over a window of at least 2 fixes
+func NewSmoothingFilter(window int) (*SmoothingFilter, error) {
+	if window < 2 {
+		return nil, fmt.Errorf("window must be at least 2, got %d", window)
+	}
+	return &SmoothingFilter{window: window}, nil
+}
+
+// Apply adds a fix to the window, dropping the oldest once it's full, and returns the
+// averaged position and altitude of the fixes in the window
+func (f *SmoothingFilter) Apply(lat, lon, alt, hdop float64) (float64, float64, float64) {
+	weight := 1.0
+	if hdop > 0 {
+		weight = 1 / (hdop * hdop)
+	}
+	f.samples = append(f.samples, smoothingSample{lat: lat, lon: lon, alt: alt, weight: weight})
+	if len(f.samples) > f.window {
+		f.samples = f.samples[1:]
+	}
+	var sumLat, sumLon, sumAlt, sumWeight float64
+	for _, s := range f.samples {
+		// Average longitudes as offsets from the newest fix so the antimeridian doesn't split them
+		dLon := math.Remainder(s.lon-lon, 360)
+		sumLat += s.lat * s.weight
+		sumLon += dLon * s.weight
+		sumAlt += s.alt * s.weight
+		sumWeight += s.weight
+	}
+	avgLon := math.Remainder(lon+sumLon/sumWeight, 360)
+	return sumLat / sumWeight, avgLon, sumAlt / sumWeight
+}
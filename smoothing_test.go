@@ -0,0 +1,71 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSmoothingFilterKnownSequence(t *testing.T) {
+	filter, err := NewSmoothingFilter(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		lat, lon, alt float64
+		want          [3]float64 // Averaged lat, lon and alt
+	}{
+		{51.0, -1.0, 10, [3]float64{51.0, -1.0, 10}},   // Window filling: one sample
+		{51.3, -1.3, 40, [3]float64{51.15, -1.15, 25}}, // Two samples
+		{51.6, -1.6, 70, [3]float64{51.3, -1.3, 40}},   // Full
+		{52.5, -2.5, 160, [3]float64{51.8, -1.8, 90}},  // The first fix ages out
+		{52.5, -2.5, 160, [3]float64{52.2, -2.2, 130}},
+		{52.5, -2.5, 160, [3]float64{52.5, -2.5, 160}}, // Only the latest value remains
+	}
+	for i, s := range steps {
+		lat, lon, alt := filter.Apply(s.lat, s.lon, s.alt, 0)
+		if math.Abs(lat-s.want[0]) > 1e-9 || math.Abs(lon-s.want[1]) > 1e-9 || math.Abs(alt-s.want[2]) > 1e-9 {
+			t.Errorf("step %d: Apply() = %v, %v, %v, want %v", i, lat, lon, alt, s.want)
+		}
+	}
+}
+
+func TestSmoothingFilterWeightsByHdop(t *testing.T) {
+	filter, err := NewSmoothingFilter(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter.Apply(51.0, -1.0, 10, 1)
+	// An HDOP of 3 weighs a ninth as much as an HDOP of 1
+	lat, lon, alt := filter.Apply(52.0, -2.0, 110, 3)
+	if math.Abs(lat-51.1) > 1e-9 || math.Abs(lon+1.1) > 1e-9 || math.Abs(alt-20) > 1e-9 {
+		t.Errorf("Apply() = %v, %v, %v, want 51.1, -1.1, 20", lat, lon, alt)
+	}
+	// A fix without an HDOP counts as an HDOP of 1, and the first fix has aged out
+	lat, _, _ = filter.Apply(51.0, -1.0, 10, 0)
+	if math.Abs(lat-51.1) > 1e-9 {
+		t.Errorf("Apply() without an HDOP = %v, want 51.1", lat)
+	}
+}
+
+func TestSmoothingFilterAntimeridian(t *testing.T) {
+	filter, err := NewSmoothingFilter(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter.Apply(0, 179.9, 0, 1)
+	_, lon, _ := filter.Apply(0, -179.9, 0, 1)
+	if math.Abs(math.Abs(lon)-180) > 1e-9 {
+		t.Errorf("average of 179.9 and -179.9 = %v, want ±180", lon)
+	}
+}
+
+func TestNewSmoothingFilterWindow(t *testing.T) {
+	for _, window := range []int{-1, 0, 1} {
+		if _, err := NewSmoothingFilter(window); err == nil {
+			t.Errorf("NewSmoothingFilter(%d) succeeded, want an error", window)
+		}
+	}
+	if _, err := NewSmoothingFilter(2); err != nil {
+		t.Errorf("NewSmoothingFilter(2) = %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+// DefaultUsableSNR is the SNR in dB-Hz from which a satellite's signal counts as usable, when
+// USABLE_SNR_THRESHOLD is unset. Below about 30 dB-Hz receivers struggle to hold a lock.
+const DefaultUsableSNR = 30
+
+// ConstellationAverageSNR returns the mean SNR of the satellites of one constellation with a
+// non-zero SNR, and false if there are none
+func ConstellationAverageSNR(sats []SatelliteInfo, constellation string) (float64, bool) {
+	total, n := 0, 0
+	for _, s := range sats {
+		if s.Constellation == constellation && s.SNR > 0 {
+			total += s.SNR
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return float64(total) / float64(n), true
+}
+
+// CountUsableSNR returns how many satellites have an SNR of at least threshold dB-Hz, which
+// must be positive so untracked satellites aren't counted
+func CountUsableSNR(sats []SatelliteInfo, threshold int) int {
+	n := 0
+	for _, s := range sats {
+		if s.SNR >= threshold {
+			n++
+		}
+	}
+	return n
+}
+
+// ApplySNRStats sets the GPS and BeiDou average SNR, left out for a constellation with no SNR
+// reported, and the number of satellites in any constellation at or above usableSNR dB-Hz
+func (d *GnssData) ApplySNRStats(usableSNR int) {
+	sats := d.Satellites()
+	if avg, ok := ConstellationAverageSNR(sats, ConstellationGPS); ok {
+		d.AvgSNRGPS = &avg
+	}
+	if avg, ok := ConstellationAverageSNR(sats, ConstellationBeidou); ok {
+		d.AvgSNRBeidou = &avg
+	}
+	usable := CountUsableSNR(sats, usableSNR)
+	d.SatellitesAboveSNR = &usable
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// SNRBucketWidth is the width in dB-Hz of each SNR histogram bucket
+	SNRBucketWidth = 10
+	// SNRBucketCount is the number of buckets; the last is open-ended
+	SNRBucketCount = 6
+)
+
+// SNRBucket counts the satellites whose SNR falls in a range
+type SNRBucket struct {
+	Range string `json:"range"` // e.g. "10-20" for 10 <= SNR < 20, or "50+" for the last bucket
+	Count int    `json:"count"`
+}
+
+// SNRHistogram is the SNR distribution of the satellites in a fix, published to <topic>/snr_histogram
+type SNRHistogram struct {
+	Time      string      `json:"time"`      // RFC3339 time of the fix
+	Buckets   []SNRBucket `json:"buckets"`   // In ascending SNR order
+	Untracked int         `json:"untracked"` // Satellites in view with no SNR reported
+}
+
+// NewSNRHistogram buckets the SNR of each satellite; satellites reporting an SNR of 0 aren't
+// being tracked and are counted separately
+func NewSNRHistogram(sats []SatelliteInfo, now time.Time) SNRHistogram {
+	h := SNRHistogram{Time: now.UTC().Format(time.RFC3339), Buckets: make([]SNRBucket, SNRBucketCount)}
+	for i := range h.Buckets {
+		low := i * SNRBucketWidth
+		if i == SNRBucketCount-1 {
+			h.Buckets[i].Range = fmt.Sprintf("%d+", low)
+		} else {
+			h.Buckets[i].Range = fmt.Sprintf("%d-%d", low, low+SNRBucketWidth)
+		}
+	}
+	for _, s := range sats {
+		if s.SNR <= 0 {
+			h.Untracked++
+			continue
+		}
+		h.Buckets[min(s.SNR/SNRBucketWidth, SNRBucketCount-1)].Count++
+	}
+	return h
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewSNRHistogram(t *testing.T) {
+	data := GnssData{
+		Slmsg: []NmeaSatelliteMsg{
+			{Num: 1, SN: 5}, {Num: 2, SN: 10}, {Num: 3, SN: 19}, {Num: 4, SN: 35},
+			{Num: 5, SN: 38}, {Num: 6, SN: 0}, {}, {},
+		},
+		BeidouSlmsg:  []BeidouNmeaSatelliteMsg{{BeidouNum: 21, BeidouSN: 42}, {BeidouNum: 22, BeidouSN: 51}, {}},
+		GalileoSlmsg: []NmeaSatelliteMsg{{Num: 30, SN: 49}, {Num: 31, SN: 99}, {Num: 32, SN: 0}},
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	got := NewSNRHistogram(data.Satellites(), now)
+	want := SNRHistogram{
+		Time: "2024-01-01T11:00:00Z",
+		Buckets: []SNRBucket{
+			{Range: "0-10", Count: 1},
+			{Range: "10-20", Count: 2},
+			{Range: "20-30", Count: 0},
+			{Range: "30-40", Count: 2},
+			{Range: "40-50", Count: 2},
+			{Range: "50+", Count: 2},
+		},
+		Untracked: 2,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewSNRHistogram() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewSNRHistogramEmpty(t *testing.T) {
+	got := NewSNRHistogram(nil, time.Unix(0, 0))
+	if len(got.Buckets) != SNRBucketCount || got.Untracked != 0 {
+		t.Fatalf("NewSNRHistogram(nil) = %+v, want %d empty buckets", got, SNRBucketCount)
+	}
+	for _, b := range got.Buckets {
+		if b.Count != 0 {
+			t.Errorf("bucket %s = %d, want 0", b.Range, b.Count)
+		}
+	}
+}
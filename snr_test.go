@@ -0,0 +1,102 @@
+package main
+
+import "testing"
+
+func TestApplySNRStats(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         GnssData
+		threshold    int
+		wantGPS      float64 // 0 when the average is left out
+		wantBeidou   float64
+		wantAboveSNR int
+	}{
+		{
+			name: "both constellations with padding",
+			data: GnssData{
+				Slmsg:       []NmeaSatelliteMsg{{Num: 1, SN: 20}, {Num: 2, SN: 35}, {Num: 3, SN: 41}, {Num: 4, SN: 0}, {}, {}},
+				BeidouSlmsg: []BeidouNmeaSatelliteMsg{{BeidouNum: 21, BeidouSN: 30}, {BeidouNum: 22, BeidouSN: 45}, {}},
+			},
+			threshold:    DefaultUsableSNR,
+			wantGPS:      32,
+			wantBeidou:   37.5,
+			wantAboveSNR: 4,
+		},
+		{
+			name: "other constellations count towards the threshold only",
+			data: GnssData{
+				Slmsg:        []NmeaSatelliteMsg{{Num: 5, SN: 28}},
+				GlonassSlmsg: []NmeaSatelliteMsg{{Num: 70, SN: 33}},
+				GalileoSlmsg: []NmeaSatelliteMsg{{Num: 30, SN: 40}, {Num: 31, SN: 12}},
+			},
+			threshold:    DefaultUsableSNR,
+			wantGPS:      28,
+			wantAboveSNR: 2,
+		},
+		{
+			name: "higher threshold",
+			data: GnssData{
+				Slmsg: []NmeaSatelliteMsg{{Num: 1, SN: 20}, {Num: 2, SN: 35}, {Num: 3, SN: 41}},
+			},
+			threshold:    40,
+			wantGPS:      32,
+			wantAboveSNR: 1,
+		},
+		{
+			name: "only untracked satellites",
+			data: GnssData{
+				Slmsg:       []NmeaSatelliteMsg{{Num: 1}, {Num: 2}, {}},
+				BeidouSlmsg: []BeidouNmeaSatelliteMsg{{BeidouNum: 21}},
+			},
+			threshold: DefaultUsableSNR,
+		},
+		{
+			name:      "no satellites",
+			threshold: DefaultUsableSNR,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data
+			data.ApplySNRStats(tt.threshold)
+			checkAverage := func(name string, got *float64, want float64) {
+				t.Helper()
+				switch {
+				case want == 0 && got != nil:
+					t.Errorf("%s = %v, want it left out", name, *got)
+				case want != 0 && (got == nil || *got != want):
+					t.Errorf("%s = %v, want %v", name, got, want)
+				}
+			}
+			checkAverage("AvgSNRGPS", data.AvgSNRGPS, tt.wantGPS)
+			checkAverage("AvgSNRBeidou", data.AvgSNRBeidou, tt.wantBeidou)
+			if data.SatellitesAboveSNR == nil || *data.SatellitesAboveSNR != tt.wantAboveSNR {
+				t.Errorf("SatellitesAboveSNR = %v, want %d", data.SatellitesAboveSNR, tt.wantAboveSNR)
+			}
+		})
+	}
+}
+
+func TestConstellationAverageSNR(t *testing.T) {
+	sats := []SatelliteInfo{
+		{Constellation: ConstellationGPS, Num: 1, SNR: 25},
+		{Constellation: ConstellationGPS, Num: 2, SNR: 0},
+		{Constellation: ConstellationGPS, Num: 3, SNR: 36},
+		{Constellation: ConstellationGalileo, Num: 30, SNR: 50},
+	}
+	tests := []struct {
+		constellation string
+		want          float64
+		wantOK        bool
+	}{
+		{ConstellationGPS, 30.5, true},
+		{ConstellationGalileo, 50, true},
+		{ConstellationBeidou, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ConstellationAverageSNR(sats, tt.constellation)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ConstellationAverageSNR(%s) = %v, %t, want %v, %t", tt.constellation, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
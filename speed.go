@@ -0,0 +1,45 @@
+package main
+
+import "fmt"
+
+// Supported values for SPEED_UNIT. The modem reports ground speed in km/h, which is what
+// SpeedUnitRaw passes through unchanged.
+const (
+	SpeedUnitRaw   = "raw"
+	SpeedUnitKmh   = "kmh"
+	SpeedUnitMph   = "mph"
+	SpeedUnitKnots = "knots"
+	SpeedUnitMs    = "ms"
+)
+
+// speedFactors converts the modem's km/h into each unit
+var speedFactors = map[string]float64{
+	SpeedUnitRaw:   1,
+	SpeedUnitKmh:   1,
+	SpeedUnitMph:   1 / 1.609344,
+	SpeedUnitKnots: 1 / 1.852,
+	SpeedUnitMs:    1 / 3.6,
+}
+
+// convertSpeed converts a raw modem speed in km/h to the given SpeedUnit
+func convertSpeed(raw float64, to string) (float64, error) {
+	factor, ok := speedFactors[to]
+	if !ok {
+		return 0, fmt.Errorf("unsupported speed unit %q", to)
+	}
+	return raw * factor, nil
+}
+
+// homeAssistantSpeedUnit returns Home Assistant's unit_of_measurement for a SpeedUnit
+func homeAssistantSpeedUnit(unit string) string {
+	switch unit {
+	case SpeedUnitMph:
+		return "mph"
+	case SpeedUnitKnots:
+		return "kn"
+	case SpeedUnitMs:
+		return "m/s"
+	default:
+		return "km/h"
+	}
+}
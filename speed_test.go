@@ -0,0 +1,50 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvertSpeed(t *testing.T) {
+	tests := []struct {
+		to   string
+		raw  float64
+		want float64
+	}{
+		{SpeedUnitRaw, 100, 100},
+		{SpeedUnitKmh, 100, 100},
+		{SpeedUnitMph, 100, 62.137119},
+		{SpeedUnitKnots, 100, 53.995680},
+		{SpeedUnitMs, 36, 10},
+		{SpeedUnitMph, 0, 0},
+	}
+	for _, tt := range tests {
+		got, err := convertSpeed(tt.raw, tt.to)
+		if err != nil {
+			t.Errorf("convertSpeed(%v, %q) = %v", tt.raw, tt.to, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("convertSpeed(%v, %q) = %v, want %v", tt.raw, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestConvertSpeedUnknownUnit(t *testing.T) {
+	for _, unit := range []string{"", "mps", "KMH", "furlongs"} {
+		if _, err := convertSpeed(10, unit); err == nil {
+			t.Errorf("convertSpeed(10, %q) succeeded, want an error", unit)
+		}
+	}
+}
+
+func TestHomeAssistantSpeedUnit(t *testing.T) {
+	tests := map[string]string{
+		SpeedUnitRaw: "km/h", SpeedUnitKmh: "km/h", SpeedUnitMph: "mph", SpeedUnitKnots: "kn", SpeedUnitMs: "m/s",
+	}
+	for unit, want := range tests {
+		if got := homeAssistantSpeedUnit(unit); got != want {
+			t.Errorf("homeAssistantSpeedUnit(%q) = %q, want %q", unit, got, want)
+		}
+	}
+}
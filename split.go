@@ -0,0 +1,71 @@
+package main
+
+// GnssPosition is the lightweight payload published to <topic>/gnss when PAYLOAD_SPLIT is set,
+// leaving out the satellite detail. Keys match the full GnssData payload.
+type GnssPosition struct {
+	DeviceID  string `json:"device_id,omitempty"`
+	Valid     int32
+	Timestamp string `json:",omitempty"`
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+	Speed     float64
+	SpeedUnit string `json:"speed_unit"`
+}
+
+// NewGnssPosition extracts the position payload from a fix
+func NewGnssPosition(data *GnssData) GnssPosition {
+	return GnssPosition{
+		DeviceID:  data.DeviceID,
+		Valid:     data.Valid,
+		Timestamp: data.Timestamp,
+		Latitude:  data.Latitude,
+		Longitude: data.Longitude,
+		Altitude:  data.Altitude,
+		Speed:     data.Speed,
+		SpeedUnit: data.SpeedUnit,
+	}
+}
+
+// GnssSatellites is the satellite detail published to <topic>/gnss/satellites when
+// PAYLOAD_SPLIT is set. Keys match the full GnssData payload.
+type GnssSatellites struct {
+	DeviceID          string `json:"device_id,omitempty"`
+	Timestamp         string `json:",omitempty"`
+	Svnum             uint8
+	BeidouSvnum       uint8
+	GlonassSvnum      uint8
+	GalileoSvnum      uint8
+	Posslnum          uint8
+	SatellitesUsed    int   `json:"satellites_used"`
+	SatellitesVisible int   `json:"satellites_visible"`
+	SatellitesListed  int   `json:"satellites_listed"`
+	UsedPRNs          []int `json:"used_prns,omitempty"`
+	Slmsg             []NmeaSatelliteMsg
+	BeidouSlmsg       []BeidouNmeaSatelliteMsg
+	GlonassSlmsg      []NmeaSatelliteMsg `json:",omitempty"`
+	GalileoSlmsg      []NmeaSatelliteMsg `json:",omitempty"`
+	Possl             []uint8
+}
+
+// NewGnssSatellites extracts the satellite payload from a fix
+func NewGnssSatellites(data *GnssData) GnssSatellites {
+	return GnssSatellites{
+		DeviceID:          data.DeviceID,
+		Timestamp:         data.Timestamp,
+		Svnum:             data.Svnum,
+		BeidouSvnum:       data.BeidouSvnum,
+		GlonassSvnum:      data.GlonassSvnum,
+		GalileoSvnum:      data.GalileoSvnum,
+		Posslnum:          data.Posslnum,
+		SatellitesUsed:    data.SatellitesUsed,
+		SatellitesVisible: data.SatellitesVisible,
+		SatellitesListed:  data.SatellitesListed,
+		UsedPRNs:          data.UsedPRNs,
+		Slmsg:             data.Slmsg,
+		BeidouSlmsg:       data.BeidouSlmsg,
+		GlonassSlmsg:      data.GlonassSlmsg,
+		GalileoSlmsg:      data.GalileoSlmsg,
+		Possl:             data.Possl,
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// sseClientBuffer is how many events may queue for a slow client before new ones are dropped
+const sseClientBuffer = 16
+
+// SSEBroker fans published fixes out to Server-Sent Events clients
+type SSEBroker struct {
+	mu      sync.Mutex
+	clients map[chan []byte]struct{}
+}
+
+// NewSSEBroker creates a broker with no connected clients
+func NewSSEBroker() *SSEBroker {
+	return &SSEBroker{clients: make(map[chan []byte]struct{})}
+}
+
+// Broadcast queues a JSON event for every connected client, dropping it for clients that are too far behind
+func (b *SSEBroker) Broadcast(data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.clients {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// subscribe registers a new client channel
+func (b *SSEBroker) subscribe() chan []byte {
+	ch := make(chan []byte, sseClientBuffer)
+	b.mu.Lock()
+	b.clients[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+// unsubscribe removes a client channel
+func (b *SSEBroker) unsubscribe(ch chan []byte) {
+	b.mu.Lock()
+	delete(b.clients, ch)
+	b.mu.Unlock()
+}
+
+// ServeHTTP streams events to the client until it disconnects or the server shuts down
+func (b *SSEBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := b.subscribe()
+	defer b.unsubscribe(ch)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-ch:
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
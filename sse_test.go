@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// waitForClients waits until the broker has n connected clients
+func waitForClients(t *testing.T, b *SSEBroker, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		b.mu.Lock()
+		got := len(b.clients)
+		b.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("broker didn't reach %d clients", n)
+}
+
+func TestSSEClientReceivesFix(t *testing.T) {
+	broker := NewSSEBroker()
+	srv := httptest.NewServer(broker)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	for header, want := range map[string]string{"Content-Type": "text/event-stream", "Cache-Control": "no-cache"} {
+		if got := resp.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	waitForClients(t, broker, 1)
+
+	fix := GnssData{Latitude: 51.5, Longitude: -0.12, Valid: 1}
+	payload, err := json.Marshal(fix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	broker.Broadcast(payload)
+
+	r := bufio.NewReader(resp.Body)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "data: " + string(payload) + "\n"; line != want {
+		t.Fatalf("event line = %q, want %q", line, want)
+	}
+	if blank, _ := r.ReadString('\n'); blank != "\n" {
+		t.Errorf("event terminator = %q, want a blank line", blank)
+	}
+
+	resp.Body.Close()
+	waitForClients(t, broker, 0)
+}
+
+func TestSSEBroadcastDropsForSlowClient(t *testing.T) {
+	broker := NewSSEBroker()
+	ch := broker.subscribe()
+	defer broker.unsubscribe(ch)
+	for range sseClientBuffer + 5 {
+		broker.Broadcast([]byte(`{}`))
+	}
+	if len(ch) != sseClientBuffer {
+		t.Errorf("client queue holds %d events, want %d", len(ch), sseClientBuffer)
+	}
+}
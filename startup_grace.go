@@ -0,0 +1,41 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// StartupGrace defers announcing the device (birth message and online status) until it has
+// stayed up for a grace period and read a valid fix, so rapid power cycles don't churn the
+// retained topics
+type StartupGrace struct {
+	until  time.Time
+	sawFix atomic.Bool
+	done   atomic.Bool
+}
+
+// NewStartupGrace creates a grace period of d starting at now
+func NewStartupGrace(now time.Time, d time.Duration) *StartupGrace {
+	return &StartupGrace{until: now.Add(d)}
+}
+
+// ObserveFix records whether a fix read during the grace period was valid
+func (g *StartupGrace) ObserveFix(valid bool) {
+	if valid {
+		g.sawFix.Store(true)
+	}
+}
+
+// Done reports whether the grace period has completed
+func (g *StartupGrace) Done() bool {
+	return g.done.Load()
+}
+
+// Complete ends the grace period once it has elapsed and a valid fix has been seen, returning
+// true exactly once, on the call that ends it
+func (g *StartupGrace) Complete(now time.Time) bool {
+	if g.done.Load() || now.Before(g.until) || !g.sawFix.Load() {
+		return false
+	}
+	return g.done.CompareAndSwap(false, true)
+}
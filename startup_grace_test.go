@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStartupGraceDefersAnnouncement(t *testing.T) {
+	tests := []struct {
+		name  string
+		fixes []bool // Validity of the fix read each second, starting at second 1
+		want  int    // Second the announcement is made, 0 for never
+	}{
+		{"valid fix throughout", []bool{true, true, true, true, true, true, true}, 5},
+		{"valid fix only after the grace period", []bool{false, false, false, false, false, false, true}, 7},
+		{"valid fix early then lost", []bool{true, false, false, false, false, false}, 5},
+		{"no valid fix", []bool{false, false, false, false, false, false, false, false}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			grace := NewStartupGrace(start, 5*time.Second)
+			announced := 0
+			for i, valid := range tt.fixes {
+				second := i + 1
+				grace.ObserveFix(valid)
+				if grace.Complete(start.Add(time.Duration(second) * time.Second)) {
+					if announced != 0 {
+						t.Fatalf("Complete() returned true again at second %d", second)
+					}
+					announced = second
+				}
+			}
+			if announced != tt.want {
+				t.Errorf("announced at second %d, want %d", announced, tt.want)
+			}
+			if grace.Done() != (tt.want != 0) {
+				t.Errorf("Done() = %t, want %t", grace.Done(), tt.want != 0)
+			}
+		})
+	}
+}
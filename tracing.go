@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the poll, read and publish spans. It goes through the global provider, so it
+// records nothing unless setupTracing installed an exporter.
+var tracer = otel.Tracer("github.com/HarryWickham/particle-tachyon-gps-dbus")
+
+// setupTracing exports spans over OTLP/HTTP when endpoint (OTEL_EXPORTER_OTLP_ENDPOINT) is
+// set, leaving tracing a no-op otherwise. The exporter reads the endpoint and the rest of the
+// standard OTEL_EXPORTER_OTLP_* variables itself, e.g. headers and timeouts, and OTEL_SERVICE_NAME and
+// OTEL_RESOURCE_ATTRIBUTES override the resource. The returned function flushes any pending
+// spans and must be called before exiting.
+func setupTracing(ctx context.Context, endpoint, deviceID string) (shutdown func(context.Context) error, err error) {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", "particle-tachyon-gps-dbus"),
+			attribute.String("service.version", version),
+			attribute.String("service.instance.id", deviceID),
+		),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// fixAttributes describes a fix on a span
+func fixAttributes(d *GnssData) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Bool("gnss.valid", d.Valid != 0),
+		attribute.Int("gnss.satellites_in_view", d.SatellitesInView()),
+	}
+}
+
+// annotateFix adds the fix's attributes to the span in ctx, if any
+func annotateFix(ctx context.Context, d *GnssData) {
+	trace.SpanFromContext(ctx).SetAttributes(fixAttributes(d)...)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	spanRecorderOnce sync.Once
+	spanRecorder     *tracetest.SpanRecorder
+)
+
+// recordSpans returns the spans ended by fn. tracer delegates to the first global provider
+// installed, so a single recording provider is shared by every test.
+func recordSpans(t *testing.T, fn func()) []sdktrace.ReadOnlySpan {
+	t.Helper()
+	spanRecorderOnce.Do(func() {
+		spanRecorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+	before := len(spanRecorder.Ended())
+	fn()
+	return spanRecorder.Ended()[before:]
+}
+
+// spanAttributes returns a span's attributes by key
+func spanAttributes(s sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range s.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestPollSpans(t *testing.T) {
+	data := &GnssData{Valid: 1, Svnum: 9, BeidouSvnum: 4}
+	spans := recordSpans(t, func() {
+		// Nested as in the poll loop
+		ctx, poll := tracer.Start(context.Background(), "gnss.poll")
+		_, read := tracer.Start(ctx, "gnss.read")
+		read.End()
+		annotateFix(ctx, data)
+		_, publish := tracer.Start(ctx, "gnss.publish", trace.WithAttributes(fixAttributes(data)...))
+		publish.End()
+		poll.End()
+	})
+
+	var names []string
+	for _, s := range spans {
+		names = append(names, s.Name())
+	}
+	if want := []string{"gnss.read", "gnss.publish", "gnss.poll"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("ended spans %q, want %q", names, want)
+	}
+	read, publish, poll := spans[0], spans[1], spans[2]
+	for _, child := range []sdktrace.ReadOnlySpan{read, publish} {
+		if child.Parent().SpanID() != poll.SpanContext().SpanID() {
+			t.Errorf("%s isn't a child of gnss.poll", child.Name())
+		}
+	}
+	for _, s := range []sdktrace.ReadOnlySpan{poll, publish} {
+		attrs := spanAttributes(s)
+		if v, ok := attrs["gnss.valid"]; !ok || !v.AsBool() {
+			t.Errorf("%s gnss.valid = %v, want true", s.Name(), v.Emit())
+		}
+		if v, ok := attrs["gnss.satellites_in_view"]; !ok || v.AsInt64() != 13 {
+			t.Errorf("%s gnss.satellites_in_view = %v, want 13", s.Name(), v.Emit())
+		}
+	}
+	if attrs := spanAttributes(read); len(attrs) != 0 {
+		t.Errorf("gnss.read attributes = %v, want none", attrs)
+	}
+}
+
+func TestFixAttributes(t *testing.T) {
+	tests := []struct {
+		name string
+		data GnssData
+		want []attribute.KeyValue
+	}{
+		{
+			name: "valid fix",
+			data: GnssData{Valid: 1, Svnum: 8, BeidouSvnum: 5, GlonassSvnum: 3, GalileoSvnum: 2},
+			want: []attribute.KeyValue{attribute.Bool("gnss.valid", true), attribute.Int("gnss.satellites_in_view", 18)},
+		},
+		{
+			name: "no fix",
+			data: GnssData{},
+			want: []attribute.KeyValue{attribute.Bool("gnss.valid", false), attribute.Int("gnss.satellites_in_view", 0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fixAttributes(&tt.data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fixAttributes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetupTracingWithoutEndpoint(t *testing.T) {
+	provider := otel.GetTracerProvider()
+	shutdown, err := setupTracing(context.Background(), "", "tachyon-1")
+	if err != nil {
+		t.Fatalf("setupTracing() = %v", err)
+	}
+	if otel.GetTracerProvider() != provider {
+		t.Error("setupTracing() without an endpoint replaced the tracer provider")
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() = %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// UTM is only defined between these latitudes; the polar regions use UPS instead
+const (
+	UTMMinLatitude = -80.0
+	UTMMaxLatitude = 84.0
+)
+
+// WGS84 ellipsoid and UTM projection constants
+const (
+	wgs84A       = 6378137.0
+	wgs84F       = 1 / 298.257223563
+	utmK0        = 0.9996
+	utmFalseEast = 500000.0
+	utmFalseNrth = 10000000.0 // Added to southern hemisphere northings
+)
+
+// utmZone returns the UTM zone number for a coordinate, including the Norway (32V) and
+// Svalbard (31X-37X) exceptions. Longitude 180 belongs to zone 60.
+func utmZone(lat, lon float64) int {
+	zone := int(math.Floor((lon+180)/6)) + 1
+	if zone > 60 {
+		zone = 60
+	}
+	switch {
+	case lat >= 56 && lat < 64 && lon >= 3 && lon < 12:
+		zone = 32
+	case lat >= 72 && lon >= 0 && lon < 9:
+		zone = 31
+	case lat >= 72 && lon >= 9 && lon < 21:
+		zone = 33
+	case lat >= 72 && lon >= 21 && lon < 33:
+		zone = 35
+	case lat >= 72 && lon >= 33 && lon < 42:
+		zone = 37
+	}
+	return zone
+}
+
+// ToUTM projects a WGS84 coordinate in degrees onto its UTM zone using Krüger's series for the
+// transverse Mercator projection, which is accurate to well under a millimeter within a zone.
+// Hemisphere is "N" or "S". Latitudes outside UTM's -80 to 84 degree range are an error.
+func ToUTM(lat, lon float64) (zone int, hemisphere string, easting, northing float64, err error) {
+	if !(lat >= UTMMinLatitude && lat <= UTMMaxLatitude) {
+		return 0, "", 0, 0, fmt.Errorf("latitude %g is outside the UTM range %g to %g", lat, UTMMinLatitude, UTMMaxLatitude)
+	}
+	if !(lon >= -180 && lon <= 180) {
+		return 0, "", 0, 0, fmt.Errorf("longitude %g is out of range", lon)
+	}
+	zone = utmZone(lat, lon)
+	centralMeridian := float64(zone*6 - 183)
+
+	n := wgs84F / (2 - wgs84F)
+	n2, n3 := n*n, n*n*n
+	rectifyingRadius := wgs84A / (1 + n) * (1 + n2/4 + n2*n2/64)
+	alpha := [3]float64{
+		n/2 - 2*n2/3 + 5*n3/16,
+		13*n2/48 - 3*n3/5,
+		61 * n3 / 240,
+	}
+
+	phi := lat * math.Pi / 180
+	lambda := (lon - centralMeridian) * math.Pi / 180
+	e := 2 * math.Sqrt(n) / (1 + n) // First eccentricity
+	t := math.Sinh(math.Atanh(math.Sin(phi)) - e*math.Atanh(e*math.Sin(phi)))
+	xiP := math.Atan2(t, math.Cos(lambda))
+	etaP := math.Atanh(math.Sin(lambda) / math.Sqrt(1+t*t))
+	xi, eta := xiP, etaP
+	for j, a := range alpha {
+		k := 2 * float64(j+1)
+		xi += a * math.Sin(k*xiP) * math.Cosh(k*etaP)
+		eta += a * math.Cos(k*xiP) * math.Sinh(k*etaP)
+	}
+
+	easting = utmFalseEast + utmK0*rectifyingRadius*eta
+	northing = utmK0 * rectifyingRadius * xi
+	hemisphere = "N"
+	if lat < 0 {
+		hemisphere = "S"
+		northing += utmFalseNrth
+	}
+	return zone, hemisphere, easting, northing, nil
+}
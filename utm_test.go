@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestToUTMReferenceValues(t *testing.T) {
+	tests := []struct {
+		name              string
+		lat, lon          float64
+		zone              int
+		hemisphere        string
+		easting, northing float64
+	}{
+		// The first three are widely published; the rest come from a sixth-order Krüger series,
+		// which agrees with the exact projection to well under a millimeter
+		{"central meridian at 45N", 45, 3, 31, "N", 500000, 4982950.4002},
+		{"equator", 0, -177, 1, "N", 500000, 0},
+		{"zone edge on the equator", 0, -180, 1, "N", 166021.4432, 0},
+		{"London", 51.5007, -0.1246, 30, "N", 699567.5395, 5709427.5625},
+		{"New York", 40.7484, -73.9857, 18, "N", 585628.4091, 4511322.4475},
+		{"Sydney", -33.8688, 151.2093, 56, "S", 334368.6336, 6250948.3454},
+		{"Oslo in the widened zone 32V", 59.9139, 10.7522, 32, "N", 597979.9029, 6643118.9915},
+		{"Svalbard in zone 33X", 78.2232, 15.6267, 33, "N", 514278.7151, 8683355.4695},
+		{"northern limit", 84, 0, 31, "N", 465005.3449, 9329005.1824},
+		{"southern limit", -80, -179.5, 1, "S", 451550.1297, 1117373.0551},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone, hemisphere, easting, northing, err := ToUTM(tt.lat, tt.lon)
+			if err != nil {
+				t.Fatalf("ToUTM() = %v", err)
+			}
+			if zone != tt.zone || hemisphere != tt.hemisphere {
+				t.Errorf("ToUTM() zone %d%s, want %d%s", zone, hemisphere, tt.zone, tt.hemisphere)
+			}
+			if math.Abs(easting-tt.easting) > 0.01 || math.Abs(northing-tt.northing) > 0.01 {
+				t.Errorf("ToUTM() = %.4f E %.4f N, want %.4f E %.4f N within a centimeter",
+					easting, northing, tt.easting, tt.northing)
+			}
+		})
+	}
+}
+
+func TestToUTMOutOfRange(t *testing.T) {
+	for _, c := range []struct{ lat, lon float64 }{
+		{84.0001, 0}, {-80.0001, 0}, {90, 0}, {-90, 0}, {math.NaN(), 0}, {0, 180.5}, {0, -181}, {0, math.NaN()},
+	} {
+		if zone, _, _, _, err := ToUTM(c.lat, c.lon); err == nil {
+			t.Errorf("ToUTM(%v, %v) = zone %d, want an error", c.lat, c.lon, zone)
+		}
+	}
+}
+
+func TestUTMZone(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     int
+	}{
+		{"antimeridian west", 0, -180, 1},
+		{"zone boundary belongs to the east", 0, -174, 2},
+		{"prime meridian", 51.5, 0, 31},
+		{"just west of the prime meridian", 51.5, -0.0001, 30},
+		{"antimeridian east", 0, 180, 60},
+		{"southern Norway coast in 32V", 60, 4, 32},
+		{"32V doesn't reach below 56N", 55.9, 4, 31},
+		{"west of the 32V widening", 60, 2.9, 31},
+		{"Svalbard 31X", 78, 8.9, 31},
+		{"Svalbard 33X", 78, 9, 33},
+		{"Svalbard 35X", 78, 25, 35},
+		{"Svalbard 37X", 78, 41.9, 37},
+		{"east of Svalbard", 78, 42, 38},
+	}
+	for _, tt := range tests {
+		if got := utmZone(tt.lat, tt.lon); got != tt.want {
+			t.Errorf("%s: utmZone(%v, %v) = %d, want %d", tt.name, tt.lat, tt.lon, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package main
+
+import "fmt"
+
+// Build metadata, set with -ldflags "-X main.version=<version> -X main.commit=<sha> -X main.buildDate=<date>"
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+// versionString formats the build metadata as a single line for -version and the startup log
+func versionString() string {
+	return fmt.Sprintf("particle-tachyon-gps-dbus %s (commit %s, built %s)", version, commit, buildDate)
+}
@@ -0,0 +1,39 @@
+package main
+
+import "time"
+
+// VerticalSpeedSmoothing is the weight given to the newest climb rate in the exponential
+// moving average, low enough to damp altitude jitter without lagging a real climb for long
+const VerticalSpeedSmoothing = 0.3
+
+// VerticalSpeedTracker derives the climb rate from the altitude change between consecutive
+// valid fixes, smoothed with an exponential moving average
+type VerticalSpeedTracker struct {
+	altitude float64   // Altitude of the previous fix
+	at       time.Time // Time of the previous fix
+	speed    float64   // Smoothed vertical speed in m/s
+	havePrev bool
+	haveRate bool
+}
+
+// Update feeds the altitude of a valid fix taken at the given time and returns the smoothed
+// vertical speed in m/s, positive when climbing. It returns false until two fixes with distinct
+// times have been seen; fixes that don't advance the time are ignored.
+func (t *VerticalSpeedTracker) Update(altitude float64, at time.Time) (float64, bool) {
+	if !t.havePrev {
+		t.altitude, t.at, t.havePrev = altitude, at, true
+		return 0, false
+	}
+	dt := at.Sub(t.at).Seconds()
+	if dt <= 0 {
+		return t.speed, t.haveRate
+	}
+	rate := (altitude - t.altitude) / dt
+	t.altitude, t.at = altitude, at
+	if t.haveRate {
+		t.speed += VerticalSpeedSmoothing * (rate - t.speed)
+	} else {
+		t.speed, t.haveRate = rate, true
+	}
+	return t.speed, true
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestVerticalSpeedAscendingTrack(t *testing.T) {
+	var tracker VerticalSpeedTracker
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if _, ok := tracker.Update(100, start); ok {
+		t.Fatal("Update() reported a speed from a single fix")
+	}
+	// A steady 2.5 m/s climb, one fix every 2 seconds
+	for i := 1; i <= 10; i++ {
+		speed, ok := tracker.Update(100+5*float64(i), start.Add(time.Duration(2*i)*time.Second))
+		if !ok || math.Abs(speed-2.5) > 1e-9 {
+			t.Errorf("fix %d: Update() = %v, %t, want 2.5 m/s", i, speed, ok)
+		}
+	}
+}
+
+func TestVerticalSpeedSmoothing(t *testing.T) {
+	var tracker VerticalSpeedTracker
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker.Update(100, start)
+	tracker.Update(102, start.Add(time.Second)) // 2 m/s
+	// A 10 m jump in one second is mostly damped
+	speed, _ := tracker.Update(112, start.Add(2*time.Second))
+	if want := 2 + VerticalSpeedSmoothing*(10-2); math.Abs(speed-want) > 1e-9 {
+		t.Errorf("speed after a jump = %v, want %v", speed, want)
+	}
+	// Descending pulls it negative
+	for i := 3; i < 30; i++ {
+		speed, _ = tracker.Update(112-3*float64(i-2), start.Add(time.Duration(i)*time.Second))
+	}
+	if math.Abs(speed+3) > 0.01 {
+		t.Errorf("speed after a long 3 m/s descent = %v, want about -3", speed)
+	}
+}
+
+func TestVerticalSpeedZeroTimeDelta(t *testing.T) {
+	var tracker VerticalSpeedTracker
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker.Update(100, start)
+	if speed, ok := tracker.Update(150, start); ok || speed != 0 {
+		t.Errorf("Update() at the same time = %v, %t, want no speed", speed, ok)
+	}
+	tracker.Update(101, start.Add(time.Second))
+	for _, at := range []time.Time{start.Add(time.Second), start} {
+		speed, ok := tracker.Update(500, at)
+		if !ok || speed != 1 {
+			t.Errorf("Update() without the time advancing = %v, %t, want the previous 1 m/s", speed, ok)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// WebhookSink marks queued messages that failed to reach the webhook rather than MQTT
+	WebhookSink = "webhook"
+	// DefaultWebhookTimeout bounds a single webhook request when WEBHOOK_TIMEOUT_SECONDS is unset
+	DefaultWebhookTimeout = 10 * time.Second
+	// webhookMinBackoff is the first retry delay after a failed request
+	webhookMinBackoff = time.Second
+)
+
+// WebhookPublisher POSTs fix payloads to an HTTP endpoint. After a failure it backs off
+// exponentially, like the MQTT client's reconnects: payloads published before the backoff
+// expires fail straight away, without a request, so they can be queued.
+type WebhookPublisher struct {
+	URL             string
+	Headers         map[string]string // Extra request headers, e.g. Authorization
+	ContentType     string            // Content type of the payloads, e.g. application/json
+	ContentEncoding string            // Content-Encoding of the payloads, e.g. gzip, or empty
+	MaxBackoff      time.Duration     // Longest delay between attempts after repeated failures
+	Client          *http.Client
+	Clock           Clock
+
+	mu      sync.Mutex
+	backoff time.Duration // Delay after the next failure, 0 while healthy
+	retryAt time.Time     // No requests are made before this time
+}
+
+// NewRequest builds the POST request for payload
+func (p *WebhookPublisher) NewRequest(ctx context.Context, payload []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	if p.ContentType != "" {
+		req.Header.Set("Content-Type", p.ContentType)
+	}
+	if p.ContentEncoding != "" {
+		req.Header.Set("Content-Encoding", p.ContentEncoding)
+	}
+	for name, value := range p.Headers {
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+// Publish POSTs payload to the webhook; it has no topics or message properties so both are
+// ignored. Any response other than 2xx is a failure.
+func (p *WebhookPublisher) Publish(ctx context.Context, _ string, payload []byte, _ map[string]string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if wait := p.retryAt.Sub(p.Clock.Now()); wait > 0 {
+		return fmt.Errorf("webhook backing off for %s after a failure", wait.Round(time.Second))
+	}
+	err := p.post(ctx, payload)
+	if err != nil {
+		p.backoff = min(max(2*p.backoff, webhookMinBackoff), p.MaxBackoff)
+		p.retryAt = p.Clock.Now().Add(p.backoff)
+		return err
+	}
+	p.backoff = 0
+	return nil
+}
+
+// post makes a single request
+func (p *WebhookPublisher) post(ctx context.Context, payload []byte) error {
+	req, err := p.NewRequest(ctx, payload)
+	if err != nil {
+		return err
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	// Drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookRequest is a request received by a test webhook server
+type webhookRequest struct {
+	method string
+	header http.Header
+	body   []byte
+}
+
+// newWebhookServer starts a server recording the requests it receives and answering each with
+// the next of statuses, then 204 once they run out
+func newWebhookServer(t *testing.T, statuses ...int) (*httptest.Server, func() []webhookRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []webhookRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, webhookRequest{r.Method, r.Header.Clone(), body})
+		status := http.StatusNoContent
+		if len(statuses) > 0 {
+			status, statuses = statuses[0], statuses[1:]
+		}
+		mu.Unlock()
+		if status/100 != 2 {
+			http.Error(w, "rejected", status)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []webhookRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]webhookRequest(nil), requests...)
+	}
+}
+
+func TestWebhookPostsBodyAndHeaders(t *testing.T) {
+	srv, received := newWebhookServer(t)
+	p := &WebhookPublisher{
+		URL:         srv.URL + "/gnss",
+		ContentType: "application/json",
+		Headers:     map[string]string{"Authorization": "Bearer s3cret", "X-Device": "tachyon-1"},
+		MaxBackoff:  time.Minute,
+		Client:      srv.Client(),
+		Clock:       newFakeClock(time.Unix(0, 0)),
+	}
+	payload, err := json.Marshal(GnssData{Latitude: 51.5007, Longitude: -0.1246, Valid: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Publish(context.Background(), "ignored/topic", payload, nil); err != nil {
+		t.Fatalf("Publish() = %v", err)
+	}
+	reqs := received()
+	if len(reqs) != 1 {
+		t.Fatalf("server received %d requests, want 1", len(reqs))
+	}
+	req := reqs[0]
+	if req.method != http.MethodPost {
+		t.Errorf("method = %s, want POST", req.method)
+	}
+	if string(req.body) != string(payload) {
+		t.Errorf("body = %s, want %s", req.body, payload)
+	}
+	var got GnssData
+	if err := json.Unmarshal(req.body, &got); err != nil || got.Latitude != 51.5007 || got.Valid != 1 {
+		t.Errorf("body decodes to %+v, %v, want the published fix", got, err)
+	}
+	for name, want := range map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer s3cret",
+		"X-Device":      "tachyon-1",
+	} {
+		if v := req.header.Get(name); v != want {
+			t.Errorf("header %s = %q, want %q", name, v, want)
+		}
+	}
+	if v := req.header.Get("Content-Encoding"); v != "" {
+		t.Errorf("Content-Encoding = %q, want none for uncompressed payloads", v)
+	}
+}
+
+func TestWebhookHeadersOverrideContentType(t *testing.T) {
+	p := &WebhookPublisher{
+		URL:             "http://example.com/hook",
+		ContentType:     "application/json",
+		ContentEncoding: PayloadCompressionGzip,
+		Headers:         map[string]string{"Content-Type": "application/vnd.gnss+json"},
+	}
+	req, err := p.NewRequest(context.Background(), []byte("{}"))
+	if err != nil {
+		t.Fatalf("NewRequest() = %v", err)
+	}
+	if req.Header.Get("Content-Type") != "application/vnd.gnss+json" || req.Header.Get("Content-Encoding") != "gzip" {
+		t.Errorf("headers = %v, want the configured Content-Type and gzip encoding", req.Header)
+	}
+}
+
+func TestWebhookFailuresBackOff(t *testing.T) {
+	srv, received := newWebhookServer(t, http.StatusUnauthorized, http.StatusServiceUnavailable)
+	clock := newFakeClock(time.Unix(0, 0))
+	p := &WebhookPublisher{URL: srv.URL, MaxBackoff: 3 * time.Second, Client: srv.Client(), Clock: clock}
+	publish := func() error { return p.Publish(context.Background(), "", []byte("{}"), nil) }
+
+	err := publish()
+	if err == nil || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "rejected") {
+		t.Fatalf("Publish() = %v, want the 401 reported as a failure", err)
+	}
+	// Backing off for a second, so nothing is sent
+	if err := publish(); err == nil {
+		t.Error("Publish() during the backoff succeeded, want it to fail so the fix is queued")
+	}
+	if n := len(received()); n != 1 {
+		t.Errorf("server received %d requests, want 1 while backing off", n)
+	}
+
+	clock.Advance(time.Second)
+	if err := publish(); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("Publish() = %v, want the 503 reported as a failure", err)
+	}
+	// The backoff doubled to 2s
+	clock.Advance(time.Second)
+	if err := publish(); err == nil {
+		t.Error("Publish() 1s into a 2s backoff succeeded")
+	}
+	clock.Advance(time.Second)
+	if err := publish(); err != nil {
+		t.Fatalf("Publish() after the backoff = %v", err)
+	}
+	if n := len(received()); n != 3 {
+		t.Errorf("server received %d requests, want 3", n)
+	}
+}
+
+func TestWebhookUnreachable(t *testing.T) {
+	srv, _ := newWebhookServer(t)
+	srv.Close()
+	p := &WebhookPublisher{URL: srv.URL, MaxBackoff: time.Minute, Client: srv.Client(), Clock: newFakeClock(time.Unix(0, 0))}
+	if err := p.Publish(context.Background(), "", []byte("{}"), nil); err == nil || !strings.Contains(err.Error(), "webhook request failed") {
+		t.Errorf("Publish() to a closed server = %v, want a request failure", err)
+	}
+}
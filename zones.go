@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Zone is a named area made up of one or more GeoJSON polygons
+type Zone struct {
+	Name     string          // Zone name, taken from the feature's "name" property
+	Polygons [][][][]float64 // Polygons, each a list of rings of [lon, lat] vertices; rings after the first are holes
+}
+
+// ZoneEvent describes a transition into or out of a zone
+type ZoneEvent struct {
+	Event     string  `json:"event"` // "enter" or "exit"
+	Zone      string  `json:"zone"`  // Zone name
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// geoJSONFeatureCollection is the subset of a GeoJSON FeatureCollection needed to load zones
+type geoJSONFeatureCollection struct {
+	Type     string `json:"type"`
+	Features []struct {
+		Properties map[string]any `json:"properties"`
+		Geometry   struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+// LoadZones reads named Polygon/MultiPolygon features from a GeoJSON FeatureCollection file
+func LoadZones(path string) ([]Zone, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zones file: %w", err)
+	}
+	var fc geoJSONFeatureCollection
+	if err := json.Unmarshal(raw, &fc); err != nil {
+		return nil, fmt.Errorf("failed to parse zones file: %w", err)
+	}
+	if fc.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("zones file must be a GeoJSON FeatureCollection, got %q", fc.Type)
+	}
+	zones := make([]Zone, 0, len(fc.Features))
+	for i, f := range fc.Features {
+		name, _ := f.Properties["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("zone feature %d has no \"name\" property", i)
+		}
+		zone := Zone{Name: name}
+		switch f.Geometry.Type {
+		case "Polygon":
+			var poly [][][]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &poly); err != nil {
+				return nil, fmt.Errorf("zone %q: invalid Polygon coordinates: %w", name, err)
+			}
+			zone.Polygons = [][][][]float64{poly}
+		case "MultiPolygon":
+			if err := json.Unmarshal(f.Geometry.Coordinates, &zone.Polygons); err != nil {
+				return nil, fmt.Errorf("zone %q: invalid MultiPolygon coordinates: %w", name, err)
+			}
+		default:
+			return nil, fmt.Errorf("zone %q: unsupported geometry type %q", name, f.Geometry.Type)
+		}
+		zones = append(zones, zone)
+	}
+	return zones, nil
+}
+
+// Contains reports whether the point lies inside any of the zone's polygons, excluding holes
+func (z *Zone) Contains(lat, lon float64) bool {
+	for _, rings := range z.Polygons {
+		if len(rings) == 0 || !pointInPolygon(lat, lon, rings[0]) {
+			continue
+		}
+		inHole := false
+		for _, hole := range rings[1:] {
+			if pointInPolygon(lat, lon, hole) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// pointInPolygon reports whether the point lies inside poly using ray casting.
+// Vertices are in GeoJSON [lon, lat] order; the ring may be open or closed.
+func pointInPolygon(lat, lon float64, poly [][]float64) bool {
+	inside := false
+	n := len(poly)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		if len(poly[i]) < 2 || len(poly[j]) < 2 {
+			continue
+		}
+		xi, yi := poly[i][0], poly[i][1]
+		xj, yj := poly[j][0], poly[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// ZoneTracker tracks which zones the device is in and reports transitions
+type ZoneTracker struct {
+	zones   []Zone
+	current map[string]bool
+}
+
+// NewZoneTracker creates a tracker for the given zones
+func NewZoneTracker(zones []Zone) *ZoneTracker {
+	return &ZoneTracker{zones: zones, current: make(map[string]bool)}
+}
+
+// Update evaluates the position against every zone, returning the sorted names of the
+// zones containing it and the enter/exit events since the previous update
+func (t *ZoneTracker) Update(lat, lon float64) ([]string, []ZoneEvent) {
+	var inside []string
+	var events []ZoneEvent
+	for i := range t.zones {
+		zone := &t.zones[i]
+		contains := zone.Contains(lat, lon)
+		if contains {
+			inside = append(inside, zone.Name)
+		}
+		if contains == t.current[zone.Name] {
+			continue
+		}
+		t.current[zone.Name] = contains
+		event := ZoneEvent{Event: "exit", Zone: zone.Name, Latitude: lat, Longitude: lon}
+		if contains {
+			event.Event = "enter"
+		}
+		events = append(events, event)
+	}
+	sort.Strings(inside)
+	return inside, events
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// uShape is a concave polygon, a U opening north, with vertices in [lon, lat] order:
+//
+//	(0,4)  (1,4)     (3,4)  (4,4)
+//	  |      |_______|      |
+//	  |     (1,1)   (3,1)   |
+//	(0,0)___________________(4,0)
+var uShape = [][]float64{{0, 0}, {4, 0}, {4, 4}, {3, 4}, {3, 1}, {1, 1}, {1, 4}, {0, 4}}
+
+func TestPointInPolygon(t *testing.T) {
+	square := [][]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	closedSquare := append(square, []float64{0, 0})
+	tests := []struct {
+		name     string
+		lat, lon float64
+		poly     [][]float64
+		want     bool
+	}{
+		{"inside square", 5, 5, square, true},
+		{"outside square", 15, 5, square, false},
+		{"west of square", 5, -1, square, false},
+		{"closed ring", 5, 5, closedSquare, true},
+		{"outside closed ring", -5, 5, closedSquare, false},
+		{"concave left arm", 3, 0.5, uShape, true},
+		{"concave right arm", 3, 3.5, uShape, true},
+		{"concave base", 0.5, 2, uShape, true},
+		{"concave notch", 3, 2, uShape, false},
+		{"level with notch floor", 1, 2, uShape, false},
+		{"beyond concave arms", 5, 2, uShape, false},
+		{"ray through vertex", 2, -1, [][]float64{{0, 0}, {2, 2}, {0, 4}, {-2, 2}}, true},
+		{"empty polygon", 0, 0, nil, false},
+		{"two vertices", 1, 1, [][]float64{{0, 0}, {2, 2}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pointInPolygon(tt.lat, tt.lon, tt.poly); got != tt.want {
+				t.Errorf("pointInPolygon(%v, %v) = %t, want %t", tt.lat, tt.lon, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZoneContainsExcludesHoles(t *testing.T) {
+	zone := Zone{Name: "yard", Polygons: [][][][]float64{{
+		{{0, 0}, {10, 0}, {10, 10}, {0, 10}},
+		{{4, 4}, {6, 4}, {6, 6}, {4, 6}},
+	}}}
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     bool
+	}{
+		{"inside outer ring", 2, 2, true},
+		{"inside hole", 5, 5, false},
+		{"outside", 20, 20, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := zone.Contains(tt.lat, tt.lon); got != tt.want {
+				t.Errorf("Contains(%v, %v) = %t, want %t", tt.lat, tt.lon, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadZones(t *testing.T) {
+	tests := []struct {
+		name    string
+		geojson string
+		want    []string // Zone names, nil when loading should fail
+	}{
+		{
+			name: "polygon and multipolygon",
+			geojson: `{"type": "FeatureCollection", "features": [
+				{"properties": {"name": "depot"}, "geometry": {"type": "Polygon", "coordinates": [[[0,0],[1,0],[1,1],[0,1]]]}},
+				{"properties": {"name": "sites"}, "geometry": {"type": "MultiPolygon", "coordinates": [[[[2,2],[3,2],[3,3]]], [[[5,5],[6,5],[6,6]]]]}}
+			]}`,
+			want: []string{"depot", "sites"},
+		},
+		{"not a feature collection", `{"type": "Feature"}`, nil},
+		{"unnamed feature", `{"type": "FeatureCollection", "features": [{"geometry": {"type": "Polygon", "coordinates": []}}]}`, nil},
+		{"unsupported geometry", `{"type": "FeatureCollection", "features": [{"properties": {"name": "a"}, "geometry": {"type": "Point", "coordinates": [0,0]}}]}`, nil},
+		{"invalid JSON", `{`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "zones.geojson")
+			if err := os.WriteFile(path, []byte(tt.geojson), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			zones, err := LoadZones(path)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("LoadZones() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, z := range zones {
+				names = append(names, z.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("zones = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestZoneTrackerEvents(t *testing.T) {
+	tracker := NewZoneTracker([]Zone{
+		{Name: "b", Polygons: [][][][]float64{{{{0, 0}, {10, 0}, {10, 10}, {0, 10}}}}},
+		{Name: "a", Polygons: [][][][]float64{{{{5, 5}, {15, 5}, {15, 15}, {5, 15}}}}},
+	})
+	steps := []struct {
+		lat, lon float64
+		inside   []string
+		events   []string // "<event> <zone>"
+	}{
+		{20, 20, nil, nil},
+		{2, 2, []string{"b"}, []string{"enter b"}},
+		{7, 7, []string{"a", "b"}, []string{"enter a"}},
+		{7, 7, []string{"a", "b"}, nil},
+		{12, 12, []string{"a"}, []string{"exit b"}},
+		{20, 20, nil, []string{"exit a"}},
+	}
+	for i, step := range steps {
+		inside, events := tracker.Update(step.lat, step.lon)
+		var got []string
+		for _, e := range events {
+			got = append(got, e.Event+" "+e.Zone)
+		}
+		if !reflect.DeepEqual(inside, step.inside) || !reflect.DeepEqual(got, step.events) {
+			t.Errorf("step %d: Update(%v, %v) = %v, %v, want %v, %v", i, step.lat, step.lon, inside, got, step.inside, step.events)
+		}
+	}
+}